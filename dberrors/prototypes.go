@@ -59,6 +59,10 @@ var (
 
 	// Unspecified Error - all other errors not included in this division
 	ErrUnspecifiedError = Error{ID: 26, Title: "Unspecified error"}
+
+	// Not Supported - the repository does not support the operation, i.e. the repository
+	// decorator could not upsert if the decorated repository is not an Upserter
+	ErrNotSupported = Error{ID: 27, Title: "Not supported"}
 )
 
 var prototypeMap = map[uint]Error{
@@ -88,4 +92,5 @@ var prototypeMap = map[uint]Error{
 	uint(24): ErrSystemError,
 	uint(25): ErrInternalError,
 	uint(26): ErrUnspecifiedError,
+	uint(27): ErrNotSupported,
 }
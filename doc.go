@@ -11,18 +11,23 @@ Almost all components are based on the interfaces. That enables combining multip
 independent third-party tools. This solution allows to easily develop
 components either based on the 'go-rest-sdk' prepared tools or on custom implementations.
 
-The package is divided into eight main components:
+The package is divided into eleven main components:
 	dberrors 	# unifies the database errors. Defines the 'Converter' interface and database Errors prototypes
 	errhandler	# handles is a mapping of database errors into resterrors. Defines 'ErrorHandler'
 			that Handles provided 'dberrors.Error' and maps into 'resterrors.Error'
 	forms		# enables binding provided model to different form types.
+	generic		# contains the type-safe 'Repository[T]' and 'Handler[T]' based on the Go generics.
 	handlers	# joins 'go-rest-sdk' packages to create model, web framework and database
 			repository independent RESTful handlers.
+	metrics		# instruments the repositories and handlers with the metrics exposed in the
+			Prometheus text format.
 	refutils	# contains reflect encapsulations useful for other subpackages
 	repository	# defines database and models repositories. Defines 'Repository' interface.
 	response	# contains body for the RESTful API responses. Defines 'Responser' and
 			'StatusResponser' interfaces.
 	resterrors	# defines RESTful response 'Errors', and their prototypes.
+	tracing		# traces the handlers, bindings and repositories with the spans propagated
+			using the W3C 'traceparent' header.
 
 */
 package sdk
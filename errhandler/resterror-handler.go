@@ -34,6 +34,7 @@ var DefaultErrorMap map[dberrors.Error]resterrors.Error = map[dberrors.Error]res
 	dberrors.ErrSystemError:           resterrors.ErrInternalError,
 	dberrors.ErrInternalError:         resterrors.ErrInternalError,
 	dberrors.ErrUnspecifiedError:      resterrors.ErrInternalError,
+	dberrors.ErrNotSupported:          resterrors.ErrInternalError,
 }
 
 // ErrorHandler defines the database dberrors.Error one-to-one mapping
@@ -0,0 +1,112 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"reflect"
+	"strings"
+)
+
+// fieldRules defines the checks of the decoded JSON document fields.
+type fieldRules struct {
+	// writable requires the fields to be writable by the clients (see refutils.FieldAccess)
+	writable bool
+
+	// create defines if the model is being created
+	create bool
+
+	// known requires the fields to match the model's fields
+	known bool
+}
+
+// checkFields checks if the decoded JSON 'value' sets only the fields of type 't'
+// allowed by the 'rules'. The nested objects, arrays and maps are checked recursively.
+// Returns the *resterrors.Error of ErrUnsupportedJSONField prototype with the path
+// of the first not allowed field.
+func checkFields(t reflect.Type, value interface{}, rules fieldRules, path []string) error {
+	t = indirectType(t)
+	if refutils.IsJSONMarshaler(t) || (!rules.known && !refutils.HasAccessRules(t)) {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			for key, elem := range v {
+				fieldPath := append(path, key)
+				field, ok := refutils.JSONFieldByName(t, key)
+				if !ok {
+					if rules.known {
+						return unknownField(fieldPath)
+					}
+					continue
+				}
+				if rules.writable && !field.Access.Writable(rules.create) {
+					return notWritable(fieldPath, field.Access)
+				}
+				if err := checkFields(field.Field.Type, elem, rules, fieldPath); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			for key, elem := range v {
+				if err := checkFields(t.Elem(), elem, rules, append(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, elem := range v {
+				if err := checkFields(t.Elem(), elem, rules, append(path, "-")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkPathAccess checks if the fields of type 't' referenced by the 'path' tokens
+// are writable by the clients. Returns the type of the value referenced by the path.
+func checkPathAccess(t reflect.Type, path []string, create bool) (reflect.Type, error) {
+	for i, token := range path {
+		t = indirectType(t)
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := refutils.JSONFieldByName(t, token)
+			if !ok {
+				return nil, nil
+			}
+			if !field.Access.Writable(create) {
+				return nil, notWritable(path[:i+1], field.Access)
+			}
+			t = field.Field.Type
+		case reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, nil
+		}
+	}
+	return t, nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func notWritable(path []string, access refutils.FieldAccess) error {
+	restErr := resterrors.ErrUnsupportedJSONField.New()
+	restErr.AddDetailInfo("Field: '" + strings.Join(path, ".") + "' is " + access.String())
+	return restErr
+}
+
+func unknownField(path []string) error {
+	restErr := resterrors.ErrUnsupportedJSONField.New()
+	restErr.AddDetailInfo("Unsupported field: '" + strings.Join(path, ".") + "'")
+	return restErr
+}
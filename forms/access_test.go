@@ -0,0 +1,84 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type accessAddress struct {
+	ID   int    `json:"id" rest:"readonly"`
+	City string `json:"city"`
+}
+
+type accessUser struct {
+	ID        int             `json:"id" rest:"readonly"`
+	Login     string          `json:"login" rest:"createonly"`
+	Password  string          `json:"password" rest:"writeonly"`
+	IsAdmin   bool            `json:"is_admin" rest:"hidden"`
+	Name      string          `json:"name"`
+	Addresses []accessAddress `json:"addresses"`
+}
+
+func bindAccessUser(body string, policy *JSONPolicy) (*accessUser, error) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	model := &accessUser{}
+	return model, BindJSONWithPolicy(req, model, policy)
+}
+
+func TestFieldAccessBinding(t *testing.T) {
+	Convey("Subject: binding the fields with the 'rest' access tags", t, func() {
+		policy := DefaultJSONPolicy.Copy()
+
+		Convey("The writable fields are bound", func() {
+			model, err := bindAccessUser(`{"login":"john","password":"secret","name":"John",
+				"addresses":[{"city":"Warsaw"}]}`, policy)
+			So(err, ShouldBeNil)
+			So(model.Login, ShouldEqual, "john")
+			So(model.Password, ShouldEqual, "secret")
+			So(model.Addresses[0].City, ShouldEqual, "Warsaw")
+		})
+
+		Convey("The readonly and hidden fields result in ErrUnsupportedJSONField", func() {
+			for _, body := range []string{`{"id":1}`, `{"IS_ADMIN":true}`, `{"addresses":[{"id":2}]}`} {
+				_, err := bindAccessUser(body, policy)
+				So(isRestError(err, resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+			}
+
+			_, err := bindAccessUser(`{"addresses":[{"city":"Warsaw","id":2}]}`, policy)
+			So(strings.Join(err.(*resterrors.Error).Detail.Info, " "), ShouldContainSubstring, "addresses.-.id")
+		})
+
+		Convey("The createonly fields are not writable on update", func() {
+			policy.Update = true
+			_, err := bindAccessUser(`{"login":"john"}`, policy)
+			So(isRestError(err, resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+
+			model, err := bindAccessUser(`{"name":"John"}`, policy)
+			So(err, ShouldBeNil)
+			So(model.Name, ShouldEqual, "John")
+		})
+
+		Convey("The patch documents could not change not writable fields", func() {
+			patch := func(mediaType, body string) error {
+				req := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(body))
+				req.Header.Set("Content-Type", mediaType)
+				_, err := BindPatch(req, &accessUser{ID: 1, Login: "john", Addresses: []accessAddress{{ID: 1}}},
+					&accessUser{}, policy)
+				return err
+			}
+
+			So(patch(MergePatchMediaType, `{"name":"John"}`), ShouldBeNil)
+			So(patch(MergePatchMediaType, `{"login":"doe"}`), ShouldNotBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"replace","path":"/password","value":"x"}]`), ShouldBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"remove","path":"/id"}]`), ShouldNotBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"replace","path":"/addresses/0/id","value":2}]`), ShouldNotBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"add","path":"/addresses/-","value":{"id":3}}]`), ShouldNotBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"copy","from":"/addresses/0","path":"/addresses/-"}]`),
+				ShouldNotBeNil)
+			So(patch(JSONPatchMediaType, `[{"op":"test","path":"/id","value":1}]`), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,136 @@
+package forms
+
+import (
+	"database/sql"
+	"encoding"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var ErrInvalidConverterResult = errors.New("Converter returned value of invalid type")
+
+// ConverterFunc converts the form or param string 'value' into the value of the type
+// it was registered for. The returned value must be assignable to that type.
+type ConverterFunc func(value string) (interface{}, error)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// customKind defines how the value of the custom type is set.
+type customKind int
+
+const (
+	notCustom customKind = iota
+	converterCustom
+	textUnmarshalerCustom
+	scannerCustom
+)
+
+var converters = struct {
+	sync.RWMutex
+	m map[reflect.Type]ConverterFunc
+}{m: make(map[reflect.Type]ConverterFunc)}
+
+// customKinds contains the customKind for the reflect.Type
+var customKinds sync.Map
+
+// RegisterConverter registers the 'converter' for the type 't'.
+// The binding functions use the converter for the fields of type 't', pointers to 't'
+// and slices of 't'. The registered converter has precedence over the
+// encoding.TextUnmarshaler and sql.Scanner implementations of the type.
+// Providing nil 'converter' removes the converter registered for 't'.
+// I.e.:
+//	forms.RegisterConverter(reflect.TypeOf(decimal.Decimal{}), func(value string) (interface{}, error) {
+//		return decimal.NewFromString(value)
+//	})
+func RegisterConverter(t reflect.Type, converter ConverterFunc) {
+	converters.Lock()
+	if converter == nil {
+		delete(converters.m, t)
+	} else {
+		converters.m[t] = converter
+	}
+	converters.Unlock()
+
+	// the cached plans and kinds may depend on the registered converters
+	resetCustomKinds()
+}
+
+func resetCustomKinds() {
+	customKinds.Range(func(key, value interface{}) bool {
+		customKinds.Delete(key)
+		return true
+	})
+	planCache.Range(func(key, value interface{}) bool {
+		planCache.Delete(key)
+		return true
+	})
+}
+
+func getConverter(t reflect.Type) ConverterFunc {
+	converters.RLock()
+	defer converters.RUnlock()
+	return converters.m[t]
+}
+
+// getCustomKind returns the customKind of the type 't'. The result is cached.
+// The time.Time is not custom, unless it has a registered converter, as it is
+// bound using the 'time_format' tags.
+func getCustomKind(t reflect.Type) customKind {
+	if kind, ok := customKinds.Load(t); ok {
+		return kind.(customKind)
+	}
+
+	kind := notCustom
+	switch {
+	case getConverter(t) != nil:
+		kind = converterCustom
+	case t == timeType || t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface:
+	case reflect.PtrTo(t).Implements(textUnmarshalerType):
+		kind = textUnmarshalerCustom
+	case reflect.PtrTo(t).Implements(scannerType):
+		kind = scannerCustom
+	}
+	customKinds.Store(t, kind)
+	return kind
+}
+
+// isCustomType checks if the type 't' is bound using the converter, encoding.TextUnmarshaler
+// or sql.Scanner.
+func isCustomType(t reflect.Type) bool {
+	return getCustomKind(t) != notCustom
+}
+
+// setCustomType sets the 'field' of custom type with 'val'.
+// Returns false if the field is not of custom type or is not addressable.
+func setCustomType(val string, field reflect.Value) (bool, error) {
+	kind := getCustomKind(field.Type())
+	if kind == notCustom || !field.CanAddr() {
+		return false, nil
+	}
+
+	switch kind {
+	case converterCustom:
+		converter := getConverter(field.Type())
+		if converter == nil {
+			return false, nil
+		}
+		result, err := converter(val)
+		if err != nil {
+			return true, err
+		}
+		value := reflect.ValueOf(result)
+		if !value.IsValid() || !value.Type().AssignableTo(field.Type()) {
+			return true, ErrInvalidConverterResult
+		}
+		field.Set(value)
+	case textUnmarshalerCustom:
+		return true, field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	case scannerCustom:
+		return true, field.Addr().Interface().(sql.Scanner).Scan(val)
+	}
+	return true, nil
+}
@@ -0,0 +1,177 @@
+package forms
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type status int
+
+const (
+	statusActive status = iota + 1
+	statusBlocked
+)
+
+func (s *status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "active":
+		*s = statusActive
+	case "blocked":
+		*s = statusBlocked
+	default:
+		return errors.New("Unknown status")
+	}
+	return nil
+}
+
+type uid [4]byte
+
+func (u *uid) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	if len(b) != len(u) {
+		return errors.New("Invalid uid length")
+	}
+	copy(u[:], b)
+	return nil
+}
+
+type money struct {
+	Amount   int64
+	Currency string
+}
+
+func convertMoney(value string) (interface{}, error) {
+	parts := strings.Split(value, " ")
+	if len(parts) != 2 {
+		return nil, errors.New("Invalid money format")
+	}
+	amount, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return money{Amount: amount, Currency: parts[1]}, nil
+}
+
+type customModel struct {
+	ID        uid             `param:"custommodel"`
+	Status    status          `form:"status"`
+	StatusPtr *status         `form:"status_ptr"`
+	Statuses  []status        `form:"statuses"`
+	Name      sql.NullString  `form:"name"`
+	Count     *sql.NullInt64  `form:"count"`
+	Price     money           `form:"price"`
+	Prices    []money         `form:"prices"`
+	Owners    []uid           `form:"owner"`
+	Limit     *int            `form:"limit"`
+	Offset    *int            `form:"offset"`
+	Nested    *customModelRef `form:"nested"`
+}
+
+type customModelRef struct {
+	Ref uid `form:"ref"`
+}
+
+func TestCustomTypes(t *testing.T) {
+	Convey("Subject: binding the custom types", t, func() {
+		RegisterConverter(reflect.TypeOf(money{}), convertMoney)
+		Reset(func() {
+			RegisterConverter(reflect.TypeOf(money{}), nil)
+		})
+
+		policy := DefaultBindPolicy.Copy()
+		policy.FailOnError = true
+
+		Convey("BindQuery honours TextUnmarshaler, Scanner and the converters", func() {
+			req := httptest.NewRequest("GET", "/models?status=blocked&status_ptr=active"+
+				"&statuses=active&statuses=blocked&name=john&count=12&price=100+PLN"+
+				"&prices=1+USD&prices=2+EUR&owner=0a0b0c0d&owner=01020304&limit=10", nil)
+			model := &customModel{}
+			So(BindQuery(req, model, policy), ShouldBeNil)
+
+			So(model.Status, ShouldEqual, statusBlocked)
+			So(*model.StatusPtr, ShouldEqual, statusActive)
+			So(model.Statuses, ShouldResemble, []status{statusActive, statusBlocked})
+			So(model.Name, ShouldResemble, sql.NullString{String: "john", Valid: true})
+			So(model.Count, ShouldResemble, &sql.NullInt64{Int64: 12, Valid: true})
+			So(model.Price, ShouldResemble, money{Amount: 100, Currency: "PLN"})
+			So(model.Prices, ShouldResemble, []money{{1, "USD"}, {2, "EUR"}})
+			So(model.Owners, ShouldResemble, []uid{{10, 11, 12, 13}, {1, 2, 3, 4}})
+			So(*model.Limit, ShouldEqual, 10)
+
+			Convey("The pointers without the value are not initialized", func() {
+				So(model.Offset, ShouldBeNil)
+				So(model.Nested, ShouldBeNil)
+			})
+		})
+
+		Convey("Nested structs containing custom types are searched", func() {
+			policy.SearchDepthLevel = 1
+			req := httptest.NewRequest("GET", "/models?ref=0a0b0c0d", nil)
+			model := &customModel{}
+			So(BindQuery(req, model, policy), ShouldBeNil)
+			So(model.Nested, ShouldNotBeNil)
+			So(model.Nested.Ref, ShouldEqual, uid{10, 11, 12, 13})
+		})
+
+		Convey("The custom type errors are returned", func() {
+			for _, query := range []string{"status=unknown", "owner=xyz", "price=100", "count=abc"} {
+				req := httptest.NewRequest("GET", "/models?"+query, nil)
+				So(BindQuery(req, &customModel{}, policy), ShouldBeError)
+			}
+		})
+
+		Convey("The converter must return the value of registered type", func() {
+			RegisterConverter(reflect.TypeOf(money{}), func(value string) (interface{}, error) {
+				return value, nil
+			})
+			req := httptest.NewRequest("GET", "/models?price=100", nil)
+			So(BindQuery(req, &customModel{}, policy), ShouldEqual, ErrInvalidConverterResult)
+		})
+
+		Convey("The registered converter has precedence over TextUnmarshaler", func() {
+			RegisterConverter(reflect.TypeOf(status(0)), func(value string) (interface{}, error) {
+				return statusBlocked, nil
+			})
+			Reset(func() {
+				RegisterConverter(reflect.TypeOf(status(0)), nil)
+			})
+			req := httptest.NewRequest("GET", "/models?status=active", nil)
+			model := &customModel{}
+			So(BindQuery(req, model, policy), ShouldBeNil)
+			So(model.Status, ShouldEqual, statusBlocked)
+		})
+
+		Convey("BindParams sets the custom type ID", func() {
+			req := httptest.NewRequest("GET", "/models/0a0b0c0d", nil)
+			model := &customModel{}
+			err := BindParams(req, model, getParamFuncWithValues(map[string]string{
+				"custommodel": "0a0b0c0d",
+			}), DefaultParamPolicy.Copy())
+			So(err, ShouldBeNil)
+			So(model.ID, ShouldEqual, uid{10, 11, 12, 13})
+		})
+
+		Convey("SetID and SetField set the custom types", func() {
+			type Model struct {
+				ID     uid
+				Status *status
+			}
+			model := &Model{}
+			So(SetID(model, "01020304"), ShouldBeNil)
+			So(model.ID, ShouldEqual, uid{1, 2, 3, 4})
+
+			So(SetField(model, "Status", "blocked"), ShouldBeNil)
+			So(*model.Status, ShouldEqual, statusBlocked)
+		})
+	})
+}
@@ -16,7 +16,13 @@ Few functions were fetched from github.com/gin-gonic/gin/binding.
 Binding functions:
 	BindQuery	- used to set the query parameters into model
 	BindJSON	- binds json form into provided model
+	BindJSONWithPolicy - binds json form into provided model using the JSONPolicy rules
 	BindParams	- binds the url routing parameters to the given model
+	BindForm	- binds the url encoded or multipart form body into provided model
+	BindMultipart	- binds the multipart form values and uploaded files into provided model
+	BindPatch	- applies the json merge patch or json patch into provided model
+	BindHeaders	- binds the request headers into provided model
+	BindCookies	- binds the request cookies into provided model
 
 There are Two types of the polices:
 	BindPolicy	- this is the basic policy structure. Used for BindQuery and BindJSON
@@ -30,6 +36,7 @@ The basic Policy contains three basic rules:
 				is set to false the function would return an error.
 	Tag			- defines the 'tag' that would be used for the binding function using this policy
 	SearchDepthLevel - is the depth of the search for nested structs
+	Update		- if set to true the 'createonly' fields are not writable by the BindForm and BindMultipart
 
 The ParamPolicy enhances Policy with:
 	IDOnly	- if set to true the BindParam function searches only for the ID field pair (with also
@@ -39,8 +46,28 @@ The ParamPolicy enhances Policy with:
 There are few default policies for different use purpose:
 	DefaultPolicy 		- default policy
 	DefaultParamPolicy	- default policy for binding parameters.
+	DefaultHeaderPolicy	- default policy for binding headers.
+	DefaultCookiePolicy	- default policy for binding cookies.
+	DefaultJSONPolicy	- default policy for binding json forms.
+	StrictJSONPolicy	- policy disallowing unknown fields and trailing data in json forms.
+
+The fields tagged with the 'required' option i.e. `header:"X-Tenant,required"` must have
+the value in the request, otherwise an error is returned, also for the fields of the nested
+structs and regardless of the policy FailOnError.
+
+The fields tagged with the 'rest' tag i.e. `rest:"readonly"` could not be set by the clients
+using the json and form body binding functions (see refutils.FieldAccess).
 
 In order to use a copy of these, use Copy() method and a new copy would be returned.
 
+The model's primary key is set using the StringIDSetter or IDSetter implementation, the field
+tagged as the primary key (see SetPrimaryKeyTag) or the field named 'ID'.
+
+The fields of custom types are set using the converters registered with RegisterConverter,
+or by their encoding.TextUnmarshaler or sql.Scanner implementation.
+
+The reflection metadata of the models (fields, tags and time layouts) is computed once
+for each model type and tag, and then reused by the BindQuery, BindParams and SetID functions.
+
 */
 package forms
@@ -0,0 +1,180 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structPlan is the reflection metadata of the struct type used by the binding functions.
+// The plan is computed once for given struct type and tag, and then reused by
+// the BindQuery, BindParams and SetID functions, so that the struct fields and
+// their tags are not parsed on every request.
+type structPlan struct {
+	// name is the lowercased struct name - the default param name of the model
+	name string
+
+	// idIndex is the index of the primary key field in the 'fields'. The primary key
+	// is the first field tagged with the primary key tag or, if there is no such field,
+	// the first settable field named 'id' (case insensitive).
+	// If the struct doesn't contain such field its value is -1
+	idIndex int
+
+	// fields are the settable (exported) fields of the struct
+	fields []fieldPlan
+}
+
+// fieldPlan is the reflection metadata of a single struct field.
+type fieldPlan struct {
+	index     int
+	field     reflect.StructField
+	lowerName string
+
+	// tag is the name part of the plan's tag value for given field
+	tag string
+
+	// tagged is true if the field has non-empty plan's tag
+	tagged bool
+
+	// required is true if the tag contains the 'required' option
+	required bool
+
+	// primary is true if the field is the primary key of the struct
+	primary bool
+
+	// access is the FieldAccess defined by the field's 'rest' tag
+	access refutils.FieldAccess
+
+	// kind is the kind of the field type
+	kind reflect.Kind
+
+	// elemKind is the kind of the pointed type if the field is a pointer
+	elemKind reflect.Kind
+
+	// custom is true if the field's type or the pointed type is bound using
+	// the converter, encoding.TextUnmarshaler or sql.Scanner
+	custom bool
+
+	// file is true if the field may be bound with the uploaded multipart file
+	file bool
+
+	// timeLayout is set if the field is (or points to) time.Time or a slice of time.Time
+	timeLayout *timeLayout
+}
+
+// timeLayout is the parsed 'time_format', 'time_utc' and 'time_location' tags of the field.
+type timeLayout struct {
+	format   string
+	location *time.Location
+	err      error
+}
+
+type planKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// planCache contains the *structPlan for the planKey
+var planCache sync.Map
+
+// getPlan returns the cached plan for the struct type 't' using the 'tag'.
+// If the plan does not exists yet it is computed and stored in the cache.
+func getPlan(t reflect.Type, tag string) *structPlan {
+	key := planKey{t: t, tag: tag}
+	if plan, ok := planCache.Load(key); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := planCache.LoadOrStore(key, newPlan(t, tag))
+	return plan.(*structPlan)
+}
+
+func newPlan(t reflect.Type, tag string) *structPlan {
+	plan := &structPlan{name: strings.ToLower(t.Name()), idIndex: -1}
+
+	// pkTagged is true if the primary key field is tagged
+	var pkTagged bool
+
+	for i := 0; i < t.NumField(); i++ {
+		tField := t.Field(i)
+
+		// unexported fields are not settable
+		if tField.PkgPath != "" {
+			continue
+		}
+
+		fp := fieldPlan{
+			index:     i,
+			field:     tField,
+			lowerName: strings.ToLower(tField.Name),
+			kind:      tField.Type.Kind(),
+			file:      isFileType(tField.Type),
+			access:    refutils.FieldAccessOf(tField),
+		}
+		if tag != "" {
+			value := tField.Tag.Get(tag)
+			fp.tagged = value != ""
+			fp.tag, fp.required = ParseTag(value)
+		}
+
+		ft := tField.Type
+		if fp.kind == reflect.Ptr {
+			ft = ft.Elem()
+			fp.elemKind = ft.Kind()
+		}
+		fp.custom = isCustomType(ft)
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft == timeType {
+			fp.timeLayout = newTimeLayout(tField)
+		}
+
+		switch {
+		case isPrimaryKeyTag(tField) && !pkTagged:
+			plan.idIndex, pkTagged = len(plan.fields), true
+		case plan.idIndex == -1 && fp.lowerName == "id":
+			plan.idIndex = len(plan.fields)
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+	if plan.idIndex != -1 {
+		plan.fields[plan.idIndex].primary = true
+	}
+	return plan
+}
+
+// ParseTag parses the binding tag 'value' i.e. `form:"name,required"` into the
+// name and the 'required' option.
+func ParseTag(value string) (name string, required bool) {
+	name = value
+	if i := strings.Index(value, ","); i != -1 {
+		name = value[:i]
+		for _, option := range strings.Split(value[i+1:], ",") {
+			if strings.TrimSpace(option) == "required" {
+				required = true
+			}
+		}
+	}
+	return name, required
+}
+
+func newTimeLayout(structField reflect.StructField) *timeLayout {
+	layout := &timeLayout{}
+	layout.format, layout.location, layout.err = prepareTimeField(structField)
+	return layout
+}
+
+// parse parses the 'val' time using the layout. The empty 'val' results in zero time.
+func (l *timeLayout) parse(val string) (time.Time, error) {
+	if l.err != nil {
+		return time.Time{}, l.err
+	}
+	if val == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(l.format, val, l.location)
+}
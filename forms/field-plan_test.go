@@ -0,0 +1,168 @@
+package forms
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type wideModel struct {
+	ID        int
+	Name      string    `form:"name"`
+	Surname   string    `form:"surname"`
+	Email     string    `form:"email"`
+	Age       int       `form:"age"`
+	Height    float64   `form:"height"`
+	Weight    float32   `form:"weight"`
+	Active    bool      `form:"active"`
+	Admin     bool      `form:"admin"`
+	Score     uint      `form:"score"`
+	Level     int8      `form:"level"`
+	Rank      int16     `form:"rank"`
+	Points    int64     `form:"points"`
+	Tags      []string  `form:"tag"`
+	Groups    []int     `form:"group"`
+	City      string    `form:"city"`
+	Country   string    `form:"country"`
+	Phone     string    `form:"phone"`
+	CreatedAt time.Time `form:"created" time_format:"2006-01-02"`
+	UpdatedAt time.Time `form:"updated" time_format:"2006-01-02" time_utc:"true"`
+	Secret    string    `form:"-"`
+	private   string
+}
+
+const wideQuery = "/wide?name=John&surname=Doe&email=john@doe.com&age=32&height=180.5" +
+	"&weight=80.2&active=true&admin=false&score=12&level=3&rank=100&points=1234" +
+	"&tag=a&tag=b&tag=c&group=1&group=2&city=Warsaw&country=Poland&phone=123456789" +
+	"&created=2017-01-02&updated=2017-03-04"
+
+func TestFieldPlan(t *testing.T) {
+	Convey("Subject: cached struct plan", t, func() {
+		Convey("The plan is computed once for given type and tag", func() {
+			plan := getPlan(reflect.TypeOf(wideModel{}), "form")
+			So(getPlan(reflect.TypeOf(wideModel{}), "form"), ShouldPointTo, plan)
+			So(getPlan(reflect.TypeOf(wideModel{}), "param"), ShouldNotPointTo, plan)
+		})
+
+		Convey("The plan contains only settable fields with parsed tags", func() {
+			plan := getPlan(reflect.TypeOf(wideModel{}), "form")
+			So(plan.name, ShouldEqual, "widemodel")
+			So(plan.fields, ShouldHaveLength, reflect.TypeOf(wideModel{}).NumField()-1)
+			So(plan.idIndex, ShouldEqual, 0)
+
+			for _, fp := range plan.fields {
+				So(fp.field.Name, ShouldNotEqual, "private")
+				So(fp.tag, ShouldEqual, fp.field.Tag.Get("form"))
+			}
+
+			created := plan.fields[18]
+			So(created.field.Name, ShouldEqual, "CreatedAt")
+			So(created.timeLayout, ShouldNotBeNil)
+			So(created.timeLayout.format, ShouldEqual, "2006-01-02")
+			So(created.timeLayout.location, ShouldEqual, time.Local)
+			So(plan.fields[19].timeLayout.location, ShouldEqual, time.UTC)
+			So(plan.fields[1].timeLayout, ShouldBeNil)
+		})
+
+		Convey("The plan of the model without id field has negative idIndex", func() {
+			type NoID struct {
+				Name string
+			}
+			So(getPlan(reflect.TypeOf(NoID{}), "").idIndex, ShouldEqual, -1)
+			So(SetID(&NoID{}, "1"), ShouldEqual, ErrIncorrectModel)
+		})
+
+		Convey("The time layout errors are stored in the plan", func() {
+			type NoFormat struct {
+				Date time.Time `form:"date"`
+			}
+			plan := getPlan(reflect.TypeOf(NoFormat{}), "form")
+			So(plan.fields[0].timeLayout.err, ShouldBeError)
+
+			_, err := plan.fields[0].timeLayout.parse("2017-01-01")
+			So(err, ShouldBeError)
+		})
+
+		Convey("Binding with the cached plan gives the same result", func() {
+			req := httptest.NewRequest("GET", wideQuery, nil)
+			first, second := &wideModel{}, &wideModel{}
+			So(BindQuery(req, first, DefaultBindPolicy.Copy()), ShouldBeNil)
+			So(BindQuery(req, second, DefaultBindPolicy.Copy()), ShouldBeNil)
+			So(second, ShouldResemble, first)
+			So(first.Name, ShouldEqual, "John")
+			So(first.Tags, ShouldResemble, []string{"a", "b", "c"})
+			So(first.CreatedAt.Year(), ShouldEqual, 2017)
+		})
+	})
+}
+
+// resetPlanCache clears the cached plans, so that the benchmarks may compare
+// the binding with and without the cache.
+func resetPlanCache() {
+	planCache.Range(func(key, value interface{}) bool {
+		planCache.Delete(key)
+		return true
+	})
+}
+
+func BenchmarkBindQuery(b *testing.B) {
+	req := httptest.NewRequest("GET", wideQuery, nil)
+	policy := DefaultBindPolicy.Copy()
+
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			BindQuery(req, &wideModel{}, policy)
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resetPlanCache()
+			BindQuery(req, &wideModel{}, policy)
+		}
+	})
+}
+
+func BenchmarkBindParams(b *testing.B) {
+	req := httptest.NewRequest("GET", "/wide/12", nil)
+	getParam := getParamFuncWithValues(map[string]string{
+		"widemodel": "12", "name": "John", "city": "Warsaw", "age": "32",
+	})
+	policy := DefaultParamPolicy.Copy()
+
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			BindParams(req, &wideModel{}, getParam, policy)
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resetPlanCache()
+			BindParams(req, &wideModel{}, getParam, policy)
+		}
+	})
+}
+
+func BenchmarkSetID(b *testing.B) {
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			SetID(&wideModel{}, "12")
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resetPlanCache()
+			SetID(&wideModel{}, "12")
+		}
+	})
+}
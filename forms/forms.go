@@ -1,18 +1,19 @@
 package forms
 
 import (
-	"encoding/json"
 	"errors"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
 	"net/http"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
 var (
 	ErrUnknownType    = errors.New("Unknown data type")
-	ErrIncorrectModel = errors.New("Given model do not have ID field. In order to set ID, it should implement IDSetter or contain field ID")
+	ErrIncorrectModel = errors.New("Given model do not have ID field. In order to set ID, it should implement IDSetter, StringIDSetter or contain field ID")
+	ErrFieldNotFound  = errors.New("Given model do not have provided field")
 )
 
 // IDSetter defines interface for data Models that allows
@@ -43,54 +44,107 @@ func BindQuery(req *http.Request, model interface{}, policy *BindPolicy) error {
 }
 
 // BindJSON binds the reads the provided request body
-// and decode it into provided model.
+// and decode it into provided model using the DefaultJSONPolicy.
 // If an error occurred during model binding, error returns
+// (see BindJSONWithPolicy).
 func BindJSON(req *http.Request, model interface{}) error {
-	decoder := json.NewDecoder(req.Body)
-	err := decoder.Decode(model)
-	if err != nil {
-		return err
-	}
-	return nil
+	return BindJSONWithPolicy(req, model, &DefaultJSONPolicy)
 }
 
 // SetID sets the ID of provided model.
-// If model implements IDSetter interface it uses SetID method at first.
-// Otherwise checks whether provided model contains 'ID' or 'Id' field
-// And parses the 'id' argument
+// If model implements StringIDSetter or IDSetter interface it uses its method at first.
+// Otherwise checks whether provided model contains the primary key field - tagged
+// with the primary key tag (see SetPrimaryKeyTag) or named 'ID' or 'Id',
+// and parses the 'id' argument according to the field's type.
 // Returns error if provided argument is not appropiate for given field
 // 	or there is no ID field in the model
 func SetID(model interface{}, id string) error {
-	// Check if given model implements IDSetter interface
-	if setter, ok := model.(IDSetter); ok {
-		uintID, err := strconv.ParseUint(id, 10, 64)
-		if err != nil {
-			return err
-		}
-		setter.SetID(uintID)
-		return nil
+	// Check if given model implements StringIDSetter or IDSetter interface
+	if ok, err := setIDWithSetter(model, id); ok {
+		return err
 	}
 
-	t := reflect.TypeOf(model).Elem()
 	v := reflect.ValueOf(model).Elem()
 
-	for i := 0; i < t.NumField(); i++ {
-		tField := t.Field(i)
-		sField := v.Field(i)
+	plan := getPlan(v.Type(), "")
+	if plan.idIndex != -1 {
+		sField := v.Field(plan.fields[plan.idIndex].index)
+		return setFieldWithType(sField.Kind(), id, sField)
+	}
+	return ErrIncorrectModel
+}
 
-		if !sField.CanSet() {
-			continue
-		}
+// SetField sets the field named 'fieldName' of provided model with the 'value'.
+// The value is parsed according to the type of the field. If the field is a pointer
+// to the basic type and it is nil, new value is allocated.
+// Returns ErrFieldNotFound if the model does not contain settable field of given name.
+func SetField(model interface{}, fieldName string, value string) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrIncorrectModel
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return ErrIncorrectModel
+	}
 
-		if strings.ToLower(tField.Name) == "id" {
-			err := setFieldWithType(sField.Kind(), id, sField)
-			if err != nil {
-				return err
-			}
-			return nil
+	field := v.FieldByName(fieldName)
+	if !field.IsValid() || !field.CanSet() {
+		return ErrFieldNotFound
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
 		}
+		field = field.Elem()
 	}
-	return ErrIncorrectModel
+	return setFieldWithType(field.Kind(), value, field)
+}
+
+// valueSource is the source of the values mapped into the model fields.
+type valueSource struct {
+	// values returns the values for given key
+	values func(key string) ([]string, bool)
+
+	// missing is the prototype of the error returned if the required value is missing
+	missing resterrors.Error
+
+	// checkAccess defines if the values may be set only into the fields writable by the clients
+	// (see refutils.FieldAccess). The 'create' defines if the model is being created.
+	checkAccess bool
+	create      bool
+}
+
+// formSource creates the valueSource for the 'form' values.
+func formSource(form map[string][]string, missing resterrors.Error) *valueSource {
+	return &valueSource{
+		values: func(key string) ([]string, bool) {
+			values, ok := form[key]
+			return values, ok
+		},
+		missing: missing,
+	}
+}
+
+// writable checks if the field of given plan may be set with the source values.
+func (s *valueSource) writable(fp *fieldPlan) bool {
+	return !s.checkAccess || fp.access.Writable(s.create)
+}
+
+// notWritableValue creates the error for the value of given 'key' set into the field
+// which is not writable by the clients. The same prototype is used by the JSON binding.
+func notWritableValue(key string, access refutils.FieldAccess) error {
+	restErr := resterrors.ErrUnsupportedJSONField.New()
+	restErr.AddDetailInfo("Field: '" + key + "' is " + access.String())
+	return restErr
+}
+
+// missingError creates the error for the missing required value of given 'key'.
+func (s *valueSource) missingError(key string) error {
+	restErr := s.missing.New()
+	restErr.AddDetailInfo("Missing required value: '" + key + "'")
+	return restErr
 }
 
 func mapForm(
@@ -99,102 +153,118 @@ func mapForm(
 	policy *BindPolicy,
 	searchDepthLevel int,
 ) error {
+	return mapValues(model, formSource(form, resterrors.ErrMissingRequiredQueryParam), policy,
+		searchDepthLevel)
+}
 
-	// Get type of pointer
-	t := reflect.TypeOf(model).Elem()
-
+// mapValues maps the values from the 'source' into the 'model' fields using the 'policy' rules.
+// The fields tagged with the 'required' option i.e. `form:"name,required"` must have the value
+// in the source, otherwise the source's missing error is returned.
+// If the source checks the fields access, the value of not writable field results in an error
+// and the not writable nested structs are not searched.
+func mapValues(
+	model interface{},
+	source *valueSource,
+	policy *BindPolicy,
+	searchDepthLevel int,
+) error {
 	// Get value of pointer
 	v := reflect.ValueOf(model).Elem()
 
-	// iterate over model field
-	for i := 0; i < t.NumField(); i++ {
+	// Get the cached plan for the model's type
+	plan := getPlan(v.Type(), policy.Tag)
 
-		tField := t.Field(i)
-		sField := v.Field(i)
-
-		// isTime flags if the field is of time.Time type
-		var isTime bool
+	// iterate over model field
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		sField := v.Field(fp.index)
 
-		// Check if field is settable - can be addresable or is public
-		// or if the field is of type Interface
-		if !sField.CanSet() || sField.Kind() == reflect.Interface {
+		// Check if the field is of type Interface or is bound with the uploaded files
+		if fp.kind == reflect.Interface || fp.file {
 			continue
 		}
 
 		// Check if the field has a tag query
-		fieldTag := tField.Tag.Get(policy.Tag)
+		fieldTag := fp.tag
 
 		// If tag is set to '-' don't map values
-		if fieldTag == "-" || (policy.TaggedOnly && fieldTag == "") {
+		if fieldTag == "-" || (policy.TaggedOnly && !fp.tagged) {
 			continue
 		}
 
-		// Init object if it is of ptr type.
-		if sField.Kind() == reflect.Ptr {
-			var initialize bool
-			switch tField.Type.Elem().Kind() {
-			case reflect.Ptr, reflect.Interface:
+		// Init nested struct object if it is of ptr type.
+		// Other pointers are initialized only if the form contains their value.
+		if fp.kind == reflect.Ptr {
+			switch {
+			case fp.elemKind == reflect.Ptr || fp.elemKind == reflect.Interface:
 				continue
-			case reflect.Struct:
-				if sField.IsNil() && policy.SearchDepthLevel > 0 {
-					initialize = true
-				} else if policy.SearchDepthLevel <= 0 {
+			case fp.elemKind == reflect.Struct && !fp.custom && fp.timeLayout == nil:
+				if policy.SearchDepthLevel <= 0 || !source.writable(fp) {
 					continue
 				}
-			default:
 				// if the sField is nil - create new item of type given struct type
 				if sField.IsNil() {
-					initialize = true
+					sField.Set(reflect.New(fp.field.Type.Elem()))
 				}
-			}
-			if initialize {
-				sField.Set(reflect.New(tField.Type.Elem()))
 				sField = sField.Elem()
 			}
 		}
 
-		if sField.Kind() == reflect.Struct {
-			_, isTime = sField.Interface().(time.Time)
-			if !isTime {
-				if searchDepthLevel > 0 {
-					// mapQuery recursively if the field is a struct
-					err := mapForm(sField.Addr().Interface(), form, policy, searchDepthLevel-1)
-					// check error only if the policy requirers it
-					if err != nil && policy.FailOnError {
-						return err
-					}
+		if sField.Kind() == reflect.Struct && !fp.custom && fp.timeLayout == nil {
+			if searchDepthLevel > 0 && source.writable(fp) {
+				// mapQuery recursively if the field is a struct. The nested mapping returns
+				// the conversion errors only if the policy requires it, but the missing
+				// required values are returned at every depth
+				err := mapValues(sField.Addr().Interface(), source, policy, searchDepthLevel-1)
+				if err != nil {
+					return err
 				}
-				continue
 			}
+			continue
 		}
 
 		if fieldTag == "" {
-			fieldTag = strings.ToLower(tField.Name)
+			fieldTag = fp.lowerName
 		}
 
 		// Check if the query contains the tag
-		formValue, ok := form[fieldTag]
-		if !ok {
+		formValue, _ := source.values(fieldTag)
+		elemNum := len(formValue)
+		if elemNum <= 0 {
+			if fp.required {
+				return source.missingError(fieldTag)
+			}
 			continue
 		}
-		elemNum := len(formValue)
+		if !source.writable(fp) {
+			return notWritableValue(fieldTag, fp.access)
+		}
 
-		// The query value can conatin more than one value
-		// If the field is a slice and the queryValue
-		// has any values assign it if possible
-		if sField.Kind() == reflect.Slice {
-			if elemNum <= 0 {
-				continue
+		if sField.Kind() == reflect.Ptr {
+			if sField.IsNil() {
+				sField.Set(reflect.New(fp.field.Type.Elem()))
+			}
+			sField = sField.Elem()
+		}
+
+		switch {
+		case fp.custom:
+			// the custom types are set using the converter, TextUnmarshaler or Scanner
+			err := setFieldWithType(sField.Kind(), formValue[0], sField)
+			if policy.FailOnError && err != nil {
+				return err
 			}
-			sliceKind := sField.Type().Elem().Kind()
+		case sField.Kind() == reflect.Slice:
+			// The query value can conatin more than one value
+			// If the field is a slice and the queryValue
+			// has any values assign it if possible
+			sliceType := sField.Type().Elem()
 			fieldSlice := reflect.MakeSlice(sField.Type(), elemNum, elemNum)
 
 			// Check if the field is a slice of time.Time
-			if sliceKind == reflect.Struct {
-
-				_, isTime = fieldSlice.Index(0).Interface().(time.Time)
-				if isTime {
-					err := setSliceTimeField(formValue, tField, fieldSlice, policy.FailOnError)
+			if sliceType.Kind() == reflect.Struct && !isCustomType(sliceType) {
+				if fp.timeLayout != nil {
+					err := setSliceTimeField(formValue, fp.timeLayout, fieldSlice, policy.FailOnError)
 					if err != nil && policy.FailOnError {
 						return err
 					}
@@ -205,22 +275,21 @@ func mapForm(
 			// Iterate over query elements and add to fieldSlice
 			for i := 0; i < elemNum; i++ {
 				// set with given value
-				err := setFieldWithType(sliceKind, formValue[i], fieldSlice.Index(i))
+				err := setFieldWithType(sliceType.Kind(), formValue[i], fieldSlice.Index(i))
 				if err != nil && policy.FailOnError {
 					return err
 				}
 			}
 
-			// Set 'model' value for field of index 'i' with 'fieldSlice'
-			v.Field(i).Set(fieldSlice)
-		} else if isTime {
-			err := setTimeField(formValue[0], tField, sField)
+			// Set 'model' value for field with 'fieldSlice'
+			sField.Set(fieldSlice)
+		case fp.timeLayout != nil:
+			err := setTimeField(formValue[0], fp.timeLayout, sField)
 			if policy.FailOnError && err != nil {
 				return err
 			}
-		} else {
-			// check if the field is of type time
-			err := setFieldWithType(tField.Type.Kind(), formValue[0], sField)
+		default:
+			err := setFieldWithType(sField.Kind(), formValue[0], sField)
 			if policy.FailOnError && err != nil {
 				return err
 			}
@@ -230,13 +299,27 @@ func mapForm(
 }
 
 // setFieldWithType sets given 'field' of 'fieldKind' with value 'val'.
+// The fields of custom types are set using the registered converter, encoding.TextUnmarshaler
+// or sql.Scanner. The nil pointer fields are initialized.
 // When the value is not of given Kind, the function throws error
 func setFieldWithType(
 	fieldKind reflect.Kind,
 	val string,
 	field reflect.Value,
 ) (err error) {
+	if ok, err := setCustomType(val, field); ok {
+		return err
+	}
+
 	switch fieldKind {
+	case reflect.Ptr:
+		if field.Kind() != reflect.Ptr || !field.CanSet() {
+			return ErrUnknownType
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setFieldWithType(field.Elem().Kind(), val, field.Elem())
 	case reflect.String:
 		field.SetString(val)
 	case reflect.Int:
@@ -328,17 +411,8 @@ func setBoolField(val string, field reflect.Value) (err error) {
 	return nil
 }
 
-func setTimeField(val string, structField reflect.StructField, value reflect.Value) error {
-	timeFormat, local, err := prepareTimeField(structField)
-	if err != nil {
-		return err
-	}
-	if val == "" {
-		value.Set(reflect.ValueOf(time.Time{}))
-		return nil
-	}
-
-	t, err := time.ParseInLocation(timeFormat, val, local)
+func setTimeField(val string, layout *timeLayout, value reflect.Value) error {
+	t, err := layout.parse(val)
 	if err != nil {
 		return err
 	}
@@ -349,22 +423,16 @@ func setTimeField(val string, structField reflect.StructField, value reflect.Val
 
 func setSliceTimeField(
 	values []string,
-	structField reflect.StructField,
+	layout *timeLayout,
 	value reflect.Value,
 	failOnError bool,
 ) error {
-	timeFormat, local, err := prepareTimeField(structField)
-	if err != nil {
-		return err
+	if layout.err != nil {
+		return layout.err
 	}
 
 	for i := 0; i < len(values); i++ {
-		if values[i] == "" {
-			value.Index(i).Set(reflect.ValueOf(time.Time{}))
-			continue
-		}
-
-		t, err := time.ParseInLocation(timeFormat, values[i], local)
+		t, err := layout.parse(values[i])
 		if err != nil && failOnError {
 			return err
 		}
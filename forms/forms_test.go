@@ -400,6 +400,38 @@ func TestSetID(t *testing.T) {
 	})
 }
 
+func TestSetField(t *testing.T) {
+	Convey("Subject: SetField function", t, func() {
+		type Model struct {
+			UserID  uint
+			GroupID *int
+			Name    string
+			private int
+		}
+		model := &Model{}
+
+		Convey("Sets the field parsing the value by its type", func() {
+			So(SetField(model, "UserID", "12"), ShouldBeNil)
+			So(model.UserID, ShouldEqual, 12)
+
+			So(SetField(model, "Name", "some name"), ShouldBeNil)
+			So(model.Name, ShouldEqual, "some name")
+		})
+
+		Convey("Allocates nil pointer fields", func() {
+			So(SetField(model, "GroupID", "-3"), ShouldBeNil)
+			So(*model.GroupID, ShouldEqual, -3)
+		})
+
+		Convey("Returns an error for incorrect value or field", func() {
+			So(SetField(model, "UserID", "-12"), ShouldBeError)
+			So(SetField(model, "Unknown", "12"), ShouldEqual, ErrFieldNotFound)
+			So(SetField(model, "private", "12"), ShouldEqual, ErrFieldNotFound)
+			So(SetField(Model{}, "UserID", "12"), ShouldEqual, ErrIncorrectModel)
+		})
+	})
+}
+
 func TestSetFieldWithType(t *testing.T) {
 	Convey("Having some interface or struct value", t, func() {
 		fks := []reflect.Kind{reflect.Slice, reflect.Interface, reflect.Struct}
@@ -430,7 +462,7 @@ func TestTimeField(t *testing.T) {
 		for i := 0; i < t.NumField(); i++ {
 			tField := t.Field(i)
 			vField := v.Field(i)
-			setTimeField("124120", tField, vField)
+			setTimeField("124120", newTimeLayout(tField), vField)
 		}
 
 		type structWithParam struct {
@@ -443,7 +475,7 @@ func TestTimeField(t *testing.T) {
 		for i := 0; i < t.NumField(); i++ {
 			tField := t.Field(i)
 			vField := v.Field(i)
-			setTimeField("124120", tField, vField)
+			setTimeField("124120", newTimeLayout(tField), vField)
 		}
 	})
 }
@@ -0,0 +1,56 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+	"net/textproto"
+)
+
+// BindHeaders binds the request headers to the provided model.
+// The header names are taken from the policy 'Tag' (by default `header:"X-Tenant"`)
+// and are matched case insensitive. The values are converted using the same rules as
+// the BindQuery function. If the field tag contains the 'required' option
+// i.e. `header:"X-Tenant,required"` and the request does not contain the header, the
+// *resterrors.Error of ErrMissingRequiredHeader prototype is returned.
+// If no policy is provided (or nil) then the function return quickly with nil error.
+func BindHeaders(req *http.Request, model interface{}, policy *BindPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	source := &valueSource{
+		values: func(key string) ([]string, bool) {
+			values, ok := req.Header[textproto.CanonicalMIMEHeaderKey(key)]
+			return values, ok
+		},
+		missing: resterrors.ErrMissingRequiredHeader,
+	}
+	return mapValues(model, source, policy, policy.SearchDepthLevel)
+}
+
+// BindCookies binds the request cookies to the provided model.
+// The cookie names are taken from the policy 'Tag' (by default `cookie:"session"`).
+// The values are converted using the same rules as the BindQuery function.
+// If the field tag contains the 'required' option i.e. `cookie:"session,required"` and
+// the request does not contain the cookie, the *resterrors.Error of ErrMissingRequiredCookie
+// prototype is returned.
+// If no policy is provided (or nil) then the function return quickly with nil error.
+func BindCookies(req *http.Request, model interface{}, policy *BindPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	cookies := map[string][]string{}
+	for _, cookie := range req.Cookies() {
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie.Value)
+	}
+
+	source := &valueSource{
+		values: func(key string) ([]string, bool) {
+			values, ok := cookies[key]
+			return values, ok
+		},
+		missing: resterrors.ErrMissingRequiredCookie,
+	}
+	return mapValues(model, source, policy, policy.SearchDepthLevel)
+}
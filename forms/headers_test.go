@@ -0,0 +1,120 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type requestMeta struct {
+	Tenant    string    `header:"X-Tenant,required"`
+	Version   int       `header:"X-Api-Version"`
+	Languages []string  `header:"Accept-Language"`
+	Since     time.Time `header:"If-Modified-Since" time_format:"2006-01-02"`
+	Session   string    `cookie:"session,required"`
+	Theme     *status   `cookie:"theme"`
+	Ignored   string
+}
+
+func isMissingHeader(err error) bool {
+	restErr, ok := err.(*resterrors.Error)
+	return ok && restErr.Compare(resterrors.ErrMissingRequiredHeader)
+}
+
+func TestBindHeaders(t *testing.T) {
+	Convey("Subject: BindHeaders binds the request headers", t, func() {
+		policy := DefaultHeaderPolicy.Copy()
+		req := httptest.NewRequest("GET", "/models", nil)
+
+		Convey("The headers are converted to the field types", func() {
+			req.Header.Set("x-tenant", "acme")
+			req.Header.Set("X-API-VERSION", "2")
+			req.Header.Add("Accept-Language", "pl")
+			req.Header.Add("Accept-Language", "en")
+			req.Header.Set("If-Modified-Since", "2018-01-02")
+			req.Header.Set("Ignored", "value")
+
+			model := &requestMeta{}
+			So(BindHeaders(req, model, policy), ShouldBeNil)
+			So(model.Tenant, ShouldEqual, "acme")
+			So(model.Version, ShouldEqual, 2)
+			So(model.Languages, ShouldResemble, []string{"pl", "en"})
+			So(model.Since.Format("2006-01-02"), ShouldEqual, "2018-01-02")
+			So(model.Ignored, ShouldBeEmpty)
+		})
+
+		Convey("The missing required header results in ErrMissingRequiredHeader", func() {
+			req.Header.Set("X-Api-Version", "2")
+			So(isMissingHeader(BindHeaders(req, &requestMeta{}, policy)), ShouldBeTrue)
+		})
+
+		Convey("The missing required header of the nested struct results in ErrMissingRequiredHeader", func() {
+			type Model struct {
+				Meta *requestMeta
+			}
+			policy.SearchDepthLevel = 1
+			policy.TaggedOnly = false
+			req.Header.Set("X-Api-Version", "2")
+
+			So(policy.FailOnError, ShouldBeFalse)
+			So(isMissingHeader(BindHeaders(req, &Model{}, policy)), ShouldBeTrue)
+
+			req.Header.Set("X-Tenant", "acme")
+			model := &Model{}
+			So(BindHeaders(req, model, policy), ShouldBeNil)
+			So(model.Meta.Tenant, ShouldEqual, "acme")
+		})
+
+		Convey("The conversion errors are returned if the policy requires it", func() {
+			req.Header.Set("X-Tenant", "acme")
+			req.Header.Set("X-Api-Version", "two")
+
+			model := &requestMeta{}
+			So(BindHeaders(req, model, policy), ShouldBeNil)
+			So(model.Tenant, ShouldEqual, "acme")
+
+			policy.FailOnError = true
+			So(BindHeaders(req, &requestMeta{}, policy), ShouldBeError)
+		})
+
+		Convey("Nil policy does nothing", func() {
+			So(BindHeaders(req, &requestMeta{}, nil), ShouldBeNil)
+		})
+	})
+}
+
+func TestBindCookies(t *testing.T) {
+	Convey("Subject: BindCookies binds the request cookies", t, func() {
+		policy := DefaultCookiePolicy.Copy()
+		policy.FailOnError = true
+		req := httptest.NewRequest("GET", "/models", nil)
+
+		Convey("The cookies are converted to the field types", func() {
+			req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+			req.AddCookie(&http.Cookie{Name: "theme", Value: "active"})
+
+			model := &requestMeta{}
+			So(BindCookies(req, model, policy), ShouldBeNil)
+			So(model.Session, ShouldEqual, "abc")
+			So(*model.Theme, ShouldEqual, statusActive)
+			So(model.Tenant, ShouldBeEmpty)
+		})
+
+		Convey("The missing required cookie results in ErrMissingRequiredCookie", func() {
+			req.AddCookie(&http.Cookie{Name: "theme", Value: "active"})
+			err := BindCookies(req, &requestMeta{}, policy)
+			restErr, ok := err.(*resterrors.Error)
+			So(ok, ShouldBeTrue)
+			So(restErr.Compare(resterrors.ErrMissingRequiredCookie), ShouldBeTrue)
+		})
+
+		Convey("The invalid cookie value results in error", func() {
+			req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+			req.AddCookie(&http.Cookie{Name: "theme", Value: "unknown"})
+			So(BindCookies(req, &requestMeta{}, policy), ShouldBeError)
+		})
+	})
+}
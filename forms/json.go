@@ -0,0 +1,183 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// JSONPolicy is a set of rules used by the BindJSONWithPolicy function.
+type JSONPolicy struct {
+	// DisallowUnknownFields defines if the JSON fields that does not match
+	// any model's field results in an error.
+	DisallowUnknownFields bool
+
+	// MaxBodySize is the maximum size of the request body.
+	// The zero value means no limit.
+	MaxBodySize int64
+
+	// UseNumber defines if the numbers decoded into interface{} fields
+	// should be of json.Number type instead of float64.
+	UseNumber bool
+
+	// DisallowTrailingData defines if any data after the JSON document results in an error.
+	DisallowTrailingData bool
+
+	// Update defines if the bound model is being updated, thus its fields tagged
+	// as `rest:"createonly"` are not writable.
+	Update bool
+}
+
+// Copy creates a copy of the JSONPolicy
+func (p JSONPolicy) Copy() *JSONPolicy {
+	policyCopy := p
+	return &policyCopy
+}
+
+var (
+	// DefaultJSONPolicy is the JSONPolicy used by the BindJSON function and by the
+	// BindJSONWithPolicy and BindPatch functions if no policy is provided.
+	// It decodes the request body the same way as the json.Decoder does.
+	DefaultJSONPolicy = JSONPolicy{
+		DisallowUnknownFields: false,
+		MaxBodySize:           0,
+		UseNumber:             false,
+		DisallowTrailingData:  false,
+		Update:                false,
+	}
+
+	// StrictJSONPolicy is the JSONPolicy that disallows unknown fields and trailing data,
+	// uses json.Number for the numbers and limits the request body to 1MB.
+	StrictJSONPolicy = JSONPolicy{
+		DisallowUnknownFields: true,
+		MaxBodySize:           1 << 20,
+		UseNumber:             true,
+		DisallowTrailingData:  true,
+		Update:                false,
+	}
+)
+
+// BindJSONWithPolicy binds the request body JSON document to the provided model
+// using the 'policy' rules. If no policy is provided (or nil) the DefaultJSONPolicy is used.
+// The decoding errors are returned as the *resterrors.Error of prototype:
+//	ErrInvalidJSONDocument		- the body is not a valid JSON document or contains trailing data
+//	ErrInvalidJSONFieldValue	- the value of the field (provided in detail) is not of the field's type
+//	ErrUnsupportedJSONField		- the JSON field does not match any model's field or is not
+//					writable by the clients (see refutils.FieldAccess)
+//	ErrRequestBodyTooLarge		- the body exceeds the policy 'MaxBodySize'
+func BindJSONWithPolicy(req *http.Request, model interface{}, policy *JSONPolicy) error {
+	if policy == nil {
+		policy = &DefaultJSONPolicy
+	}
+
+	body, err := limitBody(req, &FormLimits{MaxBodySize: policy.MaxBodySize})
+	if err != nil {
+		return err
+	}
+
+	if req.Body == nil {
+		restErr := resterrors.ErrInvalidJSONDocument.New()
+		restErr.AddDetailInfo("Empty request body")
+		return restErr
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	if policy.UseNumber {
+		decoder.UseNumber()
+	}
+
+	// the models with access rules or the policy disallowing unknown fields are decoded
+	// after the written fields are checked
+	var raw json.RawMessage
+	target := model
+	checked := policy.DisallowUnknownFields || refutils.HasAccessRules(reflect.TypeOf(model))
+	if checked {
+		target = &raw
+	}
+
+	if err = decoder.Decode(target); err != nil {
+		return jsonError(err, body)
+	}
+
+	if policy.DisallowTrailingData {
+		if _, err = decoder.Token(); err != io.EOF {
+			if body != nil && body.exceeded {
+				return resterrors.ErrRequestBodyTooLarge.New()
+			}
+			restErr := resterrors.ErrInvalidJSONDocument.New()
+			restErr.AddDetailInfo("Unexpected data after the JSON document")
+			return restErr
+		}
+	}
+
+	if checked {
+		return bindCheckedJSON(raw, model, policy)
+	}
+	return nil
+}
+
+// bindCheckedJSON decodes the 'raw' JSON document into the model, if it sets only the
+// fields writable by the clients and, if the policy disallows unknown fields, matching
+// the model's fields.
+func bindCheckedJSON(raw json.RawMessage, model interface{}, policy *JSONPolicy) error {
+	var value interface{}
+	if err := decodeJSONNumber(raw, &value); err != nil {
+		return jsonError(err, nil)
+	}
+	rules := fieldRules{writable: true, create: !policy.Update, known: policy.DisallowUnknownFields}
+	if err := checkFields(reflect.TypeOf(model), value, rules, nil); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if policy.UseNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(model); err != nil {
+		return jsonError(err, nil)
+	}
+	return nil
+}
+
+// jsonError translates the json decoding 'err' into the *resterrors.Error.
+// The errors of unknown type are returned unchanged.
+func jsonError(err error, body *limitedBody) error {
+	if body != nil && body.exceeded {
+		return resterrors.ErrRequestBodyTooLarge.New()
+	}
+
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		restErr := resterrors.ErrInvalidJSONDocument.New()
+		restErr.AddDetailInfo(e.Error() + " at offset: " + strconv.FormatInt(e.Offset, 10))
+		return restErr
+	case *json.UnmarshalTypeError:
+		// the document of the type other than the model's type has no field path
+		if e.Field == "" {
+			restErr := resterrors.ErrInvalidJSONDocument.New()
+			restErr.AddDetailInfo("Cannot decode JSON " + e.Value + " into: '" + e.Type.String() + "'")
+			return restErr
+		}
+		restErr := resterrors.ErrInvalidJSONFieldValue.New()
+		restErr.AddDetailInfo("Invalid value for field: '" + e.Field + "'. Expected type: '" +
+			e.Type.String() + "'")
+		return restErr
+	}
+
+	switch {
+	case err == io.EOF:
+		restErr := resterrors.ErrInvalidJSONDocument.New()
+		restErr.AddDetailInfo("Empty request body")
+		return restErr
+	case err == io.ErrUnexpectedEOF:
+		restErr := resterrors.ErrInvalidJSONDocument.New()
+		restErr.AddDetailInfo("Unexpected end of the JSON document")
+		return restErr
+	}
+	return err
+}
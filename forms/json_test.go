@@ -0,0 +1,107 @@
+package forms
+
+import (
+	"encoding/json"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type jsonModel struct {
+	Name    string      `json:"name"`
+	Count   int         `json:"count"`
+	Extra   interface{} `json:"extra"`
+	Address struct {
+		Zip int `json:"zip"`
+	} `json:"address"`
+}
+
+func bindJSONBody(body string, policy *JSONPolicy) (*jsonModel, error) {
+	req := httptest.NewRequest("POST", "/models", strings.NewReader(body))
+	model := &jsonModel{}
+	return model, BindJSONWithPolicy(req, model, policy)
+}
+
+func isRestError(err error, proto resterrors.Error) bool {
+	restErr, ok := err.(*resterrors.Error)
+	return ok && restErr.Compare(proto)
+}
+
+func TestBindJSONWithPolicy(t *testing.T) {
+	Convey("Subject: BindJSONWithPolicy binds the JSON body using the policy rules", t, func() {
+		policy := StrictJSONPolicy.Copy()
+
+		Convey("The valid document is decoded into the model", func() {
+			model, err := bindJSONBody(`{"name":"john","count":2,"extra":12345678901234567890}`, policy)
+			So(err, ShouldBeNil)
+			So(model.Name, ShouldEqual, "john")
+			So(model.Count, ShouldEqual, 2)
+
+			Convey("UseNumber decodes the numbers as json.Number", func() {
+				So(model.Extra, ShouldEqual, json.Number("12345678901234567890"))
+			})
+		})
+
+		Convey("The unknown fields result in ErrUnsupportedJSONField", func() {
+			_, err := bindJSONBody(`{"name":"john","unknown":true}`, policy)
+			So(isRestError(err, resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+			So(strings.Join(err.(*resterrors.Error).Detail.Info, " "), ShouldContainSubstring, "unknown")
+
+			_, err = bindJSONBody(`{"name":"john","address":{"zip":1,"city":"x"}}`, policy)
+			So(isRestError(err, resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+			So(strings.Join(err.(*resterrors.Error).Detail.Info, " "), ShouldContainSubstring, "address.city")
+
+			_, err = bindJSONBody(`{"NAME":"john","extra":{"any":true}}`, policy)
+			So(err, ShouldBeNil)
+
+			policy.DisallowUnknownFields = false
+			_, err = bindJSONBody(`{"name":"john","unknown":true}`, policy)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("The body larger than MaxBodySize results in ErrRequestBodyTooLarge", func() {
+			policy.MaxBodySize = 10
+			_, err := bindJSONBody(`{"name":"some long name"}`, policy)
+			So(isRestError(err, resterrors.ErrRequestBodyTooLarge), ShouldBeTrue)
+
+			req := httptest.NewRequest("POST", "/models", ioutil.NopCloser(strings.NewReader(`{"name":"some long name"}`)))
+			req.ContentLength = -1
+			err = BindJSONWithPolicy(req, &jsonModel{}, policy)
+			So(isRestError(err, resterrors.ErrRequestBodyTooLarge), ShouldBeTrue)
+		})
+
+		Convey("The trailing data results in ErrInvalidJSONDocument", func() {
+			_, err := bindJSONBody(`{"name":"john"} {"name":"doe"}`, policy)
+			So(isRestError(err, resterrors.ErrInvalidJSONDocument), ShouldBeTrue)
+
+			_, err = bindJSONBody("{\"name\":\"john\"}\n", policy)
+			So(err, ShouldBeNil)
+
+			policy.DisallowTrailingData = false
+			_, err = bindJSONBody(`{"name":"john"} garbage`, policy)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("The invalid field value results in ErrInvalidJSONFieldValue with the field path", func() {
+			_, err := bindJSONBody(`{"address":{"zip":"abc"}}`, policy)
+			So(isRestError(err, resterrors.ErrInvalidJSONFieldValue), ShouldBeTrue)
+			So(strings.Join(err.(*resterrors.Error).Detail.Info, " "), ShouldContainSubstring, "address.zip")
+		})
+
+		Convey("The syntax errors result in ErrInvalidJSONDocument", func() {
+			for _, body := range []string{`{"name":`, `{"name" "john"}`, ``} {
+				_, err := bindJSONBody(body, policy)
+				So(isRestError(err, resterrors.ErrInvalidJSONDocument), ShouldBeTrue)
+			}
+		})
+
+		Convey("Nil policy uses the DefaultJSONPolicy", func() {
+			model, err := bindJSONBody(`{"name":"john","unknown":true,"extra":1} trailing`, nil)
+			So(err, ShouldBeNil)
+			So(model.Extra, ShouldEqual, float64(1))
+		})
+	})
+}
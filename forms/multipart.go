@@ -0,0 +1,376 @@
+package forms
+
+import (
+	"errors"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+)
+
+var (
+	errBodyTooLarge = errors.New("Request body too large")
+	errFileTooLarge = errors.New("Uploaded file too large")
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf(&multipart.FileHeader{})
+	fileHeaderSliceType = reflect.SliceOf(fileHeaderType)
+	multipartFileType   = reflect.TypeOf((*multipart.File)(nil)).Elem()
+)
+
+// FormLimits defines the size limits used by the BindForm and BindMultipart functions.
+// The zero value of the limit means no limit.
+type FormLimits struct {
+	// MaxBodySize is the maximum size of the request body
+	MaxBodySize int64
+
+	// MaxMemory is the maximum size of the multipart form stored in memory.
+	// The rest of the files are stored in the temporary files.
+	MaxMemory int64
+
+	// MaxFileSize is the maximum size of a single uploaded file
+	MaxFileSize int64
+}
+
+// Copy creates a copy of the FormLimits
+func (l FormLimits) Copy() *FormLimits {
+	limitsCopy := l
+	return &limitsCopy
+}
+
+// DefaultFormLimits are the default FormLimits.
+// The request body is limited to 32MB, and up to 10MB of the multipart form is stored in memory.
+var DefaultFormLimits = FormLimits{
+	MaxBodySize: 32 << 20,
+	MaxMemory:   10 << 20,
+	MaxFileSize: 0,
+}
+
+// BindForm binds the form request body to the provided model.
+// The 'application/x-www-form-urlencoded' body values are mapped using the same
+// BindPolicy rules as the BindQuery function. The 'multipart/form-data' body is bound
+// using the BindMultipart function.
+// The value of the field which is not writable by the clients (see refutils.FieldAccess)
+// results in the *resterrors.Error of ErrUnsupportedJSONField prototype, the same as in
+// the BindJSONWithPolicy. The 'createonly' fields are not writable if the policy 'Update' is true.
+// If the request body exceeds the 'limits' the *resterrors.Error of
+// ErrRequestBodyTooLarge prototype is returned. If no limits are provided, the
+// DefaultFormLimits are used.
+// If no policy is provided (or nil) then the function return quickly with nil error.
+func BindForm(req *http.Request, model interface{}, policy *BindPolicy, limits *FormLimits) error {
+	if policy == nil {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		return BindMultipart(req, model, policy, limits)
+	}
+
+	if limits == nil {
+		limits = &DefaultFormLimits
+	}
+
+	body, err := limitBody(req, limits)
+	if err != nil {
+		return err
+	}
+
+	if err = req.ParseForm(); err != nil {
+		if body != nil && body.exceeded {
+			return resterrors.ErrRequestBodyTooLarge.New()
+		}
+		return err
+	}
+	return mapValues(model, bodySource(req.PostForm, policy), policy, policy.SearchDepthLevel)
+}
+
+// BindMultipart binds the 'multipart/form-data' request body to the provided model.
+// The form values are mapped using the same BindPolicy rules as the BindQuery function.
+// The uploaded files are bound to the fields of type *multipart.FileHeader, []*multipart.FileHeader
+// or an interface implemented by the multipart.File (i.e. io.Reader). The field of interface type
+// is set with the opened file, which should be closed by the caller if it implements io.Closer.
+// If an error is returned, the opened files are closed and their fields are not set.
+// The values and files of the fields not writable by the clients are rejected as in the BindForm.
+// If the request body or an uploaded file exceeds the 'limits' the *resterrors.Error of
+// ErrRequestBodyTooLarge prototype is returned. The file exceeding the MaxFileSize is rejected
+// while the body is being parsed, so that the rest of the body is not read.
+// If no limits are provided, the DefaultFormLimits are used.
+// If no policy is provided (or nil) then the function return quickly with nil error.
+func BindMultipart(req *http.Request, model interface{}, policy *BindPolicy, limits *FormLimits) error {
+	if policy == nil {
+		return nil
+	}
+	if limits == nil {
+		limits = &DefaultFormLimits
+	}
+
+	body, err := limitBody(req, limits)
+	if err != nil {
+		return err
+	}
+
+	maxMemory := limits.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultFormLimits.MaxMemory
+	}
+
+	if err = parseMultipartForm(req, maxMemory, limits.MaxFileSize); err != nil {
+		if body != nil && body.exceeded {
+			return resterrors.ErrRequestBodyTooLarge.New()
+		}
+		return err
+	}
+
+	err = mapValues(model, bodySource(req.MultipartForm.Value, policy), policy, policy.SearchDepthLevel)
+	if err != nil {
+		return err
+	}
+
+	// the files opened before an error are closed and their fields are cleared
+	var opened []openedFile
+	err = mapFiles(reflect.ValueOf(model).Elem(), req.MultipartForm.File, policy, limits,
+		policy.SearchDepthLevel, &opened)
+	if err != nil {
+		for _, o := range opened {
+			o.file.Close()
+			o.field.Set(reflect.Zero(o.field.Type()))
+		}
+		return err
+	}
+	return nil
+}
+
+// openedFile is the uploaded file opened by the mapFiles and set into the 'field'.
+type openedFile struct {
+	field reflect.Value
+	file  multipart.File
+}
+
+// parseMultipartForm parses the multipart request body as the http.Request.ParseMultipartForm,
+// but the uploaded file larger than the 'maxFileSize' results in the ErrRequestBodyTooLarge as
+// soon as its part is read, so that the rest of the body is neither read nor stored.
+func parseMultipartForm(req *http.Request, maxMemory, maxFileSize int64) error {
+	if maxFileSize <= 0 || req.MultipartForm != nil {
+		return req.ParseMultipartForm(maxMemory)
+	}
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return err
+	}
+	if err = req.ParseForm(); err != nil {
+		return err
+	}
+
+	// the parts checked by the 'limitParts' are read by the multipart.Reader.ReadForm,
+	// which stores the files in the memory or in the temporary files
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	var tooLarge string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var err error
+		tooLarge, err = limitParts(reader, writer, maxFileSize)
+		pw.CloseWithError(err)
+	}()
+
+	form, err := multipart.NewReader(pr, writer.Boundary()).ReadForm(maxMemory)
+	pr.Close()
+	<-done
+	if tooLarge != "" {
+		restErr := resterrors.ErrRequestBodyTooLarge.New()
+		restErr.AddDetailInfo("File: '" + tooLarge + "' is too large")
+		return restErr
+	}
+	if err != nil {
+		return err
+	}
+
+	for key, values := range form.Value {
+		req.Form[key] = append(req.Form[key], values...)
+		req.PostForm[key] = append(req.PostForm[key], values...)
+	}
+	req.MultipartForm = form
+	return nil
+}
+
+// limitParts copies the parts of the 'reader' into the 'writer'. Returns the name of the
+// first file larger than the 'maxFileSize', which stops the copying.
+func limitParts(reader *multipart.Reader, writer *multipart.Writer, maxFileSize int64) (string, error) {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", writer.Close()
+		}
+		if err != nil {
+			return "", err
+		}
+
+		w, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return "", err
+		}
+		if part.FileName() == "" {
+			if _, err = io.Copy(w, part); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		// read one byte more than the limit to check if it is exceeded
+		n, err := io.Copy(w, io.LimitReader(part, maxFileSize+1))
+		if err != nil {
+			return "", err
+		}
+		if n > maxFileSize {
+			return part.FileName(), errFileTooLarge
+		}
+	}
+}
+
+// bodySource creates the valueSource for the request body 'form' values, which may be set
+// only into the fields writable by the clients.
+func bodySource(form map[string][]string, policy *BindPolicy) *valueSource {
+	source := formSource(form, resterrors.ErrInvalidInput)
+	source.checkAccess, source.create = true, !policy.Update
+	return source
+}
+
+// mapFiles sets the file fields of the model with the uploaded 'files'.
+// The nested structs are searched if the 'searchDepthLevel' is greater than zero.
+// The files uploaded for the fields not writable by the clients result in an error.
+func mapFiles(
+	v reflect.Value,
+	files map[string][]*multipart.FileHeader,
+	policy *BindPolicy,
+	limits *FormLimits,
+	searchDepthLevel int,
+	opened *[]openedFile,
+) error {
+	plan := getPlan(v.Type(), policy.Tag)
+
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		sField := v.Field(fp.index)
+
+		fieldTag := fp.tag
+		if fieldTag == "-" || (policy.TaggedOnly && !fp.tagged) {
+			continue
+		}
+
+		writable := fp.access.Writable(!policy.Update)
+		if !fp.file {
+			// search the nested structs initialized by the mapForm
+			if sField.Kind() == reflect.Ptr && !sField.IsNil() {
+				sField = sField.Elem()
+			}
+			if sField.Kind() == reflect.Struct && !fp.custom && fp.timeLayout == nil &&
+				searchDepthLevel > 0 && writable {
+				err := mapFiles(sField, files, policy, limits, searchDepthLevel-1, opened)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if fieldTag == "" {
+			fieldTag = fp.lowerName
+		}
+
+		headers := files[fieldTag]
+		if len(headers) == 0 {
+			if fp.required {
+				restErr := resterrors.ErrInvalidInput.New()
+				restErr.AddDetailInfo("Missing required file: '" + fieldTag + "'")
+				return restErr
+			}
+			continue
+		}
+		if !writable {
+			return notWritableValue(fieldTag, fp.access)
+		}
+
+		// the file size limit is always enforced, also for the form parsed before
+		for _, header := range headers {
+			if limits.MaxFileSize > 0 && header.Size > limits.MaxFileSize {
+				restErr := resterrors.ErrRequestBodyTooLarge.New()
+				restErr.AddDetailInfo("File: '" + header.Filename + "' is too large")
+				return restErr
+			}
+		}
+
+		switch fp.field.Type {
+		case fileHeaderType:
+			sField.Set(reflect.ValueOf(headers[0]))
+		case fileHeaderSliceType:
+			sField.Set(reflect.ValueOf(headers))
+		default:
+			file, err := headers[0].Open()
+			if err != nil {
+				if policy.FailOnError {
+					return err
+				}
+				continue
+			}
+			sField.Set(reflect.ValueOf(file))
+			*opened = append(*opened, openedFile{field: sField, file: file})
+		}
+	}
+	return nil
+}
+
+// isFileType checks if the field of type 't' may be bound with the uploaded file.
+func isFileType(t reflect.Type) bool {
+	switch {
+	case t == fileHeaderType, t == fileHeaderSliceType:
+		return true
+	case t.Kind() == reflect.Interface:
+		return t.NumMethod() > 0 && multipartFileType.AssignableTo(t)
+	}
+	return false
+}
+
+// limitedBody is the request body that could not be read over its limit.
+// The 'exceeded' flag is set if the body is larger than the limit.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errBodyTooLarge
+	}
+	// read one byte more than remaining to check if the limit is exceeded
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return n, err
+	}
+	n = int(l.remaining)
+	l.remaining = 0
+	l.exceeded = true
+	return n, errBodyTooLarge
+}
+
+// limitBody limits the request body with the MaxBodySize limit.
+// Returns ErrRequestBodyTooLarge if the request content length is greater than the limit.
+func limitBody(req *http.Request, limits *FormLimits) (*limitedBody, error) {
+	if limits.MaxBodySize <= 0 || req.Body == nil {
+		return nil, nil
+	}
+	if req.ContentLength > limits.MaxBodySize {
+		return nil, resterrors.ErrRequestBodyTooLarge.New()
+	}
+	body := &limitedBody{ReadCloser: req.Body, remaining: limits.MaxBodySize}
+	req.Body = body
+	return body, nil
+}
@@ -0,0 +1,251 @@
+package forms
+
+import (
+	"bytes"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type upload struct {
+	Title       string                  `form:"title"`
+	Count       int                     `form:"count"`
+	Avatar      *multipart.FileHeader   `form:"avatar"`
+	Attachments []*multipart.FileHeader `form:"attachment"`
+	Content     io.Reader               `form:"content"`
+	Ignored     *multipart.FileHeader   `form:"-"`
+}
+
+type account struct {
+	Name    string                `form:"name"`
+	Role    string                `form:"role" rest:"readonly"`
+	Login   string                `form:"login" rest:"createonly"`
+	Secret  string                `form:"secret" rest:"hidden"`
+	Picture *multipart.FileHeader `form:"picture" rest:"readonly"`
+}
+
+func isNotWritable(err error) bool {
+	restErr, ok := err.(*resterrors.Error)
+	return ok && restErr.Compare(resterrors.ErrUnsupportedJSONField)
+}
+
+func multipartRequest(values map[string]string, files map[string][]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range values {
+		writer.WriteField(name, value)
+	}
+	for name, contents := range files {
+		for i, content := range contents {
+			part, _ := writer.CreateFormFile(name, name+string(rune('a'+i))+".txt")
+			part.Write([]byte(content))
+		}
+	}
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/uploads", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// countingBody counts the bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	read int
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.read += n
+	return n, err
+}
+
+func isBodyTooLarge(err error) bool {
+	restErr, ok := err.(*resterrors.Error)
+	return ok && restErr.Compare(resterrors.ErrRequestBodyTooLarge)
+}
+
+func TestBindForm(t *testing.T) {
+	Convey("Subject: BindForm binds url encoded form", t, func() {
+		policy := DefaultBindPolicy.Copy()
+
+		Convey("The form values are mapped with the policy rules", func() {
+			req := httptest.NewRequest("POST", "/uploads?title=query", strings.NewReader("title=Some+title&count=3"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			model := &upload{}
+			So(BindForm(req, model, policy, nil), ShouldBeNil)
+			So(model.Title, ShouldEqual, "Some title")
+			So(model.Count, ShouldEqual, 3)
+		})
+
+		Convey("The body larger than the limit results in ErrRequestBodyTooLarge", func() {
+			limits := DefaultFormLimits.Copy()
+			limits.MaxBodySize = 10
+
+			req := httptest.NewRequest("POST", "/uploads", strings.NewReader("title=Some+long+title"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			So(isBodyTooLarge(BindForm(req, &upload{}, policy, limits)), ShouldBeTrue)
+
+			Convey("Also if the content length is unknown", func() {
+				req := httptest.NewRequest("POST", "/uploads", ioutil.NopCloser(strings.NewReader("title=Some+long+title")))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.ContentLength = -1
+				So(isBodyTooLarge(BindForm(req, &upload{}, policy, limits)), ShouldBeTrue)
+			})
+		})
+
+		Convey("The fields not writable by the clients could not be set", func() {
+			formRequest := func(body string) *http.Request {
+				req := httptest.NewRequest("POST", "/accounts", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			}
+
+			model := &account{}
+			So(BindForm(formRequest("name=john&login=john"), model, policy, nil), ShouldBeNil)
+			So(model.Login, ShouldEqual, "john")
+
+			So(isNotWritable(BindForm(formRequest("name=john&role=admin"), &account{}, policy, nil)), ShouldBeTrue)
+			So(isNotWritable(BindForm(formRequest("secret=x"), &account{}, policy, nil)), ShouldBeTrue)
+
+			policy.Update = true
+			So(isNotWritable(BindForm(formRequest("login=john"), &account{}, policy, nil)), ShouldBeTrue)
+			So(BindForm(formRequest("name=john"), &account{}, policy, nil), ShouldBeNil)
+		})
+
+		Convey("The multipart body is bound with BindMultipart", func() {
+			req := multipartRequest(map[string]string{"title": "multipart"}, nil)
+			model := &upload{}
+			So(BindForm(req, model, policy, nil), ShouldBeNil)
+			So(model.Title, ShouldEqual, "multipart")
+		})
+
+		Convey("Nil policy does nothing", func() {
+			req := httptest.NewRequest("POST", "/uploads", strings.NewReader("title=Some+title"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			model := &upload{}
+			So(BindForm(req, model, nil, nil), ShouldBeNil)
+			So(model.Title, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestBindMultipart(t *testing.T) {
+	Convey("Subject: BindMultipart binds the multipart form and files", t, func() {
+		policy := DefaultBindPolicy.Copy()
+
+		Convey("The values and files are bound to the model", func() {
+			req := multipartRequest(map[string]string{"title": "files", "count": "2"},
+				map[string][]string{
+					"avatar":     {"avatar content"},
+					"attachment": {"first", "second"},
+					"content":    {"reader content"},
+					"ignored":    {"ignored"},
+				})
+
+			model := &upload{}
+			So(BindMultipart(req, model, policy, nil), ShouldBeNil)
+			So(model.Title, ShouldEqual, "files")
+			So(model.Count, ShouldEqual, 2)
+
+			So(model.Avatar, ShouldNotBeNil)
+			So(model.Avatar.Filename, ShouldEqual, "avatara.txt")
+			So(model.Attachments, ShouldHaveLength, 2)
+			So(model.Ignored, ShouldBeNil)
+
+			So(model.Content, ShouldNotBeNil)
+			content, err := ioutil.ReadAll(model.Content)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "reader content")
+		})
+
+		Convey("The nested structs are searched for files", func() {
+			type Nested struct {
+				Document *multipart.FileHeader `form:"document"`
+			}
+			type Model struct {
+				Nested *Nested
+			}
+			policy.SearchDepthLevel = 1
+
+			req := multipartRequest(nil, map[string][]string{"document": {"doc"}})
+			model := &Model{}
+			So(BindMultipart(req, model, policy, nil), ShouldBeNil)
+			So(model.Nested, ShouldNotBeNil)
+			So(model.Nested.Document, ShouldNotBeNil)
+		})
+
+		Convey("The fields not writable by the clients could not be set", func() {
+			req := multipartRequest(map[string]string{"role": "admin"}, nil)
+			So(isNotWritable(BindMultipart(req, &account{}, policy, nil)), ShouldBeTrue)
+
+			req = multipartRequest(map[string]string{"name": "john"}, map[string][]string{"picture": {"picture"}})
+			So(isNotWritable(BindMultipart(req, &account{}, policy, nil)), ShouldBeTrue)
+
+			type Model struct {
+				Account *account `rest:"readonly"`
+			}
+			policy.SearchDepthLevel = 1
+			req = multipartRequest(map[string]string{"name": "john"}, nil)
+			model := &Model{}
+			So(BindMultipart(req, model, policy, nil), ShouldBeNil)
+			So(model.Account, ShouldBeNil)
+		})
+
+		Convey("The file larger than MaxFileSize results in ErrRequestBodyTooLarge", func() {
+			limits := DefaultFormLimits.Copy()
+			limits.MaxFileSize = 4
+
+			req := multipartRequest(nil, map[string][]string{"avatar": {"avatar content"}})
+			So(isBodyTooLarge(BindMultipart(req, &upload{}, policy, limits)), ShouldBeTrue)
+
+			Convey("The rest of the body is not read", func() {
+				req := multipartRequest(nil, map[string][]string{"avatar": {strings.Repeat("a", 1<<20)}})
+				body := &countingBody{ReadCloser: req.Body}
+				req.Body = body
+
+				So(isBodyTooLarge(BindMultipart(req, &upload{}, policy, limits)), ShouldBeTrue)
+				So(body.read, ShouldBeLessThan, 1<<19)
+			})
+		})
+
+		Convey("The files opened before an error are closed and not set", func() {
+			type Model struct {
+				Content  io.Reader             `form:"content"`
+				Required *multipart.FileHeader `form:"required,required"`
+			}
+			req := multipartRequest(nil, map[string][]string{"content": {"content"}})
+			model := &Model{}
+			So(BindMultipart(req, model, policy, nil), ShouldBeError)
+			So(model.Content, ShouldBeNil)
+		})
+
+		Convey("The body larger than MaxBodySize results in ErrRequestBodyTooLarge", func() {
+			limits := DefaultFormLimits.Copy()
+			limits.MaxBodySize = 64
+
+			req := multipartRequest(nil, map[string][]string{"avatar": {strings.Repeat("a", 128)}})
+			So(isBodyTooLarge(BindMultipart(req, &upload{}, policy, limits)), ShouldBeTrue)
+
+			req = multipartRequest(nil, map[string][]string{"avatar": {strings.Repeat("a", 128)}})
+			req.Body = ioutil.NopCloser(req.Body)
+			req.ContentLength = -1
+			So(isBodyTooLarge(BindMultipart(req, &upload{}, policy, limits)), ShouldBeTrue)
+		})
+
+		Convey("Not multipart request results in error", func() {
+			req := httptest.NewRequest("POST", "/uploads", strings.NewReader("title=x"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			err := BindMultipart(req, &upload{}, policy, nil)
+			So(err, ShouldBeError)
+			So(isBodyTooLarge(err), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,537 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatchMediaType is the media type of the JSON Merge Patch (RFC 7396) documents
+	MergePatchMediaType = "application/merge-patch+json"
+
+	// JSONPatchMediaType is the media type of the JSON Patch (RFC 6902) documents
+	JSONPatchMediaType = "application/json-patch+json"
+)
+
+// patchOperation is a single operation of the JSON Patch document
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// IsPatchRequest checks if the request body is the JSON Merge Patch or JSON Patch document.
+func IsPatchRequest(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return mediaType == MergePatchMediaType || mediaType == JSONPatchMediaType
+}
+
+// BindPatch applies the JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) request body
+// to the JSON representation of the 'current' model and binds the result into the 'model'.
+// The patch document kind is recognised by the request 'Content-Type' header.
+// Returns the names of the model's struct fields that were present in the patch, so that
+// the fields set to zero values or null could be distinguished from the fields not provided.
+// The 'policy' MaxBodySize and DisallowUnknownFields rules are applied, if no policy
+// is provided (or nil) the DefaultJSONPolicy is used.
+// The errors are returned as the *resterrors.Error of prototype:
+//	ErrUnsupportedMediaType		- the request is neither JSON Merge Patch nor JSON Patch
+//	ErrInvalidJSONDocument		- the patch document is not valid
+//	ErrPatchConflict		- the JSON Patch operation could not be applied
+//	ErrUnsupportedJSONField		- the patch contains the field not matching any model's field
+//	ErrInvalidJSONFieldValue	- the patched value is not of the field's type
+//	ErrRequestBodyTooLarge		- the body exceeds the policy 'MaxBodySize'
+func BindPatch(req *http.Request, current, model interface{}, policy *JSONPolicy) ([]string, error) {
+	if policy == nil {
+		policy = &DefaultJSONPolicy
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != MergePatchMediaType && mediaType != JSONPatchMediaType {
+		restErr := resterrors.ErrUnsupportedMediaType.New()
+		restErr.AddDetailInfo("Unsupported patch media type: '" + mediaType + "'")
+		return nil, restErr
+	}
+
+	body, err := limitBody(req, &FormLimits{MaxBodySize: policy.MaxBodySize})
+	if err != nil {
+		return nil, err
+	}
+	if req.Body == nil {
+		return nil, invalidPatch("Empty request body")
+	}
+
+	patch, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		if body != nil && body.exceeded {
+			return nil, resterrors.ErrRequestBodyTooLarge.New()
+		}
+		return nil, err
+	}
+
+	currentDoc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err = decodeJSONNumber(currentDoc, &doc); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if mediaType == MergePatchMediaType {
+		doc, keys, err = applyMergePatch(reflect.TypeOf(model), doc, patch)
+	} else {
+		doc, keys, err = applyJSONPatch(reflect.TypeOf(model), doc, patch)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := patchedFields(reflect.TypeOf(model), keys, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.DisallowUnknownFields {
+		if err = checkFields(reflect.TypeOf(model), doc, fieldRules{known: true}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	if policy.UseNumber {
+		decoder.UseNumber()
+	}
+	if err = decoder.Decode(model); err != nil {
+		return nil, jsonError(err, nil)
+	}
+	return fields, nil
+}
+
+// applyMergePatch applies the JSON Merge Patch to the 'doc' of type 't'.
+// Returns the patched document and the patched top-level members.
+func applyMergePatch(t reflect.Type, doc interface{}, data []byte) (interface{}, []string, error) {
+	var patch interface{}
+	if err := decodeJSONNumber(data, &patch); err != nil {
+		return nil, nil, invalidPatch(err.Error())
+	}
+
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return nil, nil, invalidPatch("The merge patch must be a JSON object")
+	}
+
+	// the patched model is being updated
+	if err := checkFields(t, patchObj, fieldRules{writable: true}, nil); err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(patchObj))
+	for key := range patchObj {
+		keys = append(keys, key)
+	}
+	return mergePatch(doc, patchObj), keys, nil
+}
+
+// mergePatch is the MergePatch function defined in the RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// applyJSONPatch applies the JSON Patch operations to the 'doc' of type 't'.
+// Returns the patched document and the patched top-level members.
+func applyJSONPatch(t reflect.Type, doc interface{}, data []byte) (interface{}, []string, error) {
+	var operations []patchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, nil, invalidPatch(err.Error())
+	}
+
+	var keys []string
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return nil, nil, invalidPatch("Missing 'path' in operation: " + strconv.Itoa(i))
+		}
+
+		path, err := parsePointer(*operation.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 0 {
+			return nil, nil, invalidPatch("The whole document could not be patched")
+		}
+
+		var from []string
+		if operation.Op == "move" || operation.Op == "copy" {
+			if operation.From == nil {
+				return nil, nil, invalidPatch("Missing 'from' in operation: " + strconv.Itoa(i))
+			}
+			if from, err = parsePointer(*operation.From); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		var value interface{}
+		switch operation.Op {
+		case "add", "replace", "test":
+			if operation.Value == nil {
+				return nil, nil, invalidPatch("Missing 'value' in operation: " + strconv.Itoa(i))
+			}
+			if err = decodeJSONNumber(operation.Value, &value); err != nil {
+				return nil, nil, invalidPatch(err.Error())
+			}
+		}
+
+		if err = checkOperationAccess(t, operation.Op, path, from, value); err != nil {
+			return nil, nil, err
+		}
+
+		switch operation.Op {
+		case "add":
+			doc, err = pointerAdd(doc, path, value)
+		case "remove":
+			doc, _, err = pointerRemove(doc, path)
+		case "replace":
+			if doc, _, err = pointerRemove(doc, path); err == nil {
+				doc, err = pointerAdd(doc, path, value)
+			}
+		case "move":
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, nil, invalidPatch("Cannot move the value into its own child")
+			}
+			if doc, value, err = pointerRemove(doc, from); err == nil {
+				doc, err = pointerAdd(doc, path, value)
+			}
+			if len(from) > 0 {
+				keys = append(keys, from[0])
+			}
+		case "copy":
+			if value, err = pointerGet(doc, from); err == nil {
+				if err = checkOperationAccess(t, "add", path, nil, value); err == nil {
+					doc, err = pointerAdd(doc, path, deepCopyJSON(value))
+				}
+			}
+		case "test":
+			var actual interface{}
+			if actual, err = pointerGet(doc, path); err == nil && !jsonEqual(actual, value) {
+				err = patchConflict("Test failed for path: '" + *operation.Path + "'")
+			}
+		default:
+			return nil, nil, invalidPatch("Unsupported operation: '" + operation.Op + "'")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if operation.Op != "test" {
+			keys = append(keys, path[0])
+		}
+	}
+	return doc, keys, nil
+}
+
+// checkOperationAccess checks if the JSON Patch operation changes only the writable fields.
+func checkOperationAccess(t reflect.Type, op string, path, from []string, value interface{}) error {
+	if op == "test" {
+		return nil
+	}
+	valueType, err := checkPathAccess(t, path, false)
+	if err != nil {
+		return err
+	}
+	if op == "move" {
+		if _, err = checkPathAccess(t, from, false); err != nil {
+			return err
+		}
+	}
+	if valueType != nil && (op == "add" || op == "replace") {
+		return checkFields(valueType, value, fieldRules{writable: true}, path)
+	}
+	return nil
+}
+
+// parsePointer parses the JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, invalidPatch("Invalid JSON pointer: '" + pointer + "'")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// pointerGet returns the value referenced by the 'path'.
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, pathNotFound(path)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1, path)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, pathNotFound(path)
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd adds the 'value' at the 'path' location. Returns the modified document.
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return modifyParent(doc, path, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			i, err := arrayIndex(token, len(node), path)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		return nil, pathNotFound(path)
+	})
+}
+
+// pointerRemove removes the value at the 'path' location.
+// Returns the modified document and the removed value.
+func pointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	var removed interface{}
+	doc, err := modifyParent(doc, path, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, pathNotFound(path)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1, path)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		}
+		return nil, pathNotFound(path)
+	})
+	return doc, removed, err
+}
+
+// modifyParent walks the 'doc' to the parent of the last 'tokens' element and calls the
+// 'modify' function on it. The modified containers are set back into their parents,
+// as the arrays may be reallocated.
+func modifyParent(
+	doc interface{},
+	tokens, path []string,
+	modify func(parent interface{}, token string) (interface{}, error),
+) (interface{}, error) {
+	if len(tokens) == 1 {
+		return modify(doc, tokens[0])
+	}
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[tokens[0]]
+		if !ok {
+			return nil, pathNotFound(path)
+		}
+		child, err := modifyParent(child, tokens[1:], path, modify)
+		if err != nil {
+			return nil, err
+		}
+		node[tokens[0]] = child
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(node)-1, path)
+		if err != nil {
+			return nil, err
+		}
+		child, err := modifyParent(node[i], tokens[1:], path, modify)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+		return node, nil
+	}
+	return nil, pathNotFound(path)
+}
+
+// arrayIndex parses the array index 'token' not greater than 'max'.
+func arrayIndex(token string, max int, path []string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, pathNotFound(path)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, pathNotFound(path)
+	}
+	return i, nil
+}
+
+// patchedFields maps the patched top-level JSON members to the struct field names of type 't'.
+func patchedFields(t reflect.Type, keys []string, policy *JSONPolicy) ([]string, error) {
+	var fields []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		field, ok := refutils.JSONFieldByName(t, key)
+		if !ok {
+			if policy.DisallowUnknownFields {
+				return nil, unknownField([]string{key})
+			}
+			continue
+		}
+		if !seen[field.Field.Name] {
+			seen[field.Field.Name] = true
+			fields = append(fields, field.Field.Name)
+		}
+	}
+	return fields, nil
+}
+
+// decodeJSONNumber decodes the 'data' into 'v' using the json.Number for numbers.
+func decodeJSONNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// deepCopyJSON copies the decoded JSON value.
+func deepCopyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			copied[key] = deepCopyJSON(elem)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, elem := range v {
+			copied[i] = deepCopyJSON(elem)
+		}
+		return copied
+	}
+	return value
+}
+
+// jsonEqual compares the decoded JSON values. The numbers are compared by their value.
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if av == bv {
+			return true
+		}
+		af, aErr := av.Float64()
+		bf, bErr := bv.Float64()
+		return aErr == nil && bErr == nil && af == bf
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidPatch(info string) *resterrors.Error {
+	restErr := resterrors.ErrInvalidJSONDocument.New()
+	restErr.AddDetailInfo(info)
+	return restErr
+}
+
+func patchConflict(info string) *resterrors.Error {
+	restErr := resterrors.ErrPatchConflict.New()
+	restErr.AddDetailInfo(info)
+	return restErr
+}
+
+func pathNotFound(path []string) *resterrors.Error {
+	return patchConflict("Path not found: '/" + strings.Join(path, "/") + "'")
+}
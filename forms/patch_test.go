@@ -0,0 +1,152 @@
+package forms
+
+import (
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type patchBase struct {
+	Version int `json:"version"`
+}
+
+type patchModel struct {
+	patchBase
+	ID       int               `json:"id"`
+	Name     string            `json:"name"`
+	Active   bool              `json:"active"`
+	Nickname *string           `json:"nickname"`
+	Tags     []string          `json:"tags"`
+	Meta     map[string]string `json:"meta"`
+	Secret   string            `json:"-"`
+}
+
+func currentPatchModel() *patchModel {
+	nickname := "johnny"
+	return &patchModel{
+		patchBase: patchBase{Version: 1},
+		ID:        1, Name: "john", Active: true, Nickname: &nickname,
+		Tags: []string{"a", "b"}, Meta: map[string]string{"city": "Warsaw", "zip": "00-001"},
+	}
+}
+
+func bindPatchBody(mediaType, body string, policy *JSONPolicy) (*patchModel, []string, error) {
+	req := httptest.NewRequest("PATCH", "/models/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", mediaType)
+	model := &patchModel{}
+	fields, err := BindPatch(req, currentPatchModel(), model, policy)
+	return model, fields, err
+}
+
+func TestBindPatch(t *testing.T) {
+	Convey("Subject: BindPatch applies the patch documents", t, func() {
+		policy := DefaultJSONPolicy.Copy()
+
+		Convey("IsPatchRequest recognises the patch media types", func() {
+			req := httptest.NewRequest("PATCH", "/models/1", nil)
+			req.Header.Set("Content-Type", MergePatchMediaType+"; charset=utf-8")
+			So(IsPatchRequest(req), ShouldBeTrue)
+
+			req.Header.Set("Content-Type", "application/json")
+			So(IsPatchRequest(req), ShouldBeFalse)
+		})
+
+		Convey("The merge patch sets the zero values and nulls", func() {
+			model, fields, err := bindPatchBody(MergePatchMediaType,
+				`{"active":false,"name":"","nickname":null,"meta":{"zip":null},"version":2}`, policy)
+			So(err, ShouldBeNil)
+			So(fields, ShouldHaveLength, 5)
+			So(fields, ShouldContain, "Active")
+			So(fields, ShouldContain, "Name")
+			So(fields, ShouldContain, "Nickname")
+			So(fields, ShouldContain, "Meta")
+			So(fields, ShouldContain, "Version")
+
+			So(model.Active, ShouldBeFalse)
+			So(model.Name, ShouldBeEmpty)
+			So(model.Nickname, ShouldBeNil)
+			So(model.Meta, ShouldResemble, map[string]string{"city": "Warsaw"})
+			So(model.Version, ShouldEqual, 2)
+
+			Convey("The fields not present in the patch keep the current values", func() {
+				So(model.ID, ShouldEqual, 1)
+				So(model.Tags, ShouldResemble, []string{"a", "b"})
+			})
+		})
+
+		Convey("The merge patch must be an object", func() {
+			_, _, err := bindPatchBody(MergePatchMediaType, `["name"]`, policy)
+			So(isRestError(err, resterrors.ErrInvalidJSONDocument), ShouldBeTrue)
+		})
+
+		Convey("The JSON patch operations are applied", func() {
+			model, fields, err := bindPatchBody(JSONPatchMediaType, `[
+				{"op":"test","path":"/name","value":"john"},
+				{"op":"replace","path":"/active","value":false},
+				{"op":"add","path":"/tags/1","value":"c"},
+				{"op":"remove","path":"/tags/0"},
+				{"op":"add","path":"/tags/-","value":"d"},
+				{"op":"copy","from":"/meta/city","path":"/name"},
+				{"op":"move","from":"/nickname","path":"/meta/nickname"}
+			]`, policy)
+			So(err, ShouldBeNil)
+			So(fields, ShouldResemble, []string{"Active", "Tags", "Name", "Nickname", "Meta"})
+
+			So(model.Active, ShouldBeFalse)
+			So(model.Tags, ShouldResemble, []string{"c", "b", "d"})
+			So(model.Name, ShouldEqual, "Warsaw")
+			So(model.Nickname, ShouldBeNil)
+			So(model.Meta["nickname"], ShouldEqual, "johnny")
+		})
+
+		Convey("The failed test operation results in ErrPatchConflict", func() {
+			_, _, err := bindPatchBody(JSONPatchMediaType, `[{"op":"test","path":"/version","value":1.0},
+				{"op":"test","path":"/name","value":"doe"}]`, policy)
+			So(isRestError(err, resterrors.ErrPatchConflict), ShouldBeTrue)
+
+			_, _, err = bindPatchBody(JSONPatchMediaType, `[{"op":"remove","path":"/tags/5"}]`, policy)
+			So(isRestError(err, resterrors.ErrPatchConflict), ShouldBeTrue)
+		})
+
+		Convey("The invalid JSON patch results in ErrInvalidJSONDocument", func() {
+			for _, body := range []string{
+				`{"op":"add"}`,
+				`[{"op":"add","path":"/name"}]`,
+				`[{"op":"unknown","path":"/name"}]`,
+				`[{"op":"move","path":"/name"}]`,
+				`[{"op":"remove","path":"name"}]`,
+			} {
+				_, _, err := bindPatchBody(JSONPatchMediaType, body, policy)
+				So(isRestError(err, resterrors.ErrInvalidJSONDocument), ShouldBeTrue)
+			}
+		})
+
+		Convey("The unknown fields are rejected if the policy requires it", func() {
+			_, fields, err := bindPatchBody(MergePatchMediaType, `{"secret":"x","name":"doe"}`, policy)
+			So(err, ShouldBeNil)
+			So(fields, ShouldResemble, []string{"Name"})
+
+			policy.DisallowUnknownFields = true
+			_, _, err = bindPatchBody(MergePatchMediaType, `{"secret":"x"}`, policy)
+			So(isRestError(err, resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+		})
+
+		Convey("The invalid value results in ErrInvalidJSONFieldValue", func() {
+			_, _, err := bindPatchBody(MergePatchMediaType, `{"active":"yes"}`, policy)
+			So(isRestError(err, resterrors.ErrInvalidJSONFieldValue), ShouldBeTrue)
+		})
+
+		Convey("The body larger than MaxBodySize results in ErrRequestBodyTooLarge", func() {
+			policy.MaxBodySize = 4
+			_, _, err := bindPatchBody(MergePatchMediaType, `{"name":"doe"}`, policy)
+			So(isRestError(err, resterrors.ErrRequestBodyTooLarge), ShouldBeTrue)
+		})
+
+		Convey("Other media types result in ErrUnsupportedMediaType", func() {
+			_, _, err := bindPatchBody("application/json", `{"name":"doe"}`, policy)
+			So(isRestError(err, resterrors.ErrUnsupportedMediaType), ShouldBeTrue)
+		})
+	})
+}
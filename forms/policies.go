@@ -15,6 +15,11 @@ type BindPolicy struct {
 
 	// SearchDepthLevel defines how deep the binding function should search
 	SearchDepthLevel int
+
+	// Update defines if the model bound by the BindForm or BindMultipart is being updated,
+	// thus its fields tagged as `rest:"createonly"` are not writable.
+	// The other binding functions do not check the fields access.
+	Update bool
 }
 
 // Copy creates a copy of the BindPolicy
@@ -51,6 +56,24 @@ var (
 		SearchDepthLevel: 0,
 	}
 
+	// DefaultHeaderPolicy is the default BindPolicy used by the BindHeaders function.
+	// It matches the fields with the 'header' tag only.
+	DefaultHeaderPolicy = BindPolicy{
+		TaggedOnly:       true,
+		FailOnError:      false,
+		Tag:              "header",
+		SearchDepthLevel: 0,
+	}
+
+	// DefaultCookiePolicy is the default BindPolicy used by the BindCookies function.
+	// It matches the fields with the 'cookie' tag only.
+	DefaultCookiePolicy = BindPolicy{
+		TaggedOnly:       true,
+		FailOnError:      false,
+		Tag:              "cookie",
+		SearchDepthLevel: 0,
+	}
+
 	// DefaultParamPolicy is a default ParamPolicy.
 	// It sets the default param tag to 'param'.
 	// Every other fields are set to false (TaggedOnly, FailOnError and DeepSearch)
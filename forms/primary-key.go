@@ -0,0 +1,104 @@
+package forms
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StringIDSetter defines interface for data Models with non-numeric IDs
+// i.e. UUID's or slugs. Defines SetStringID() method.
+// The StringIDSetter has precedence over the IDSetter.
+type StringIDSetter interface {
+	SetStringID(id string) error
+}
+
+var primaryKey = struct {
+	sync.RWMutex
+	tag    string
+	option string
+}{tag: "gorm", option: "primary_key"}
+
+// SetPrimaryKeyTag sets the struct 'tag' and its 'option' that marks the primary key
+// field of the models. The options of the tag are separated with ';' or ','.
+// If the 'option' is empty, any field with the non-empty 'tag' (other than '-') is the primary key.
+// By default the primary key is marked with the `gorm:"primary_key"` tag.
+// If none of the model's fields is marked as the primary key, the field named 'ID'
+// (case insensitive) is used.
+func SetPrimaryKeyTag(tag, option string) {
+	primaryKey.Lock()
+	primaryKey.tag, primaryKey.option = tag, option
+	primaryKey.Unlock()
+
+	// the cached plans depend on the primary key tag
+	planCache.Range(func(key, value interface{}) bool {
+		planCache.Delete(key)
+		return true
+	})
+}
+
+// PrimaryKeyTag returns the struct tag and its option that marks the primary key field.
+func PrimaryKeyTag() (tag, option string) {
+	primaryKey.RLock()
+	defer primaryKey.RUnlock()
+	return primaryKey.tag, primaryKey.option
+}
+
+// PrimaryKeyField returns the primary key field of the struct type 't'.
+// Returns false if the struct does not contain the primary key field.
+func PrimaryKeyField(t reflect.Type) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	plan := getPlan(t, "")
+	if plan.idIndex == -1 {
+		return reflect.StructField{}, false
+	}
+	return plan.fields[plan.idIndex].field, true
+}
+
+// isPrimaryKeyTag checks if the 'field' is tagged as the primary key.
+func isPrimaryKeyTag(field reflect.StructField) bool {
+	tag, option := PrimaryKeyTag()
+	if tag == "" {
+		return false
+	}
+	value := field.Tag.Get(tag)
+	if value == "" || value == "-" {
+		return false
+	}
+	if option == "" {
+		return true
+	}
+	for _, opt := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		// the option may be followed by the value i.e. 'primary_key:true'
+		if i := strings.Index(opt, ":"); i != -1 {
+			opt = opt[:i]
+		}
+		if strings.EqualFold(strings.TrimSpace(opt), option) {
+			return true
+		}
+	}
+	return false
+}
+
+// setIDWithSetter sets the 'id' using the StringIDSetter or IDSetter implementation of the 'model'.
+// Returns false if the model does not implement any of them.
+func setIDWithSetter(model interface{}, id string) (bool, error) {
+	if setter, ok := model.(StringIDSetter); ok {
+		return true, setter.SetStringID(id)
+	}
+	if setter, ok := model.(IDSetter); ok {
+		uintID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return true, err
+		}
+		setter.SetID(uintID)
+		return true, nil
+	}
+	return false, nil
+}
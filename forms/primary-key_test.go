@@ -0,0 +1,99 @@
+package forms
+
+import (
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type slugModel struct {
+	slug string
+}
+
+func (s *slugModel) SetStringID(id string) error {
+	if strings.ContainsAny(id, " /") {
+		return errors.New("Invalid slug")
+	}
+	s.slug = id
+	return nil
+}
+
+type taggedPKModel struct {
+	ID   int
+	Code string `gorm:"type:varchar(10);PRIMARY_KEY"`
+}
+
+type customPKModel struct {
+	Key  string `pk:"true"`
+	Name string
+}
+
+func TestPrimaryKey(t *testing.T) {
+	Convey("Subject: non-numeric primary keys", t, func() {
+		Convey("SetID uses the StringIDSetter", func() {
+			model := &slugModel{}
+			So(SetID(model, "some-slug"), ShouldBeNil)
+			So(model.slug, ShouldEqual, "some-slug")
+			So(SetID(model, "some slug"), ShouldBeError)
+		})
+
+		Convey("SetID sets the field tagged as the primary key", func() {
+			model := &taggedPKModel{}
+			So(SetID(model, "abc"), ShouldBeNil)
+			So(model.Code, ShouldEqual, "abc")
+			So(model.ID, ShouldEqual, 0)
+
+			field, ok := PrimaryKeyField(reflect.TypeOf(model))
+			So(ok, ShouldBeTrue)
+			So(field.Name, ShouldEqual, "Code")
+		})
+
+		Convey("BindParams sets the string ID", func() {
+			req := httptest.NewRequest("GET", "/models/abc", nil)
+			policy := DefaultParamPolicy.Copy()
+
+			slug := &slugModel{}
+			err := BindParams(req, slug, getParamFuncWithValues(map[string]string{"slugmodel": "abc"}), policy)
+			So(err, ShouldBeNil)
+			So(slug.slug, ShouldEqual, "abc")
+
+			tagged := &taggedPKModel{}
+			err = BindParams(req, tagged, getParamFuncWithValues(map[string]string{
+				"taggedpkmodel": "abc", "id": "12",
+			}), policy)
+			So(err, ShouldBeNil)
+			So(tagged.Code, ShouldEqual, "abc")
+			So(tagged.ID, ShouldEqual, 12)
+		})
+
+		Convey("The primary key tag is configurable", func() {
+			SetPrimaryKeyTag("pk", "")
+			Reset(func() {
+				SetPrimaryKeyTag("gorm", "primary_key")
+			})
+
+			tag, option := PrimaryKeyTag()
+			So(tag, ShouldEqual, "pk")
+			So(option, ShouldBeEmpty)
+
+			model := &customPKModel{}
+			So(SetID(model, "key"), ShouldBeNil)
+			So(model.Key, ShouldEqual, "key")
+
+			// the gorm tag is no longer used, thus the 'ID' field is the primary key
+			tagged := &taggedPKModel{}
+			So(SetID(tagged, "12"), ShouldBeNil)
+			So(tagged.ID, ShouldEqual, 12)
+			So(tagged.Code, ShouldBeEmpty)
+		})
+
+		Convey("The model without primary key returns an error", func() {
+			_, ok := PrimaryKeyField(reflect.TypeOf(customPKModel{}))
+			So(ok, ShouldBeFalse)
+			So(SetID(&customPKModel{}, "key"), ShouldEqual, ErrIncorrectModel)
+		})
+	})
+}
@@ -2,12 +2,8 @@ package forms
 
 import (
 	"errors"
-	"github.com/kucjac/go-rest-sdk/refutils"
 	"net/http"
 	"reflect"
-	"strconv"
-	"strings"
-	"time"
 )
 
 var ErrNoParamGetterFunc = errors.New("No param getter func provided")
@@ -70,9 +66,15 @@ func mapParam(
 	// idAlreadySet is control flag that defines if id was set
 	var idAlreadySet bool
 
+	// Get reflect.Value of model
+	v := reflect.ValueOf(model).Elem()
+
+	// Get the cached plan for the model's type
+	plan := getPlan(v.Type(), policy.Tag)
+
 	// if paramName is an empty string - set it by default as Model struct Name.
 	if paramName == "" {
-		paramName = refutils.ModelName(model)
+		paramName = plan.name
 	}
 	// Get the parameter from the getParam function
 	modelID, err := getParam(paramName, req)
@@ -85,14 +87,12 @@ func mapParam(
 		return ErrIncorrectModel
 	}
 
-	// If given model implements IDSetter, set ID  using SetID method
+	// If given model implements StringIDSetter or IDSetter, set ID using its method
 	// Returns if an error occurs or policy.IDOnly is true.
-	if setter, ok := model.(IDSetter); ok {
-		uintID, err := strconv.ParseUint(modelID, 10, 64)
+	if ok, err := setIDWithSetter(model, modelID); ok {
 		if err != nil {
 			return err
 		}
-		setter.SetID(uintID)
 
 		if policy.IDOnly && searchDepthLevel == 0 {
 			return nil
@@ -100,68 +100,52 @@ func mapParam(
 		idAlreadySet = true
 	}
 
-	// Get reflect.Type of model
-	t := reflect.TypeOf(model).Elem()
-
-	// Get reflect.Value of model
-	v := reflect.ValueOf(model).Elem()
-
 	// iterate  over model fields
-	for i := 0; i < t.NumField(); i++ {
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		sField := v.Field(fp.index)
 
-		tField := t.Field(i)
-		sField := v.Field(i)
-
-		// Check if field is settable - can be addresable or is public
-		if !sField.CanSet() {
-			continue
-		}
-
-		switch sField.Kind() {
+		switch fp.kind {
 		case reflect.Slice, reflect.Interface, reflect.Array:
-			continue
+			if !fp.custom {
+				continue
+			}
 		default:
 		}
 
 		// Check if the field has a tag query
-		fieldTag := tField.Tag.Get(policy.Tag)
+		fieldTag := fp.tag
 
 		// If tag is set to '-' don't map values
-		if fieldTag == "-" || (fieldTag == "" && policy.TaggedOnly) {
+		if fieldTag == "-" || (!fp.tagged && policy.TaggedOnly) {
 			continue
 		}
 
 		// if sField is a Ptr check where it points to.
-		if sField.Kind() == reflect.Ptr {
-			var initialize bool
-			switch tField.Type.Elem().Kind() {
+		// The pointers to the custom and basic types are initialized only
+		// if the param value is set.
+		if fp.kind == reflect.Ptr && !fp.custom {
+			switch fp.elemKind {
 			case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Array:
 				continue
 			case reflect.Struct:
-				if sField.IsNil() && policy.SearchDepthLevel > 0 {
-					initialize = true
-				} else if policy.SearchDepthLevel <= 0 {
+				if policy.SearchDepthLevel <= 0 {
 					continue
 				}
-			default:
 				// if the sField is nil - create new item of type given struct type
 				if sField.IsNil() {
-					initialize = true
+					sField.Set(reflect.New(fp.field.Type.Elem()))
 				}
-			}
-			if initialize {
-				sField.Set(reflect.New(tField.Type.Elem()))
 				sField = sField.Elem()
 			}
 		}
 
 		// if the field is of Struct Type
-		if sField.Kind() == reflect.Struct {
+		if sField.Kind() == reflect.Struct && !fp.custom {
 			// distinguish the
 			// if it is time field set it as a time
-			_, isTime := sField.Interface().(time.Time)
-			if isTime {
-				err = paramSetTime(sField, tField, getParam, req, fieldTag)
+			if sField.Type() == timeType {
+				err = paramSetTime(sField, fp.timeLayout, getParam, req, fieldTag)
 				if err != nil && policy.FailOnError {
 					return err
 				}
@@ -181,10 +165,10 @@ func mapParam(
 			continue
 		}
 
-		var lowerCasedName string = strings.ToLower(tField.Name)
+		var lowerCasedName string = fp.lowerName
 
 		// if given field is an id, which was not yet set
-		if !idAlreadySet && modelID != "" && (lowerCasedName == "id" || fieldTag == "id") {
+		if !idAlreadySet && modelID != "" && (fp.primary || fieldTag == "id") {
 
 			err := setFieldWithType(sField.Kind(), modelID, sField)
 			if err != nil {
@@ -230,7 +214,7 @@ func mapParam(
 		if err != nil && policy.FailOnError {
 			return err
 
-		} else if lowerCasedName == "id" {
+		} else if fp.primary {
 			// if the correctly setted field was an id
 			// set the 'idAlreadySet' flag to true
 			idAlreadySet = true
@@ -244,7 +228,7 @@ func mapParam(
 }
 
 func paramSetTime(sField reflect.Value,
-	tField reflect.StructField,
+	layout *timeLayout,
 	getParam ParamGetterFunc,
 	req *http.Request,
 	fieldTag string,
@@ -254,7 +238,7 @@ func paramSetTime(sField reflect.Value,
 		return err
 	}
 
-	err = setTimeField(timeValue, tField, sField)
+	err = setTimeField(timeValue, layout, sField)
 	if err != nil {
 		return err
 	}
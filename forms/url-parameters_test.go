@@ -276,9 +276,9 @@ func TestBindParams(t *testing.T) {
 
 func TestParamSetTime(t *testing.T) {
 	sField := reflect.ValueOf(1)
-	tField := reflect.StructField{}
+	layout := newTimeLayout(reflect.StructField{})
 	errorMap := map[string]error{"test": errors.New("Error")}
-	err := paramSetTime(sField, tField, getParamErrFunc(errorMap), nil, "test")
+	err := paramSetTime(sField, layout, getParamErrFunc(errorMap), nil, "test")
 	if err == nil {
 		t.Error(err)
 	}
@@ -0,0 +1,16 @@
+/*
+	Package generic contains the type-safe counterparts of the repository.Repository
+	and handlers.GenericHandler based on the Go generics.
+
+	The Repository[T] takes and returns the pointers to the model 'T', so that the model type
+	is checked at compile time and the List results need no type assertions.
+	The existing repositories, like gormrepo.GORMRepository or mockrepo.MockRepository,
+	are adapted with the Wrap function. The Unwrap function adapts the Repository[T] back
+	into the repository.Repository.
+
+	The Handler[T] embeds the handlers.GenericHandler, so that it shares its configuration,
+	while the operation handlers are created without the model argument.
+
+	The package requires Go 1.18 or later.
+*/
+package generic
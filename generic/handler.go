@@ -0,0 +1,127 @@
+//go:build go1.18
+// +build go1.18
+
+package generic
+
+import (
+	"errors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/logger"
+	"github.com/kucjac/go-rest-sdk/response"
+	"net/http"
+)
+
+// ErrNilRepository is returned by the NewHandler if no repository is provided.
+var ErrNilRepository = errors.New("Repository cannot be nil.")
+
+// Handler is the type-safe handlers.GenericHandler for the model 'T'.
+// The embedded GenericHandler is configured with the With... methods as usual,
+// while the operation handlers need no model argument.
+// I.e.:
+//	users, err := generic.NewHandler[User](generic.Wrap[User](gormRepo), errhandler.New(), nil, nil)
+//	router.Post("/users", users.Create())
+//
+// The nested handlers (handlers.ParentScope) get the parent model from the repository,
+// thus the Repository[T] must be created with the Wrap function of the repository that
+// handles the parent model.
+type Handler[T any] struct {
+	*handlers.GenericHandler
+}
+
+// NewHandler creates the Handler for the model 'T' using the typed 'repo'.
+func NewHandler[T any](
+	repo Repository[T],
+	errHandler *errhandler.ErrorHandler,
+	responseBody response.Responser,
+	logs logger.ExtendedLeveledLogger,
+) (*Handler[T], error) {
+	if repo == nil {
+		return nil, ErrNilRepository
+	}
+	handler, err := handlers.New(Unwrap[T](repo), errHandler, responseBody, logs)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler[T]{GenericHandler: handler}, nil
+}
+
+// New creates a copy of given handler and returns it.
+func (h *Handler[T]) New() *Handler[T] {
+	return &Handler[T]{GenericHandler: h.GenericHandler.New()}
+}
+
+// Repository returns the typed repository of the handler.
+func (h *Handler[T]) Repository() Repository[T] {
+	return Wrap[T](h.Repo)
+}
+
+// Create returns the http.HandlerFunc that creates the model 'T'.
+func (h *Handler[T]) Create() http.HandlerFunc {
+	return h.GenericHandler.Create(new(T))
+}
+
+// Get returns the http.HandlerFunc that responds with the model 'T'.
+func (h *Handler[T]) Get() http.HandlerFunc {
+	return h.GenericHandler.Get(new(T))
+}
+
+// List returns the http.HandlerFunc that lists the models 'T'.
+func (h *Handler[T]) List() http.HandlerFunc {
+	return h.GenericHandler.List(new(T))
+}
+
+// Update returns the http.HandlerFunc that updates the model 'T'.
+func (h *Handler[T]) Update() http.HandlerFunc {
+	return h.GenericHandler.Update(new(T))
+}
+
+// Patch returns the http.HandlerFunc that patches the model 'T'.
+func (h *Handler[T]) Patch() http.HandlerFunc {
+	return h.GenericHandler.Patch(new(T))
+}
+
+// Delete returns the http.HandlerFunc that deletes the model 'T'.
+func (h *Handler[T]) Delete() http.HandlerFunc {
+	return h.GenericHandler.Delete(new(T))
+}
+
+// Handler returns the http.HandlerFunc for given operation 'op'.
+func (h *Handler[T]) Handler(op handlers.Operation) http.HandlerFunc {
+	return h.GenericHandler.Handler(op, new(T))
+}
+
+// Route describes given operation 'op' mounted at the 'path'.
+func (h *Handler[T]) Route(op handlers.Operation, path string) handlers.Route {
+	return h.GenericHandler.Route(op, path, new(T))
+}
+
+// Register records the route of the 'handler' in the 'registry' and returns
+// the http.HandlerFunc for given operation 'op'.
+func Register[T any](
+	registry *handlers.RouteRegistry,
+	path string,
+	op handlers.Operation,
+	handler *Handler[T],
+) http.HandlerFunc {
+	return registry.Register(path, op, handler.GenericHandler, new(T))
+}
+
+// Hook adapts the typed 'hook' into the handlers.Hook. The hooks of the List operation
+// that run after the repository call are called for each of the listed models.
+// The 'hook' is not called for the objects of other types than *T.
+func Hook[T any](hook func(req *http.Request, op handlers.Operation, obj *T) error) handlers.Hook {
+	return func(req *http.Request, op handlers.Operation, obj interface{}) error {
+		switch typed := obj.(type) {
+		case *T:
+			return hook(req, op, typed)
+		case []*T:
+			for _, elem := range typed {
+				if err := hook(req, op, elem); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
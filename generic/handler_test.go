@@ -0,0 +1,77 @@
+//go:build go1.18
+// +build go1.18
+
+package generic
+
+import (
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Subject: Handler[T] serves the operations of the typed repository", t, func() {
+		repo := &usersRepository{}
+
+		handler, err := NewHandler[User](repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		Convey("The nil repository is not allowed", func() {
+			_, err := NewHandler[User](nil, errhandler.New(), nil, nil)
+			So(err, ShouldEqual, ErrNilRepository)
+		})
+
+		Convey("The operations use the model of the handler", func() {
+			rw := httptest.NewRecorder()
+			handler.Create()(rw, httptest.NewRequest("POST", "/users", strings.NewReader(`{"Name":"john"}`)))
+			So(rw.Code, ShouldEqual, 201)
+			So(repo.users, ShouldResemble, []*User{{ID: 1, Name: "john"}})
+
+			rw = httptest.NewRecorder()
+			handler.Handler(handlers.OpList)(rw, httptest.NewRequest("GET", "/users", nil))
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Body.String(), ShouldContainSubstring, `"Name":"john"`)
+
+			So(handler.Repository(), ShouldEqual, repo)
+		})
+
+		Convey("The typed hooks get the model of the handler", func() {
+			var names []string
+			hook := Hook[User](func(req *http.Request, op handlers.Operation, user *User) error {
+				if user.Name == "" {
+					return resterrors.ErrInvalidInput.New()
+				}
+				names = append(names, op.String()+":"+user.Name)
+				return nil
+			})
+			handlerCopy := handler.New()
+			handlerCopy.WithBeforeHooks(handlers.OpCreate, hook).WithAfterHooks(handlers.OpList, hook)
+			So(handler.Hooks.Before, ShouldBeEmpty)
+
+			rw := httptest.NewRecorder()
+			handlerCopy.Create()(rw, httptest.NewRequest("POST", "/users", strings.NewReader(`{"Name":"jane"}`)))
+			So(rw.Code, ShouldEqual, 201)
+
+			rw = httptest.NewRecorder()
+			handlerCopy.Create()(rw, httptest.NewRequest("POST", "/users", strings.NewReader(`{}`)))
+			So(rw.Code, ShouldEqual, 400)
+
+			rw = httptest.NewRecorder()
+			handlerCopy.List()(rw, httptest.NewRequest("GET", "/users", nil))
+			So(rw.Code, ShouldEqual, 200)
+			So(names, ShouldResemble, []string{"create:jane", "list:jane"})
+		})
+
+		Convey("The routes are registered with the model of the handler", func() {
+			registry := handlers.NewRouteRegistry()
+			So(Register(registry, "/users/{user}", handlers.OpGet, handler), ShouldNotBeNil)
+			So(registry.Routes()[0].Model.Name(), ShouldEqual, "User")
+			So(handler.Route(handlers.OpDelete, "/users/{user}").Method, ShouldEqual, http.MethodDelete)
+		})
+	})
+}
@@ -0,0 +1,252 @@
+//go:build go1.18
+// +build go1.18
+
+package generic
+
+import (
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+)
+
+// Repository is the type-safe counterpart of the repository.Repository for the model 'T'.
+// The query objects and the results are the pointers to the model, so that the model type
+// is checked at compile time and the results need no type assertions.
+type Repository[T any] interface {
+	// Create creates a new entry for the 'obj'.
+	Create(obj *T) *dberrors.Error
+
+	// Get returns the first entry that matches the non-zero fields of the 'where'.
+	Get(where *T) (*T, *dberrors.Error)
+
+	// List returns all the entries that match the non-zero fields of the 'where'.
+	List(where *T) ([]*T, *dberrors.Error)
+
+	// ListWithParams extends the List by the query parameters.
+	ListWithParams(where *T, params *repository.ListParameters) ([]*T, *dberrors.Error)
+
+	// Count returns the number of entries that match the non-zero fields of the 'where'.
+	Count(where *T) (int, *dberrors.Error)
+
+	// Update replaces the whole entry with the 'obj'.
+	Update(obj *T) *dberrors.Error
+
+	// Patch updates the non-zero fields of the 'obj' in the entries selected by the 'where'.
+	Patch(obj, where *T) *dberrors.Error
+
+	// Delete deletes the entries selected by the 'where'.
+	Delete(where *T) *dberrors.Error
+}
+
+// Wrap adapts the untyped 'repo' i.e. *gormrepo.GORMRepository or *mockrepo.MockRepository
+// into the Repository of the model 'T'. If the 'repo' returns the results of other type
+// than *T or []*T the ErrInternalError is returned.
+func Wrap[T any](repo repository.Repository) Repository[T] {
+	if typed, ok := repo.(*untypedRepository[T]); ok {
+		return typed.repo
+	}
+	return &typedRepository[T]{repo: repo}
+}
+
+// Unwrap adapts the typed 'repo' into the repository.Repository, so that it could be used
+// by the handlers.GenericHandler. If the 'repo' was created by the Wrap function
+// the original repository is returned, so that its optional interfaces, like the
+// repository.FieldPatcher, are preserved.
+func Unwrap[T any](repo Repository[T]) repository.Repository {
+	if typed, ok := repo.(*typedRepository[T]); ok {
+		return typed.repo
+	}
+	return &untypedRepository[T]{repo: repo}
+}
+
+type typedRepository[T any] struct {
+	repo repository.Repository
+}
+
+func (r *typedRepository[T]) Create(obj *T) *dberrors.Error {
+	return r.repo.Create(obj)
+}
+
+func (r *typedRepository[T]) Get(where *T) (*T, *dberrors.Error) {
+	res, dbErr := r.repo.Get(where)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return resultOf[T](res)
+}
+
+func (r *typedRepository[T]) List(where *T) ([]*T, *dberrors.Error) {
+	res, dbErr := r.repo.List(where)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return listOf[T](res)
+}
+
+func (r *typedRepository[T]) ListWithParams(
+	where *T,
+	params *repository.ListParameters,
+) ([]*T, *dberrors.Error) {
+	res, dbErr := r.repo.ListWithParams(where, params)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return listOf[T](res)
+}
+
+func (r *typedRepository[T]) Count(where *T) (int, *dberrors.Error) {
+	return r.repo.Count(where)
+}
+
+func (r *typedRepository[T]) Update(obj *T) *dberrors.Error {
+	return r.repo.Update(obj)
+}
+
+func (r *typedRepository[T]) Patch(obj, where *T) *dberrors.Error {
+	return r.repo.Patch(obj, where)
+}
+
+func (r *typedRepository[T]) Delete(where *T) *dberrors.Error {
+	return r.repo.Delete(new(T), where)
+}
+
+// resultOf converts the result of the untyped repository into *T.
+func resultOf[T any](res interface{}) (*T, *dberrors.Error) {
+	switch typed := res.(type) {
+	case *T:
+		return typed, nil
+	case T:
+		return &typed, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, incorrectType[T](res)
+}
+
+// listOf converts the list result of the untyped repository into []*T.
+func listOf[T any](res interface{}) ([]*T, *dberrors.Error) {
+	switch typed := res.(type) {
+	case []*T:
+		return typed, nil
+	case *[]*T:
+		return *typed, nil
+	case []T:
+		list := make([]*T, len(typed))
+		for i := range typed {
+			list[i] = &typed[i]
+		}
+		return list, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, incorrectType[T](res)
+}
+
+func incorrectType[T any](res interface{}) *dberrors.Error {
+	return dberrors.ErrInternalError.NewWithMessage(
+		fmt.Sprintf("Incorrect repository result type: %T for model: %T", res, new(T)))
+}
+
+// untypedRepository adapts the Repository[T] into the repository.Repository.
+// The arguments of other type than *T or T result in the ErrInternalError.
+type untypedRepository[T any] struct {
+	repo Repository[T]
+}
+
+func (r *untypedRepository[T]) Create(req interface{}) *dberrors.Error {
+	obj, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return dbErr
+	}
+	return r.repo.Create(obj)
+}
+
+func (r *untypedRepository[T]) Get(req interface{}) (interface{}, *dberrors.Error) {
+	where, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	res, dbErr := r.repo.Get(where)
+	if dbErr != nil || res == nil {
+		return nil, dbErr
+	}
+	return res, nil
+}
+
+func (r *untypedRepository[T]) List(req interface{}) (interface{}, *dberrors.Error) {
+	where, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	res, dbErr := r.repo.List(where)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return res, nil
+}
+
+func (r *untypedRepository[T]) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	where, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	res, dbErr := r.repo.ListWithParams(where, params)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return res, nil
+}
+
+func (r *untypedRepository[T]) Count(req interface{}) (int, *dberrors.Error) {
+	where, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return 0, dbErr
+	}
+	return r.repo.Count(where)
+}
+
+func (r *untypedRepository[T]) Update(req interface{}) *dberrors.Error {
+	obj, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return dbErr
+	}
+	return r.repo.Update(obj)
+}
+
+func (r *untypedRepository[T]) Patch(req, where interface{}) *dberrors.Error {
+	obj, dbErr := argumentOf[T](req)
+	if dbErr != nil {
+		return dbErr
+	}
+	whereObj, dbErr := argumentOf[T](where)
+	if dbErr != nil {
+		return dbErr
+	}
+	return r.repo.Patch(obj, whereObj)
+}
+
+func (r *untypedRepository[T]) Delete(req, where interface{}) *dberrors.Error {
+	whereObj, dbErr := argumentOf[T](where)
+	if dbErr != nil {
+		return dbErr
+	}
+	return r.repo.Delete(whereObj)
+}
+
+// argumentOf converts the argument of the untyped repository into *T.
+// The nil argument is converted into nil.
+func argumentOf[T any](req interface{}) (*T, *dberrors.Error) {
+	switch typed := req.(type) {
+	case *T:
+		return typed, nil
+	case T:
+		return &typed, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, dberrors.ErrInternalError.NewWithMessage(
+		fmt.Sprintf("Incorrect repository argument type: %T for model: %T", req, new(T)))
+}
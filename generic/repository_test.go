@@ -0,0 +1,184 @@
+//go:build go1.18
+// +build go1.18
+
+package generic
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/gormrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+type User struct {
+	ID   uint
+	Name string
+}
+
+type fieldPatcherRepository struct {
+	*mockrepo.MockRepository
+}
+
+func (r *fieldPatcherRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	return nil
+}
+
+func TestWrap(t *testing.T) {
+	Convey("Subject: Wrap adapts the untyped repository into the Repository[T]", t, func() {
+		mock := &mockrepo.MockRepository{}
+		repo := Wrap[User](mock)
+
+		Convey("The results are typed", func() {
+			mock.On("Get", &User{ID: 1}).Return(&User{ID: 1, Name: "john"}, nil)
+			mock.On("List", &User{Name: "john"}).Return([]*User{{ID: 1, Name: "john"}}, nil)
+			mock.On("ListWithParams", &User{}, &repository.ListParameters{Limit: 1}).
+				Return([]User{{ID: 2}}, nil)
+
+			user, dbErr := repo.Get(&User{ID: 1})
+			So(dbErr, ShouldBeNil)
+			So(user.Name, ShouldEqual, "john")
+
+			users, dbErr := repo.List(&User{Name: "john"})
+			So(dbErr, ShouldBeNil)
+			So(users, ShouldHaveLength, 1)
+			So(users[0].ID, ShouldEqual, 1)
+
+			users, dbErr = repo.ListWithParams(&User{}, &repository.ListParameters{Limit: 1})
+			So(dbErr, ShouldBeNil)
+			So(users, ShouldResemble, []*User{{ID: 2}})
+		})
+
+		Convey("The repository errors are passed", func() {
+			mock.On("Get", &User{ID: 2}).Return(nil, dberrors.ErrNoResult.New())
+
+			user, dbErr := repo.Get(&User{ID: 2})
+			So(user, ShouldBeNil)
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+		})
+
+		Convey("The result of incorrect type results in the internal error", func() {
+			mock.On("Get", &User{ID: 3}).Return(&struct{ ID uint }{ID: 3}, nil)
+
+			user, dbErr := repo.Get(&User{ID: 3})
+			So(user, ShouldBeNil)
+			So(dbErr.Compare(dberrors.ErrInternalError), ShouldBeTrue)
+		})
+
+		Convey("The write operations pass the typed arguments", func() {
+			mock.On("Create", &User{Name: "john"}).Return(nil)
+			mock.On("Delete", &User{}, &User{ID: 1}).Return(nil)
+			mock.On("Count", &User{}).Return(3, nil)
+
+			So(repo.Create(&User{Name: "john"}), ShouldBeNil)
+			So(repo.Delete(&User{ID: 1}), ShouldBeNil)
+			count, dbErr := repo.Count(&User{})
+			So(dbErr, ShouldBeNil)
+			So(count, ShouldEqual, 3)
+			mock.AssertExpectations(t)
+		})
+
+		Convey("Unwrap returns the original repository", func() {
+			patcher := &fieldPatcherRepository{mock}
+			unwrapped := Unwrap[User](Wrap[User](patcher))
+			So(unwrapped, ShouldEqual, patcher)
+			_, ok := unwrapped.(repository.FieldPatcher)
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("Subject: Wrap adapts the gormrepo.GORMRepository", t, func() {
+		db, err := gorm.Open("sqlite3", ":memory:")
+		So(err, ShouldBeNil)
+		defer db.Close()
+		So(db.AutoMigrate(&User{}).Error, ShouldBeNil)
+
+		gormRepo, err := gormrepo.New(db)
+		So(err, ShouldBeNil)
+		repo := Wrap[User](gormRepo)
+
+		So(repo.Create(&User{Name: "john"}), ShouldBeNil)
+		So(repo.Create(&User{Name: "jane"}), ShouldBeNil)
+
+		user, dbErr := repo.Get(&User{Name: "jane"})
+		So(dbErr, ShouldBeNil)
+		So(user.ID, ShouldEqual, 2)
+
+		users, dbErr := repo.List(&User{})
+		So(dbErr, ShouldBeNil)
+		So(users, ShouldHaveLength, 2)
+
+		So(repo.Patch(&User{Name: "janet"}, &User{ID: 2}), ShouldBeNil)
+		users, dbErr = repo.ListWithParams(&User{}, &repository.ListParameters{Order: "name"})
+		So(dbErr, ShouldBeNil)
+		So(users[0].Name, ShouldEqual, "janet")
+	})
+}
+
+// usersRepository is the native Repository[User] implementation.
+type usersRepository struct {
+	users []*User
+}
+
+func (r *usersRepository) Create(obj *User) *dberrors.Error {
+	obj.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, obj)
+	return nil
+}
+
+func (r *usersRepository) Get(where *User) (*User, *dberrors.Error) {
+	for _, user := range r.users {
+		if user.ID == where.ID {
+			return user, nil
+		}
+	}
+	return nil, dberrors.ErrNoResult.New()
+}
+
+func (r *usersRepository) List(where *User) ([]*User, *dberrors.Error) {
+	return r.users, nil
+}
+
+func (r *usersRepository) ListWithParams(where *User, params *repository.ListParameters) ([]*User, *dberrors.Error) {
+	return r.users, nil
+}
+
+func (r *usersRepository) Count(where *User) (int, *dberrors.Error) {
+	return len(r.users), nil
+}
+
+func (r *usersRepository) Update(obj *User) *dberrors.Error {
+	return nil
+}
+
+func (r *usersRepository) Patch(obj, where *User) *dberrors.Error {
+	return nil
+}
+
+func (r *usersRepository) Delete(where *User) *dberrors.Error {
+	return nil
+}
+
+func TestUnwrap(t *testing.T) {
+	Convey("Subject: Unwrap adapts the Repository[T] into the repository.Repository", t, func() {
+		typed := &usersRepository{}
+		repo := Unwrap[User](typed)
+
+		So(repo.Create(&User{Name: "john"}), ShouldBeNil)
+		res, dbErr := repo.Get(&User{ID: 1})
+		So(dbErr, ShouldBeNil)
+		So(res, ShouldResemble, &User{ID: 1, Name: "john"})
+
+		Convey("The arguments of other types result in the internal error", func() {
+			dbErr := repo.Create(&struct{ Name string }{})
+			So(dbErr.Compare(dberrors.ErrInternalError), ShouldBeTrue)
+		})
+
+		Convey("Wrap returns the original typed repository", func() {
+			So(Wrap[User](repo), ShouldEqual, typed)
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+	"reflect"
+)
+
+var (
+	// ErrAccessDenied is returned by the Authorizer if the client is not allowed
+	// to execute the operation. The request is responsed with the 403 status.
+	ErrAccessDenied = errors.New("Access denied.")
+
+	// ErrAccessHidden is returned by the Authorizer if the existence of the resource
+	// should not be revealed to the client. The request is responsed with the 404 status,
+	// the same as the request for the resource that does not exist.
+	ErrAccessHidden = errors.New("Access hidden.")
+)
+
+// Authorizer decides if the request may execute the GenericHandler operation.
+// The 'model' is the type of the handler's model and the 'obj' is:
+//	- OpCreate - the object bound from the request body
+//	- OpGet - the object loaded from the repository
+//	- OpList - the query object bound from the request
+//	- OpUpdate, OpPatch, OpDelete - the stored object that is being modified
+// If the object to be updated has no primary key set, the object bound from
+// the request body is provided.
+//
+// The Authorize method should return nil if the operation is allowed, ErrAccessDenied
+// to respond with the 403 status or ErrAccessHidden to respond with the 404 status.
+// The *resterrors.Error is responsed with its own status. Any other error is logged
+// and responsed as an internal error.
+type Authorizer interface {
+	Authorize(req *http.Request, op Operation, model reflect.Type, obj interface{}) error
+}
+
+// AuthorizerFunc is the function that implements the Authorizer interface.
+type AuthorizerFunc func(req *http.Request, op Operation, model reflect.Type, obj interface{}) error
+
+// Authorize implements Authorizer interface.
+func (f AuthorizerFunc) Authorize(req *http.Request, op Operation, model reflect.Type, obj interface{}) error {
+	return f(req, op, model, obj)
+}
+
+// ListScoper is the Authorizer that limits the rows available to the client.
+// The Scope method is called for the List operation after the request is authorized.
+// It may set the fields of the query object 'where', that is passed to the repository's
+// List, ListWithParams and Count methods, i.e. to limit the collection to the records
+// owned by the client:
+//	where.(*Post).AuthorID = userFromContext(req.Context()).ID
+type ListScoper interface {
+	Scope(req *http.Request, model reflect.Type, where interface{}) error
+}
+
+// WithAuthorizer sets the authorizer for given handler. If the 'authorizer' implements
+// the ListScoper, it also scopes the List queries.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithAuthorizer(authorizer Authorizer) *GenericHandler {
+	c.Authorizer = authorizer
+	return c
+}
+
+// authorize checks if the request may execute the operation 'op' on the 'obj'.
+// If the handler has no Authorizer every operation is allowed.
+// Returns false if the response was already written.
+func (c *GenericHandler) authorize(
+	rw http.ResponseWriter,
+	req *http.Request,
+	op Operation,
+	obj interface{},
+) bool {
+	if c.Authorizer == nil {
+		return true
+	}
+	err := c.Authorizer.Authorize(req, op, refutils.GetType(obj), obj)
+	if err == nil {
+		return true
+	}
+	c.writeAuthError(rw, req, err)
+	return false
+}
+
+// authorizeStored loads the record selected by the 'whereObj' and checks if the request
+// may execute the operation 'op' on it. Returns false if the response was already written.
+func (c *GenericHandler) authorizeStored(
+	rw http.ResponseWriter,
+	req *http.Request,
+	op Operation,
+	whereObj interface{},
+) bool {
+	if c.Authorizer == nil {
+		return true
+	}
+	stored, dbErr := c.repo(req).Get(whereObj)
+	if dbErr != nil {
+		c.handleStoredError(rw, req, dbErr)
+		return false
+	}
+	return c.authorize(rw, req, op, stored)
+}
+
+// handleStoredError writes the response for the error of getting the stored record.
+// If the handler has the Authorizer, the record that does not exist is responsed with
+// the 404 status the same as the record hidden by the ErrAccessHidden, so that the clients
+// could not tell the hidden records exist.
+func (c *GenericHandler) handleStoredError(rw http.ResponseWriter, req *http.Request, dbErr *dberrors.Error) {
+	if c.Authorizer != nil && dbErr.Compare(dberrors.ErrNoResult) {
+		c.writeRestError(rw, req, resterrors.ErrResourceNotFound.New())
+		return
+	}
+	c.handleDBError(rw, req, dbErr)
+}
+
+// scopeList applies the ListScoper conditions to the query 'models'.
+// Returns false if the response was already written.
+func (c *GenericHandler) scopeList(
+	rw http.ResponseWriter,
+	req *http.Request,
+	models ...interface{},
+) bool {
+	scoper, ok := c.Authorizer.(ListScoper)
+	if !ok {
+		return true
+	}
+	for _, model := range models {
+		if err := scoper.Scope(req, refutils.GetType(model), model); err != nil {
+			c.writeAuthError(rw, req, err)
+			return false
+		}
+	}
+	return true
+}
+
+// updateWhere returns the query object that selects the stored record updated by the 'obj'.
+// Returns nil if the 'obj' has no primary key set.
+func updateWhere(obj interface{}) interface{} {
+	field, ok := forms.PrimaryKeyField(refutils.GetType(obj))
+	if !ok {
+		return nil
+	}
+	id := reflect.Indirect(reflect.ValueOf(obj)).FieldByName(field.Name)
+	if !id.IsValid() || id.IsZero() {
+		return nil
+	}
+
+	whereObj := refutils.ObjOfPtrType(obj)
+	reflect.ValueOf(whereObj).Elem().FieldByName(field.Name).Set(id)
+	return whereObj
+}
+
+// writeAuthError writes the response for the error returned by the Authorizer.
+func (c *GenericHandler) writeAuthError(rw http.ResponseWriter, req *http.Request, err error) {
+	switch err {
+	case ErrAccessDenied:
+		c.writeRestError(rw, req, resterrors.ErrInsufficientAccPerm.New())
+	case ErrAccessHidden:
+		c.writeRestError(rw, req, resterrors.ErrResourceNotFound.New())
+	default:
+		c.writeError(rw, req, err)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ownerAuthorizer allows the access to the Models named after the 'X-Owner' header.
+type ownerAuthorizer struct {
+	calls []Operation
+}
+
+func (a *ownerAuthorizer) Authorize(req *http.Request, op Operation, model reflect.Type, obj interface{}) error {
+	a.calls = append(a.calls, op)
+	if model != reflect.TypeOf(Model{}) {
+		return ErrAccessDenied
+	}
+	owner := req.Header.Get("X-Owner")
+	switch {
+	case owner == "":
+		return ErrAccessDenied
+	case op == OpList:
+		return nil
+	case obj.(*Model).Name == "secret":
+		return ErrAccessHidden
+	case obj.(*Model).Name != owner:
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+func (a *ownerAuthorizer) Scope(req *http.Request, model reflect.Type, where interface{}) error {
+	where.(*Model).Name = req.Header.Get("X-Owner")
+	return nil
+}
+
+func TestAuthorizer(t *testing.T) {
+	Convey("Subject: GenericHandler operations are checked by the Authorizer", t, func() {
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		authorizer := &ownerAuthorizer{}
+		So(handler.WithAuthorizer(authorizer), ShouldEqual, handler)
+		handler.WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+			WithParamGetterFunc(getParamFuncWithValues(map[string]string{"model": "1"}))
+
+		serve := func(h http.HandlerFunc, method, body, owner string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/models/1", strings.NewReader(body))
+			if owner != "" {
+				req.Header.Set("X-Owner", owner)
+			}
+			rw := httptest.NewRecorder()
+			h(rw, req)
+			return rw
+		}
+
+		Convey("The bound object is authorized before it is created", func() {
+			repo.On("Create", &Model{ID: 1, Name: "john"}).Return(nil)
+
+			rw := serve(handler.Create(Model{}), "POST", `{"Name":"john"}`, "john")
+			So(rw.Code, ShouldEqual, 201)
+			So(authorizer.calls, ShouldResemble, []Operation{OpCreate})
+
+			rw = serve(handler.Create(Model{}), "POST", `{"Name":"other"}`, "john")
+			So(rw.Code, ShouldEqual, 403)
+			body, err := readBody(rw)
+			So(err, ShouldBeNil)
+			So(body.Errors[0].Compare(resterrors.ErrInsufficientAccPerm), ShouldBeTrue)
+			repo.AssertNumberOfCalls(t, "Create", 1)
+		})
+
+		Convey("The loaded object is authorized", func() {
+			Convey("The allowed object is responsed", func() {
+				repo.On("Get", &Model{ID: 1}).Return(&Model{ID: 1, Name: "john"}, nil)
+
+				So(serve(handler.Get(Model{}), "GET", "", "john").Code, ShouldEqual, 200)
+				So(serve(handler.Get(Model{}), "GET", "", "other").Code, ShouldEqual, 403)
+			})
+
+			Convey("The hidden object is not found", func() {
+				repo.On("Get", &Model{ID: 1}).Return(&Model{ID: 1, Name: "secret"}, nil)
+
+				rw := serve(handler.Get(Model{}), "GET", "", "john")
+				So(rw.Code, ShouldEqual, 404)
+				body, err := readBody(rw)
+				So(err, ShouldBeNil)
+				So(body.Errors[0].Compare(resterrors.ErrResourceNotFound), ShouldBeTrue)
+			})
+
+			Convey("The missing object is responsed the same as the hidden one", func() {
+				repo.On("Get", &Model{ID: 1}).Return(nil, dberrors.ErrNoResult.New())
+
+				for _, h := range []http.HandlerFunc{handler.Get(Model{}), handler.Delete(Model{})} {
+					rw := serve(h, "GET", "", "john")
+					So(rw.Code, ShouldEqual, 404)
+					body, err := readBody(rw)
+					So(err, ShouldBeNil)
+					So(body.Errors[0].Compare(resterrors.ErrResourceNotFound), ShouldBeTrue)
+				}
+				rw := serve(handler.Patch(Model{}), "PATCH", `{"Name":"jane"}`, "john")
+				So(rw.Code, ShouldEqual, 404)
+				repo.AssertNotCalled(t, "Delete", &Model{}, &Model{ID: 1})
+			})
+		})
+
+		Convey("The List query is scoped by the ListScoper", func() {
+			lister, err := New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			lister.WithAuthorizer(authorizer).WithSelectCount(true)
+
+			repo.On("List", &Model{Name: "john"}).Return([]*Model{{ID: 1, Name: "john"}}, nil)
+			repo.On("Count", &Model{Name: "john"}).Return(1, nil)
+
+			So(serve(lister.List(Model{}), "GET", "", "john").Code, ShouldEqual, 200)
+			So(serve(lister.List(Model{}), "GET", "", "").Code, ShouldEqual, 403)
+			repo.AssertNumberOfCalls(t, "List", 1)
+		})
+
+		Convey("The stored object is authorized before it is modified", func() {
+			repo.On("Get", &Model{ID: 1}).Return(&Model{ID: 1, Name: "john"}, nil)
+			repo.On("Update", &Model{ID: 1, Name: "john"}).Return(nil)
+			repo.On("Patch", &Model{Name: "jane"}, &Model{ID: 1}).Return(nil)
+			repo.On("Delete", &Model{}, &Model{ID: 1}).Return(nil)
+
+			So(serve(handler.Update(Model{}), "PUT", `{"Name":"john"}`, "john").Code, ShouldEqual, 200)
+			So(serve(handler.Patch(Model{}), "PATCH", `{"Name":"jane"}`, "john").Code, ShouldEqual, 200)
+			So(serve(handler.Delete(Model{}), "DELETE", "", "john").Code, ShouldEqual, 200)
+			So(authorizer.calls, ShouldResemble, []Operation{OpUpdate, OpPatch, OpDelete})
+
+			So(serve(handler.Update(Model{}), "PUT", `{"Name":"other"}`, "other").Code, ShouldEqual, 403)
+			So(serve(handler.Delete(Model{}), "DELETE", "", "other").Code, ShouldEqual, 403)
+			repo.AssertNumberOfCalls(t, "Update", 1)
+			repo.AssertNumberOfCalls(t, "Delete", 1)
+		})
+
+		Convey("The resterrors are responsed with their status and other errors with 500", func() {
+			handler.WithAuthorizer(AuthorizerFunc(
+				func(req *http.Request, op Operation, model reflect.Type, obj interface{}) error {
+					if req.Header.Get("X-Owner") == "" {
+						return resterrors.ErrAuthInvalidCredentials.New()
+					}
+					return errors.New("authorizer failure")
+				}))
+
+			rw := serve(handler.Create(Model{}), "POST", `{}`, "")
+			So(strconv.Itoa(rw.Code), ShouldEqual, resterrors.ErrAuthInvalidCredentials.Status)
+			So(serve(handler.Create(Model{}), "POST", `{}`, "john").Code, ShouldEqual, 500)
+			repo.AssertNotCalled(t, "Create", &Model{ID: 1})
+		})
+
+		Convey("The route is marked as authorized", func() {
+			So(handler.Route(OpGet, "/models/{model}", Model{}).Authorized, ShouldBeTrue)
+		})
+	})
+}
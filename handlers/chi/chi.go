@@ -28,3 +28,19 @@ func New(
 	generic.WithParamGetterFunc(ChiParamGetterFunc)
 	return generic, nil
 }
+
+// Resource mounts all the CRUD operations of the 'model' on the go-chi 'router'
+// using the 'handler'. The routes are described in handlers.Resource function.
+// I.e. Resource(router, handler, "/users", &User{}) mounts the routes
+// '/users' and '/users/{user}'.
+func Resource(
+	router chi.Router,
+	handler *handlers.GenericHandler,
+	path string,
+	model interface{},
+	opts ...handlers.ResourceOption,
+) {
+	for _, route := range handlers.Resource(handler, path, model, handlers.BracesParam, opts...) {
+		router.Method(route.Method, route.Path, route.HandlerFunc())
+	}
+}
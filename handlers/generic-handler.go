@@ -11,9 +11,11 @@ import (
 	"github.com/kucjac/go-rest-sdk/repository"
 	"github.com/kucjac/go-rest-sdk/response"
 	"github.com/kucjac/go-rest-sdk/resterrors"
+	"github.com/kucjac/go-rest-sdk/tracing"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 )
 
 var (
@@ -42,6 +44,9 @@ type GenericHandler struct {
 
 	// ParamPolicy - policy used for binding Parameters with BindParams
 	ParamPolicy *forms.ParamPolicy
+
+	// JSONPolicy - policy used for binding the request body with BindJSONWithPolicy
+	JSONPolicy *forms.JSONPolicy
 	// GetParams - ParamGetterFunction used for getting parameters used by third-party routers
 	GetParams forms.ParamGetterFunc
 	// with params specify if given route should bind parameters
@@ -52,6 +57,19 @@ type GenericHandler struct {
 	//UseCount flag for List method - defines if the response should include count of given
 	//collection
 	IncludeListCount bool
+
+	// Parent - scope of the parent resource used by the nested handlers
+	Parent *ParentScope
+
+	// Authorizer - checks if the request may execute the handler's operations
+	Authorizer Authorizer
+
+	// Hooks - handler-level hook chains that run around the repository calls
+	Hooks Hooks
+
+	// UsePutSemantics - flag for Update method - defines if the record not stored yet
+	// is created and responsed with the 201 status
+	UsePutSemantics bool
 }
 
 type SetIDFunc func(req *http.Request, model interface{}) error
@@ -99,6 +117,13 @@ func (c *GenericHandler) WithParamPolicy(policy *forms.ParamPolicy) *GenericHand
 	return c
 }
 
+// WithJSONPolicy sets the JSON policy for given handler.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithJSONPolicy(policy *forms.JSONPolicy) *GenericHandler {
+	c.JSONPolicy = policy
+	return c
+}
+
 // WithListParameters sets the ListParameters for the Select method
 // Returns given handler so it can be used in a callback manner
 func (c *GenericHandler) WithListParameters(
@@ -149,35 +174,42 @@ func (c *GenericHandler) Create(model interface{}) http.HandlerFunc {
 		var status int
 		obj := refutils.ObjOfPtrType(model)
 
-		err := forms.BindJSON(req, obj)
-		if err != nil {
-			restErr := resterrors.ErrInvalidJSONDocument.New()
-			restErr.AddDetailInfo(err.Error())
-			status = 400
-			c.JSON(rw, req, status, c.getResponseBodyErr(status, restErr))
+		if !c.bindJSON(rw, req, obj, false) {
 			return
 		}
 
 		// Set parameter if WithParams flag is set to true
 		if c.UseURLParams {
 			// bind params
-			err := forms.BindParams(req, obj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, obj)
 			// if error occured - either the policy FailOnError is set or cannot set
 			// other parameters
 			if err != nil {
 				c.Log.Errorf("%v: %s", req.URL.Path, err)
 				restErr := resterrors.ErrInternalError.New()
 				status = 500
-				c.JSON(rw, req, status, c.getResponseBodyErr(status, restErr))
+				c.JSON(rw, req, status, c.getResponseBodyErr(req, status, restErr))
 				return
 			}
 		}
 
-		dbErr := c.Repo.Create(obj)
+		if !c.bindParent(rw, req, obj) {
+			return
+		}
+
+		if !c.authorize(rw, req, OpCreate, obj) || !c.beforeHooks(rw, req, OpCreate, obj) {
+			return
+		}
+
+		dbErr := c.repo(req).Create(obj)
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
+
+		if !c.afterHooks(rw, req, OpCreate, obj) {
+			return
+		}
 		status = http.StatusCreated
 		c.JSON(rw, req, status, c.getResponseBodyContent(status, obj))
 		return
@@ -190,18 +222,30 @@ func (c *GenericHandler) Get(model interface{}) http.HandlerFunc {
 		obj := refutils.ObjOfPtrType(model)
 
 		if c.UseURLParams {
-			err := forms.BindParams(req, obj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, obj)
 			if err != nil {
 				restErr := resterrors.ErrInternalError.New()
 				c.Log.Errorf("%v: %v", req.URL.Path, err)
-				c.JSON(rw, req, 500, c.getResponseBodyErr(500, restErr))
+				c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, restErr))
 				return
 			}
 		}
 
-		result, dbErr := c.Repo.Get(obj)
+		if !c.bindParent(rw, req, obj) {
+			return
+		}
+
+		if !c.beforeHooks(rw, req, OpGet, obj) {
+			return
+		}
+
+		result, dbErr := c.repo(req).Get(obj)
 		if dbErr != nil {
-			c.handleDBError(rw, req, dbErr)
+			c.handleStoredError(rw, req, dbErr)
+			return
+		}
+
+		if !c.authorize(rw, req, OpGet, result) || !c.afterHooks(rw, req, OpGet, result) {
 			return
 		}
 
@@ -215,11 +259,11 @@ func (c *GenericHandler) List(model interface{}) http.HandlerFunc {
 		obj := refutils.ObjOfPtrType(model)
 
 		// Bind Query
-		err := forms.BindQuery(req, obj, c.QueryPolicy)
+		err := c.bindQuery(req, obj)
 		if err != nil {
 			restErr := resterrors.ErrInvalidQueryParameter.New()
 			restErr.AddDetailInfo(err.Error())
-			c.JSON(rw, req, 400, c.getResponseBodyErr(400, restErr))
+			c.JSON(rw, req, 400, c.getResponseBodyErr(req, 400, restErr))
 			return
 		}
 
@@ -227,26 +271,44 @@ func (c *GenericHandler) List(model interface{}) http.HandlerFunc {
 		// Set List Parameters
 		if c.ListParams != nil {
 			params = new(repository.ListParameters)
-			err = forms.BindQuery(req, params, c.QueryPolicy)
+			err = c.bindQuery(req, params)
 			if err != nil {
 				restErr := resterrors.ErrInvalidQueryParameter.New()
 				restErr.AddDetailInfo(err.Error())
-				c.JSON(rw, req, 400, c.getResponseBodyErr(400, restErr))
+				c.JSON(rw, req, 400, c.getResponseBodyErr(req, 400, restErr))
 				return
 			}
 		}
 
 		// set URL parameters
 		if c.UseURLParams {
-			err := forms.BindParams(req, obj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, obj)
 			if err != nil {
 				restErr := resterrors.ErrInternalError.New()
 				c.Log.Errorf("%v: %v", req.URL.Path, err)
-				c.JSON(rw, req, 500, c.getResponseBodyErr(500, restErr))
+				c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, restErr))
 				return
 			}
 		}
 
+		// the nested or scoped collection is counted within the parent's and authorizer's scope
+		countModel := model
+		if c.Parent != nil || c.Authorizer != nil {
+			countModel = refutils.ObjOfPtrType(model)
+		}
+
+		if !c.bindParent(rw, req, obj, countModel) {
+			return
+		}
+
+		if !c.authorize(rw, req, OpList, obj) || !c.scopeList(rw, req, obj, countModel) {
+			return
+		}
+
+		if !c.beforeHooks(rw, req, OpList, obj) {
+			return
+		}
+
 		var result interface{}
 		var dbErr *dberrors.Error
 
@@ -254,22 +316,26 @@ func (c *GenericHandler) List(model interface{}) http.HandlerFunc {
 			if !params.ContainsParameters() {
 				params.Limit = c.ListParams.Limit
 			}
-			result, dbErr = c.Repo.ListWithParams(obj, params)
+			result, dbErr = c.repo(req).ListWithParams(obj, params)
 		} else {
-			result, dbErr = c.Repo.List(obj)
+			result, dbErr = c.repo(req).List(obj)
 		}
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
 
+		if !c.afterHooks(rw, req, OpList, result) {
+			return
+		}
+
 		body := c.getResponseBodyContent(200, result)
 
 		// CollectionCount
 		var collectionCount int
 		if c.IncludeListCount {
 			// Get Count for given collection
-			collectionCount, dbErr = c.Repo.Count(model)
+			collectionCount, dbErr = c.repo(req).Count(countModel)
 			if dbErr != nil {
 				c.handleDBError(rw, req, dbErr)
 				return
@@ -288,31 +354,62 @@ func (c *GenericHandler) Update(model interface{}) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		obj := refutils.ObjOfPtrType(model)
 
-		err := forms.BindJSON(req, obj)
-		if err != nil {
-			restErr := resterrors.ErrInvalidJSONDocument.New()
-			restErr.AddDetailInfo(err.Error())
-			c.JSON(rw, req, 400, c.getResponseBodyErr(400, restErr))
+		if !c.bindJSON(rw, req, obj, true) {
 			return
 		}
 
 		// set URL parameters
 		if c.UseURLParams {
-			err := forms.BindParams(req, obj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, obj)
 			if err != nil {
 				restErr := resterrors.ErrInternalError.New()
 				c.Log.Errorf("%v: %v", req.URL.Path, err)
-				c.JSON(rw, req, 500, c.getResponseBodyErr(500, restErr))
+				c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, restErr))
+				return
+			}
+		}
+
+		if c.UsePutSemantics {
+			c.put(rw, req, obj)
+			return
+		}
+
+		// the stored record is checked within the parent scope and authorized
+		// if the obj has its primary key set
+		whereObj := updateWhere(obj)
+		switch {
+		case whereObj != nil && c.Parent != nil:
+			if !c.bindParent(rw, req, obj, whereObj) {
+				return
+			}
+			stored, ok := c.getInParent(rw, req, whereObj)
+			if !ok || !c.authorize(rw, req, OpUpdate, stored) {
 				return
 			}
+		case whereObj != nil && c.Authorizer != nil:
+			if !c.bindParent(rw, req, obj, whereObj) || !c.authorizeStored(rw, req, OpUpdate, whereObj) {
+				return
+			}
+		default:
+			if !c.bindParent(rw, req, obj) || !c.authorize(rw, req, OpUpdate, obj) {
+				return
+			}
+		}
+
+		if !c.beforeHooks(rw, req, OpUpdate, obj) {
+			return
 		}
 
-		dbErr := c.Repo.Update(obj)
+		dbErr := c.repo(req).Update(obj)
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
 
+		if !c.afterHooks(rw, req, OpUpdate, obj) {
+			return
+		}
+
 		c.JSON(rw, req, 200, c.getResponseBodyContent(200, obj))
 		return
 	}
@@ -327,37 +424,56 @@ func (c *GenericHandler) Patch(model interface{}) http.HandlerFunc {
 
 		// set URL parameters
 		if c.UseURLParams {
-			err := forms.BindParams(req, whereObj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, whereObj)
 			if err != nil {
 				restErr := resterrors.ErrInternalError.New()
 				c.Log.Errorf("%v: %v", req.URL.Path, err)
-				c.JSON(rw, req, 500, c.getResponseBodyErr(500, restErr))
+				c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, restErr))
 				return
 			}
 		}
 
+		if !c.bindParent(rw, req, whereObj) {
+			return
+		}
+
+		if !c.authorizeStored(rw, req, OpPatch, whereObj) {
+			return
+		}
+
+		// the JSON Merge Patch and JSON Patch documents are applied to the current record
+		if forms.IsPatchRequest(req) {
+			c.patchFields(rw, req, model, whereObj)
+			return
+		}
+
 		obj := refutils.ObjOfPtrType(model)
 
-		if err := forms.BindJSON(req, obj); err != nil {
-			restErr := resterrors.ErrInvalidJSONDocument.New()
-			restErr.AddDetailInfo(err.Error())
-			status = 400
-			c.JSON(rw, req, status, c.getResponseBodyErr(status, restErr))
+		// the patched record could not be moved to the other parent
+		if !c.bindJSON(rw, req, obj, true) || !c.bindParent(rw, req, obj) {
+			return
+		}
+
+		if !c.beforeHooks(rw, req, OpPatch, obj) {
 			return
 		}
 
-		dbErr := c.Repo.Patch(obj, whereObj)
+		dbErr := c.repo(req).Patch(obj, whereObj)
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
 
-		result, dbErr := c.Repo.Get(whereObj)
+		result, dbErr := c.repo(req).Get(whereObj)
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
 
+		if !c.afterHooks(rw, req, OpPatch, result) {
+			return
+		}
+
 		status = 200
 		c.JSON(rw, req, status, c.getResponseBodyContent(status, result))
 	}
@@ -371,22 +487,38 @@ func (c *GenericHandler) Delete(model interface{}) http.HandlerFunc {
 
 		// set URL parameters
 		if c.UseURLParams {
-			err := forms.BindParams(req, whereObj, c.GetParams, c.ParamPolicy)
+			err := c.bindParams(req, whereObj)
 			if err != nil {
 				restErr := resterrors.ErrInternalError.New()
 				c.Log.Errorf("%v: %v", req.URL.Path, err)
-				c.JSON(rw, req, 500, c.getResponseBodyErr(500, restErr))
+				c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, restErr))
 				return
 			}
 		}
 
+		if !c.bindParent(rw, req, whereObj) {
+			return
+		}
+
+		if !c.authorizeStored(rw, req, OpDelete, whereObj) {
+			return
+		}
+
+		if !c.beforeHooks(rw, req, OpDelete, whereObj) {
+			return
+		}
+
 		obj := refutils.ObjOfPtrType(model)
-		dbErr := c.Repo.Delete(obj, whereObj)
+		dbErr := c.repo(req).Delete(obj, whereObj)
 		if dbErr != nil {
 			c.handleDBError(rw, req, dbErr)
 			return
 		}
 
+		if !c.afterHooks(rw, req, OpDelete, whereObj) {
+			return
+		}
+
 		status = 200
 		c.JSON(rw, req, status, c.getResponseBodyContent(204))
 	}
@@ -401,8 +533,12 @@ func (c *GenericHandler) JSON(
 	if status == 0 {
 		status = 200
 	}
+	_, span := tracing.StartSpan(req.Context(), "json.Marshal")
+	defer span.End()
+
 	marshaledBody, err := json.Marshal(body)
 	if err != nil {
+		span.RecordError(err)
 		body = (&response.DefaultBody{}).NewErrored().WithErrors(resterrors.ErrInternalError.New())
 		status = 500
 		marshaledBody, _ = json.Marshal(body)
@@ -413,6 +549,55 @@ func (c *GenericHandler) JSON(
 	return
 }
 
+// bindJSON binds the request body into the 'obj' using the handler's JSONPolicy.
+// The 'update' argument defines if the obj is being updated, so that its 'createonly'
+// fields are not writable. If the binding fails the error response is written and false is returned.
+func (c *GenericHandler) bindJSON(rw http.ResponseWriter, req *http.Request, obj interface{}, update bool) bool {
+	policy := c.JSONPolicy
+	if update {
+		if policy == nil {
+			policy = &forms.DefaultJSONPolicy
+		}
+		policy = policy.Copy()
+		policy.Update = true
+	}
+
+	_, span := tracing.StartSpan(req.Context(), "forms.BindJSON")
+	err := forms.BindJSONWithPolicy(req, obj, policy)
+	span.RecordError(err)
+	span.End()
+	if err == nil {
+		return true
+	}
+
+	restErr, ok := err.(*resterrors.Error)
+	if !ok {
+		restErr = resterrors.ErrInvalidJSONDocument.New()
+		restErr.AddDetailInfo(err.Error())
+	}
+	c.writeRestError(rw, req, restErr)
+	return false
+}
+
+// writeRestError writes the 'restErr' response with the status of the error.
+// If the error has no valid status the 400 status is used.
+func (c *GenericHandler) writeRestError(rw http.ResponseWriter, req *http.Request, restErr *resterrors.Error) {
+	status, err := strconv.Atoi(restErr.Status)
+	if err != nil {
+		status = 400
+	}
+	c.JSON(rw, req, status, c.getResponseBodyErr(req, status, restErr))
+}
+
+// repo returns the handler's repository. If the repository is a repository.ContextBinder
+// the request context is bound to the repository calls.
+func (c *GenericHandler) repo(req *http.Request) repository.Repository {
+	if binder, ok := c.Repo.(repository.ContextBinder); ok {
+		return binder.WithContext(req.Context())
+	}
+	return c.Repo
+}
+
 func (c *GenericHandler) handleDBError(
 	rw http.ResponseWriter,
 	req *http.Request,
@@ -435,13 +620,14 @@ func (c *GenericHandler) handleDBError(
 	} else {
 		status = 400
 	}
-	c.JSON(rw, req, status, c.getResponseBodyErr(status, restErr))
+	c.JSON(rw, req, status, c.getResponseBodyErr(req, status, restErr))
 	return
 }
 
 func (c *GenericHandler) getResponseBodyErr(
-	status int, errs ...*resterrors.Error,
+	req *http.Request, status int, errs ...*resterrors.Error,
 ) response.Responser {
+	recordErrors(req, errs)
 	body := c.ResponseBody.NewErrored().WithErrors(errs...)
 	if body, ok := body.(response.StatusResponser); ok {
 		body.WithStatus(status)
@@ -83,6 +83,14 @@ func TestCallbacks(t *testing.T) {
 				So(callbacked, ShouldPointTo, handler)
 			})
 
+			Convey(`WithJSONPolicy method sets the JSONPolicy and callback the handler`, func() {
+				So(handler.JSONPolicy, ShouldBeNil)
+
+				callbacked := handler.WithJSONPolicy(forms.StrictJSONPolicy.Copy())
+				So(handler.JSONPolicy, ShouldNotBeNil)
+				So(callbacked, ShouldPointTo, handler)
+			})
+
 			Convey(`WithListParameters sets the ListParameters and 
 				the flag IncludeListCount`, func() {
 
@@ -174,6 +182,33 @@ func TestCreateHandlerfunc(t *testing.T) {
 					So(body.Errors[0].Compare(resterrors.ErrInvalidJSONDocument), ShouldBeTrue)
 				})
 			})
+			Convey("Having a strict JSONPolicy", func() {
+				strict := handler.New().WithJSONPolicy(forms.StrictJSONPolicy.Copy())
+				server.Handle("/strict", strict.Create(Model{}))
+
+				Convey("Unknown fields result in 400 with UnsupportedJSONField", func() {
+					req := httptest.NewRequest("POST", "/strict", strings.NewReader(`{"Name":"x","Unknown":1}`))
+					rw := httptest.NewRecorder()
+					server.ServeHTTP(rw, req)
+
+					body, err := readBody(rw)
+					So(err, ShouldBeNil)
+					So(rw.Code, ShouldEqual, 400)
+					So(body.Errors[0].Compare(resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+				})
+
+				Convey("Too large body results in 413 with RequestBodyTooLarge", func() {
+					strict.JSONPolicy.MaxBodySize = 8
+					req := httptest.NewRequest("POST", "/strict", strings.NewReader(`{"Name":"some long name"}`))
+					rw := httptest.NewRecorder()
+					server.ServeHTTP(rw, req)
+
+					body, err := readBody(rw)
+					So(err, ShouldBeNil)
+					So(rw.Code, ShouldEqual, 413)
+					So(body.Errors[0].Compare(resterrors.ErrRequestBodyTooLarge), ShouldBeTrue)
+				})
+			})
 			Convey(`Having a UseURLParameters flag`, func() {
 				handler.WithURLParams(true)
 				paramPolicy := forms.DefaultParamPolicy.Copy()
@@ -761,7 +796,7 @@ func TestHandleDBError(t *testing.T) {
 			handler.ResponseBody = body
 			So(body, ShouldImplement, (*response.StatusResponser)(nil))
 			Convey("To getResponseBodyErr", func() {
-				handler.getResponseBodyErr(123)
+				handler.getResponseBodyErr(httptest.NewRequest("GET", "/", nil), 123)
 			})
 			Convey("To getResponseBodyContent", func() {
 				handler.getResponseBodyContent(123)
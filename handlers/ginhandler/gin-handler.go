@@ -136,12 +136,36 @@ func (g *GinHandler) Patch(model interface{}) gin.HandlerFunc {
 func (g *GinHandler) Delete(model interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		contextWithGinParams(c)
-		g.GenericHandler.Patch(model).ServeHTTP(c.Writer, c.Request)
+		g.GenericHandler.Delete(model).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// Resource mounts all the CRUD operations of the 'model' on the gin 'router'
+// using the 'handler'. The routes are described in handlers.Resource function.
+// I.e. Resource(router, handler, "/users", &User{}) mounts the routes
+// '/users' and '/users/:user'.
+func Resource(
+	router gin.IRoutes,
+	handler *GinHandler,
+	path string,
+	model interface{},
+	opts ...handlers.ResourceOption,
+) {
+	generic := &handler.GenericHandler
+	for _, route := range handlers.Resource(generic, path, model, handlers.ColonParam, opts...) {
+		router.Handle(route.Method, route.Path, handlerFunc(route.HandlerFunc()))
+	}
+}
+
+func handlerFunc(h http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		contextWithGinParams(c)
+		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
 func contextWithGinParams(c *gin.Context) {
 	ctx := c.Request.Context()
 	ctx = context.WithValue(ctx, GinParamKey{}, c.Params)
-	c.Request.WithContext(ctx)
+	c.Request = c.Request.WithContext(ctx)
 }
@@ -1,10 +1,14 @@
 package gorillamux
 
 import (
+	"github.com/gorilla/mux"
+	"github.com/kucjac/go-rest-sdk/dberrors"
 	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
 	"github.com/kucjac/go-rest-sdk/repository"
 	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
 	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -36,3 +40,40 @@ func TestNew(t *testing.T) {
 		})
 	})
 }
+
+func TestResource(t *testing.T) {
+	Convey("Subject: Resource mounts CRUD routes for the model", t, func() {
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		router := mux.NewRouter()
+		Resource(router, handler, "/models", &Model{}, handlers.WithoutOperations(handlers.OpDelete))
+
+		Convey("The item route binds the model parameter", func() {
+			repo.On("Get", &Model{ID: 3}).Return(&Model{ID: 3}, nil)
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("GET", "/models/3", nil))
+			So(rw.Code, ShouldEqual, 200)
+			repo.AssertCalled(t, "Get", &Model{ID: 3})
+		})
+
+		Convey("The collection route lists the models", func() {
+			repo.On("List", &Model{}).Return([]*Model{{ID: 1}}, nil)
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("GET", "/models", nil))
+			So(rw.Code, ShouldEqual, 200)
+		})
+
+		Convey("Disabled operations are not mounted", func() {
+			repo.On("Delete", &Model{}, &Model{ID: 3}).Return(dberrors.ErrNoResult.New())
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("DELETE", "/models/3", nil))
+			So(rw.Code, ShouldEqual, 405)
+			repo.AssertNotCalled(t, "Delete", &Model{}, &Model{ID: 3})
+		})
+	})
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+	"reflect"
+)
+
+// BeforeCreateHook is the model that is prepared before it is created in the repository.
+// I.e. the password could be hashed within the HookBeforeCreate method.
+type BeforeCreateHook interface {
+	HookBeforeCreate(ctx context.Context) error
+}
+
+// AfterCreateHook is the model that is notified after it was created in the repository.
+type AfterCreateHook interface {
+	HookAfterCreate(ctx context.Context) error
+}
+
+// AfterGetHook is the model that is processed after it was loaded from the repository.
+// The HookAfterGet method is called for the result of the Get operation and for each
+// element of the List operation result.
+type AfterGetHook interface {
+	HookAfterGet(ctx context.Context) error
+}
+
+// BeforeUpdateHook is the model that is prepared before it is updated in the repository.
+type BeforeUpdateHook interface {
+	HookBeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdateHook is the model that is notified after it was updated in the repository.
+type AfterUpdateHook interface {
+	HookAfterUpdate(ctx context.Context) error
+}
+
+// BeforePatchHook is the model that is prepared before it is patched in the repository.
+// The HookBeforePatch method is called on the model with the patched values.
+type BeforePatchHook interface {
+	HookBeforePatch(ctx context.Context) error
+}
+
+// AfterPatchHook is the model that is notified after it was patched in the repository.
+// The HookAfterPatch method is called on the patched record loaded from the repository.
+type AfterPatchHook interface {
+	HookAfterPatch(ctx context.Context) error
+}
+
+// BeforeDeleteHook is the model that is notified before it is deleted from the repository.
+// The HookBeforeDelete method is called on the query object that selects the deleted record.
+type BeforeDeleteHook interface {
+	HookBeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleteHook is the model that is notified after it was deleted from the repository.
+// The HookAfterDelete method is called on the query object that selects the deleted record.
+type AfterDeleteHook interface {
+	HookAfterDelete(ctx context.Context) error
+}
+
+// Hook is the handler-level function that runs before or after the repository call
+// of the operation 'op'. The 'obj' is the object passed to the repository or returned by it.
+// For the List operation the before hooks get the query object and the after hooks get
+// the list of results.
+//
+// If the hook returns an error the rest of the chain is not executed and the request
+// is responsed with the error. The *resterrors.Error is responsed with its own status,
+// any other error is logged and responsed as an internal error.
+// Note that the error returned by the after hook does not revert the repository changes.
+type Hook func(req *http.Request, op Operation, obj interface{}) error
+
+// Hooks contains the handler-level hook chains of the operations.
+type Hooks struct {
+	Before map[Operation][]Hook
+	After  map[Operation][]Hook
+}
+
+// WithBeforeHooks appends the 'hooks' to the chain that runs before the repository
+// call of the operation 'op'. The model's hooks run before the handler's chain.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithBeforeHooks(op Operation, hooks ...Hook) *GenericHandler {
+	c.Hooks.Before = appendHooks(c.Hooks.Before, op, hooks)
+	return c
+}
+
+// WithAfterHooks appends the 'hooks' to the chain that runs after the repository
+// call of the operation 'op'. The model's hooks run before the handler's chain.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithAfterHooks(op Operation, hooks ...Hook) *GenericHandler {
+	c.Hooks.After = appendHooks(c.Hooks.After, op, hooks)
+	return c
+}
+
+// appendHooks returns the copy of the 'chains' with the 'hooks' appended to the 'op' chain,
+// so that the hooks added to the copy of the handler do not change the original one.
+func appendHooks(chains map[Operation][]Hook, op Operation, hooks []Hook) map[Operation][]Hook {
+	result := make(map[Operation][]Hook, len(chains)+1)
+	for chainOp, chain := range chains {
+		result[chainOp] = chain
+	}
+	chain := make([]Hook, 0, len(chains[op])+len(hooks))
+	result[op] = append(append(chain, chains[op]...), hooks...)
+	return result
+}
+
+// beforeHooks runs the model's and handler's hooks of the operation 'op' before
+// the repository call. Returns false if the response was already written.
+func (c *GenericHandler) beforeHooks(
+	rw http.ResponseWriter,
+	req *http.Request,
+	op Operation,
+	obj interface{},
+) bool {
+	var err error
+	ctx := req.Context()
+	switch op {
+	case OpCreate:
+		if hook, ok := obj.(BeforeCreateHook); ok {
+			err = hook.HookBeforeCreate(ctx)
+		}
+	case OpUpdate:
+		if hook, ok := obj.(BeforeUpdateHook); ok {
+			err = hook.HookBeforeUpdate(ctx)
+		}
+	case OpPatch:
+		if hook, ok := obj.(BeforePatchHook); ok {
+			err = hook.HookBeforePatch(ctx)
+		}
+	case OpDelete:
+		if hook, ok := obj.(BeforeDeleteHook); ok {
+			err = hook.HookBeforeDelete(ctx)
+		}
+	}
+	if err == nil {
+		err = runHooks(c.Hooks.Before[op], req, op, obj)
+	}
+	if err != nil {
+		c.writeError(rw, req, err)
+		return false
+	}
+	return true
+}
+
+// afterHooks runs the model's and handler's hooks of the operation 'op' after
+// the repository call. Returns false if the response was already written.
+func (c *GenericHandler) afterHooks(
+	rw http.ResponseWriter,
+	req *http.Request,
+	op Operation,
+	obj interface{},
+) bool {
+	var err error
+	ctx := req.Context()
+	switch op {
+	case OpCreate:
+		if hook, ok := obj.(AfterCreateHook); ok {
+			err = hook.HookAfterCreate(ctx)
+		}
+	case OpGet:
+		if hook, ok := obj.(AfterGetHook); ok {
+			err = hook.HookAfterGet(ctx)
+		}
+	case OpList:
+		err = afterGetEach(ctx, obj)
+	case OpUpdate:
+		if hook, ok := obj.(AfterUpdateHook); ok {
+			err = hook.HookAfterUpdate(ctx)
+		}
+	case OpPatch:
+		if hook, ok := obj.(AfterPatchHook); ok {
+			err = hook.HookAfterPatch(ctx)
+		}
+	case OpDelete:
+		if hook, ok := obj.(AfterDeleteHook); ok {
+			err = hook.HookAfterDelete(ctx)
+		}
+	}
+	if err == nil {
+		err = runHooks(c.Hooks.After[op], req, op, obj)
+	}
+	if err != nil {
+		c.writeError(rw, req, err)
+		return false
+	}
+	return true
+}
+
+func runHooks(hooks []Hook, req *http.Request, op Operation, obj interface{}) error {
+	for _, hook := range hooks {
+		if err := hook(req, op, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterGetEach calls the HookAfterGet method of each element of the 'list'.
+func afterGetEach(ctx context.Context, list interface{}) error {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			continue
+		}
+		if hook, ok := elem.Interface().(AfterGetHook); ok {
+			if err := hook.HookAfterGet(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeError writes the 'err' response. The *resterrors.Error is responsed with its own
+// status, any other error is logged and responsed as an internal error.
+func (c *GenericHandler) writeError(rw http.ResponseWriter, req *http.Request, err error) {
+	restErr, ok := err.(*resterrors.Error)
+	if !ok {
+		c.Log.Errorf("%v: %v", req.URL.Path, err)
+		restErr = resterrors.ErrInternalError.New()
+	}
+	c.writeRestError(rw, req, restErr)
+}
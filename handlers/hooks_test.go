@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository/gormrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type hookedModel struct {
+	ID       int
+	Password string
+	Loaded   bool
+}
+
+func (m *hookedModel) HookBeforeCreate(ctx context.Context) error {
+	if m.Password == "" {
+		restErr := resterrors.ErrInvalidInput.New()
+		restErr.AddDetailInfo("Password is required")
+		return restErr
+	}
+	m.Password = "hashed:" + m.Password
+	return nil
+}
+
+func (m *hookedModel) HookAfterGet(ctx context.Context) error {
+	m.Loaded = true
+	return nil
+}
+
+func (m *hookedModel) HookBeforeDelete(ctx context.Context) error {
+	if m.ID == 1 {
+		return errors.New("the admin could not be deleted")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	Convey("Subject: GenericHandler runs the hooks around the repository calls", t, func() {
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		Convey("The model hooks", func() {
+			Convey("HookBeforeCreate prepares the model", func() {
+				repo.On("Create", &hookedModel{Password: "hashed:secret"}).Return(nil)
+
+				rw := httptest.NewRecorder()
+				handler.Create(hookedModel{})(rw, httptest.NewRequest("POST", "/models",
+					strings.NewReader(`{"Password":"secret"}`)))
+				So(rw.Code, ShouldEqual, 201)
+				So(rw.Body.String(), ShouldContainSubstring, "hashed:secret")
+			})
+
+			Convey("The HookBeforeCreate error short-circuits the operation", func() {
+				rw := httptest.NewRecorder()
+				handler.Create(hookedModel{})(rw, httptest.NewRequest("POST", "/models",
+					strings.NewReader(`{}`)))
+
+				body, err := readBody(rw)
+				So(err, ShouldBeNil)
+				So(rw.Code, ShouldEqual, 400)
+				So(body.Errors[0].Compare(resterrors.ErrInvalidInput), ShouldBeTrue)
+				repo.AssertNotCalled(t, "Create", &hookedModel{})
+			})
+
+			Convey("HookAfterGet is called for the Get and each List result", func() {
+				repo.On("Get", &hookedModel{}).Return(&hookedModel{ID: 2}, nil)
+				repo.On("List", &hookedModel{}).Return([]*hookedModel{{ID: 2}, nil, {ID: 3}}, nil)
+
+				rw := httptest.NewRecorder()
+				handler.Get(hookedModel{})(rw, httptest.NewRequest("GET", "/models/2", nil))
+				So(rw.Code, ShouldEqual, 200)
+				So(rw.Body.String(), ShouldContainSubstring, `"Loaded":true`)
+
+				rw = httptest.NewRecorder()
+				handler.List(hookedModel{})(rw, httptest.NewRequest("GET", "/models", nil))
+				So(rw.Code, ShouldEqual, 200)
+				So(strings.Count(rw.Body.String(), `"Loaded":true`), ShouldEqual, 2)
+			})
+
+			Convey("The error other than resterrors results in 500", func() {
+				handler.WithURLParams(true).
+					WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+					WithParamGetterFunc(getParamFuncWithValues(map[string]string{"hookedmodel": "1"}))
+
+				rw := httptest.NewRecorder()
+				handler.Delete(hookedModel{})(rw, httptest.NewRequest("DELETE", "/models/1", nil))
+				So(rw.Code, ShouldEqual, 500)
+				repo.AssertNotCalled(t, "Delete", &hookedModel{}, &hookedModel{ID: 1})
+			})
+		})
+
+		Convey("The model hooks do not collide with the gorm callbacks", func() {
+			db, err := gorm.Open("sqlite3", ":memory:")
+			So(err, ShouldBeNil)
+			defer db.Close()
+			So(db.AutoMigrate(&hookedModel{}).Error, ShouldBeNil)
+
+			gormRepo, err := gormrepo.New(db)
+			So(err, ShouldBeNil)
+			handler, err := New(gormRepo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+
+			rw := httptest.NewRecorder()
+			handler.Create(hookedModel{})(rw, httptest.NewRequest("POST", "/models",
+				strings.NewReader(`{"Password":"secret"}`)))
+			So(rw.Code, ShouldEqual, 201)
+
+			stored, dbErr := gormRepo.Get(&hookedModel{ID: 1})
+			So(dbErr, ShouldBeNil)
+			So(stored.(*hookedModel).Password, ShouldEqual, "hashed:secret")
+		})
+
+		Convey("The handler hook chains", func() {
+			var calls []string
+			hook := func(name string, err error) Hook {
+				return func(req *http.Request, op Operation, obj interface{}) error {
+					calls = append(calls, op.String()+":"+name)
+					return err
+				}
+			}
+
+			handler.WithBeforeHooks(OpUpdate, hook("first", nil), hook("second", nil)).
+				WithAfterHooks(OpUpdate, hook("after", nil))
+			repo.On("Update", &Model{ID: 5}).Return(nil)
+
+			Convey("Run in order around the repository call", func() {
+				rw := httptest.NewRecorder()
+				handler.Update(Model{})(rw, httptest.NewRequest("PUT", "/models/5",
+					strings.NewReader(`{"ID":5}`)))
+				So(rw.Code, ShouldEqual, 200)
+				So(calls, ShouldResemble, []string{"update:first", "update:second", "update:after"})
+			})
+
+			Convey("The hook error stops the chain", func() {
+				handler.WithBeforeHooks(OpUpdate, hook("denied", resterrors.ErrInsufficientAccPerm.New()),
+					hook("never", nil))
+
+				rw := httptest.NewRecorder()
+				handler.Update(Model{})(rw, httptest.NewRequest("PUT", "/models/5",
+					strings.NewReader(`{"ID":5}`)))
+				So(rw.Code, ShouldEqual, 403)
+				So(calls, ShouldResemble, []string{"update:first", "update:second", "update:denied"})
+				repo.AssertNotCalled(t, "Update", &Model{ID: 5})
+			})
+
+			Convey("The hooks added to the handler's copy do not change the original", func() {
+				handlerCopy := handler.New().WithBeforeHooks(OpUpdate, hook("copy", nil))
+				So(handlerCopy.Hooks.Before[OpUpdate], ShouldHaveLength, 3)
+				So(handler.Hooks.Before[OpUpdate], ShouldHaveLength, 2)
+			})
+		})
+	})
+}
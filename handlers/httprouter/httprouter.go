@@ -61,48 +61,65 @@ func (h *HttpRouterHandler) WithParamPolicy(policy *forms.ParamPolicy) *HttpRout
 
 func (h *HttpRouterHandler) Create(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.Create(model).ServeHTTP(rw, req)
+		h.GenericHandler.Create(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
 func (h *HttpRouterHandler) Get(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.Get(model).ServeHTTP(rw, req)
+		h.GenericHandler.Get(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
 func (h *HttpRouterHandler) List(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.List(model).ServeHTTP(rw, req)
+		h.GenericHandler.List(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
 func (h *HttpRouterHandler) Update(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.Update(model).ServeHTTP(rw, req)
+		h.GenericHandler.Update(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
 func (h *HttpRouterHandler) Patch(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.Patch(model).ServeHTTP(rw, req)
+		h.GenericHandler.Patch(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
 func (h *HttpRouterHandler) Delete(model interface{}) httprouter.Handle {
 	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
-		contextWithParams(req, p)
-		h.GenericHandler.Delete(model).ServeHTTP(rw, req)
+		h.GenericHandler.Delete(model).ServeHTTP(rw, contextWithParams(req, p))
 	}
 }
 
-func contextWithParams(req *http.Request, p httprouter.Params) {
+// Resource mounts all the CRUD operations of the 'model' on the httprouter 'router'
+// using the 'handler'. The routes are described in handlers.Resource function.
+// I.e. Resource(router, handler, "/users", &User{}) mounts the routes
+// '/users' and '/users/:user'.
+func Resource(
+	router *httprouter.Router,
+	handler *HttpRouterHandler,
+	path string,
+	model interface{},
+	opts ...handlers.ResourceOption,
+) {
+	generic := &handler.GenericHandler
+	for _, route := range handlers.Resource(generic, path, model, handlers.ColonParam, opts...) {
+		router.Handle(route.Method, route.Path, handle(route.HandlerFunc()))
+	}
+}
+
+func handle(handlerFunc http.HandlerFunc) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, p httprouter.Params) {
+		handlerFunc.ServeHTTP(rw, contextWithParams(req, p))
+	}
+}
+
+func contextWithParams(req *http.Request, p httprouter.Params) *http.Request {
 	ctx := req.Context()
 	ctx = context.WithValue(ctx, httprouter.ParamsKey, p)
-	req.WithContext(ctx)
+	return req.WithContext(ctx)
 }
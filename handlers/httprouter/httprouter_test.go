@@ -0,0 +1,71 @@
+package httprouter
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"testing"
+)
+
+type Model struct {
+	ID int
+}
+
+func TestNew(t *testing.T) {
+	Convey("Subject: New httprouter based GenericHandler", t, func() {
+		Convey("Having some repository and errorHandler a new HttpRouterHandler should be created", func() {
+			handler, err := New(&mockrepo.MockRepository{}, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			So(handler, ShouldNotBeNil)
+		})
+
+		Convey("If no repo would be provided, then an error would be returned instead", func() {
+			var repo repository.Repository
+			handler, err := New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeError)
+			So(handler, ShouldBeNil)
+		})
+	})
+}
+
+func TestResource(t *testing.T) {
+	Convey("Subject: Resource mounts CRUD routes for the model", t, func() {
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		router := httprouter.New()
+		Resource(router, handler, "/models", &Model{}, handlers.WithoutOperations(handlers.OpDelete))
+
+		Convey("The item route binds the model parameter", func() {
+			repo.On("Get", &Model{ID: 3}).Return(&Model{ID: 3}, nil)
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("GET", "/models/3", nil))
+			So(rw.Code, ShouldEqual, 200)
+			repo.AssertCalled(t, "Get", &Model{ID: 3})
+		})
+
+		Convey("The collection route lists the models", func() {
+			repo.On("List", &Model{}).Return([]*Model{{ID: 1}}, nil)
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("GET", "/models", nil))
+			So(rw.Code, ShouldEqual, 200)
+		})
+
+		Convey("Disabled operations are not mounted", func() {
+			repo.On("Delete", &Model{}, &Model{ID: 3}).Return(dberrors.ErrNoResult.New())
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest("DELETE", "/models/3", nil))
+			So(rw.Code, ShouldEqual, 405)
+			repo.AssertNotCalled(t, "Delete", &Model{}, &Model{ID: 3})
+		})
+	})
+}
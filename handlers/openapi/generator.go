@@ -0,0 +1,586 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/response"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jsonMediaType = "application/json"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// matches '{name}' and '{name:regexp}' path parameters used by chi and gorilla/mux
+	bracesParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+)
+
+// Generator creates the OpenAPI 3 documents on the base of the routes
+// registered in the handlers.RouteRegistry.
+// The model schemas are reflected from the struct fields and their 'json' tags.
+// The query parameters are reflected using the routes BindPolicy and ListParameters,
+// and the path parameters using the ParamPolicy.
+type Generator struct {
+	Registry *handlers.RouteRegistry
+	Info     Info
+	Servers  []Server
+}
+
+// New creates new Generator for given 'registry' and document 'info'.
+func New(registry *handlers.RouteRegistry, info Info) *Generator {
+	return &Generator{Registry: registry, Info: info}
+}
+
+// Generate creates the OpenAPI document for the currently registered routes.
+func (g *Generator) Generate() *Document {
+	b := &builder{
+		doc: &Document{
+			OpenAPI:    Version,
+			Info:       g.Info,
+			Servers:    g.Servers,
+			Paths:      make(map[string]*PathItem),
+			Components: &Components{Schemas: make(map[string]*Schema)},
+		},
+		names:        make(map[reflect.Type]string),
+		operationIDs: make(map[string]int),
+	}
+	for _, route := range g.Registry.Routes() {
+		b.addRoute(route)
+	}
+	return b.doc
+}
+
+// Handler returns the http.HandlerFunc that serves the generated document as JSON.
+// The document is generated on each request, so that the routes registered after
+// mounting the handler are also included. The handler may be mounted at any path.
+func (g *Generator) Handler() http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		marshaled, err := json.Marshal(g.Generate())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", jsonMediaType)
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(marshaled)
+	}
+}
+
+type builder struct {
+	doc          *Document
+	names        map[reflect.Type]string
+	operationIDs map[string]int
+}
+
+func (b *builder) addRoute(route handlers.Route) {
+	path, pathParams := convertPath(route.Path)
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	op := &Operation{
+		OperationID: b.operationID(route),
+		Summary:     fmt.Sprintf("%s %s", strings.Title(route.Operation.String()), route.Model.Name()),
+		Tags:        []string{route.Model.Name()},
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, param := range pathParams {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   b.pathParamSchema(route, param),
+		})
+	}
+
+	switch route.Operation {
+	case handlers.OpCreate, handlers.OpUpdate, handlers.OpPatch:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(b.schemaOf(route.Model)),
+		}
+		if route.Operation == handlers.OpPatch && route.PatchDocuments {
+			op.RequestBody.Content[forms.MergePatchMediaType] = &MediaType{Schema: b.schemaOf(route.Model)}
+			op.RequestBody.Content[forms.JSONPatchMediaType] = &MediaType{Schema: jsonPatchSchema()}
+		}
+	case handlers.OpList:
+		if route.QueryPolicy != nil {
+			op.Parameters = append(op.Parameters,
+				b.queryParams(route.Model, route.QueryPolicy, route.QueryPolicy.SearchDepthLevel)...)
+			if route.ListParams != nil {
+				op.Parameters = append(op.Parameters,
+					b.queryParams(reflect.TypeOf(repository.ListParameters{}), route.QueryPolicy, 0)...)
+			}
+		}
+	}
+
+	b.addResponses(op, route)
+
+	switch route.Method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPatch:
+		item.Patch = op
+	case http.MethodDelete:
+		item.Delete = op
+	}
+}
+
+func (b *builder) operationID(route handlers.Route) string {
+	id := route.Operation.String() + route.Model.Name()
+	b.operationIDs[id]++
+	if n := b.operationIDs[id]; n > 1 {
+		id += strconv.Itoa(n)
+	}
+	return id
+}
+
+// addResponses adds the success and error responses for the route.
+// The error responses are described by the resterrors prototypes that the
+// GenericHandler may return for given operation.
+func (b *builder) addResponses(op *Operation, route handlers.Route) {
+	content := map[string]*Schema{}
+	status := http.StatusOK
+
+	model := reflect.New(route.Model).Interface()
+	switch route.Operation {
+	case handlers.OpCreate:
+		status = http.StatusCreated
+		content[refutils.ModelName(model)] = b.schemaOf(route.Model)
+	case handlers.OpList:
+		models := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(route.Model)), 0, 0).Interface()
+		content[refutils.ModelName(models)] = &Schema{Type: "array", Items: b.schemaOf(route.Model)}
+		if route.IncludeListCount {
+			content["count"] = &Schema{Type: "integer"}
+		}
+	case handlers.OpDelete:
+	default:
+		content[refutils.ModelName(model)] = b.schemaOf(route.Model)
+	}
+	op.Responses[strconv.Itoa(status)] = &Response{
+		Description: http.StatusText(status),
+		Content:     jsonContent(b.bodySchema(route.ResponseBody, content)),
+	}
+	if route.Operation == handlers.OpUpdate && route.UsePutSemantics {
+		op.Responses[strconv.Itoa(http.StatusCreated)] = &Response{
+			Description: http.StatusText(http.StatusCreated),
+			Content:     jsonContent(b.bodySchema(route.ResponseBody, content)),
+		}
+	}
+
+	badRequest := []resterrors.Error{}
+	switch route.Operation {
+	case handlers.OpCreate, handlers.OpUpdate, handlers.OpPatch:
+		badRequest = append(badRequest, resterrors.ErrInvalidJSONDocument)
+	case handlers.OpList:
+		badRequest = append(badRequest, resterrors.ErrInvalidQueryParameter)
+	}
+	badRequest = append(badRequest, repositoryErrors()...)
+
+	errBody := jsonContent(b.bodySchema(route.ResponseBody, nil))
+	op.Responses[strconv.Itoa(http.StatusBadRequest)] = &Response{
+		Description: errorsDescription(http.StatusBadRequest, badRequest),
+		Content:     errBody,
+	}
+	if route.Authorized {
+		op.Responses[strconv.Itoa(http.StatusForbidden)] = &Response{
+			Description: errorsDescription(http.StatusForbidden,
+				[]resterrors.Error{resterrors.ErrInsufficientAccPerm}),
+			Content: errBody,
+		}
+	}
+	if route.Parent != nil || route.Authorized {
+		op.Responses[strconv.Itoa(http.StatusNotFound)] = &Response{
+			Description: errorsDescription(http.StatusNotFound,
+				[]resterrors.Error{resterrors.ErrResourceNotFound}),
+			Content: errBody,
+		}
+	}
+	op.Responses[strconv.Itoa(http.StatusInternalServerError)] = &Response{
+		Description: errorsDescription(http.StatusInternalServerError,
+			[]resterrors.Error{resterrors.ErrInternalError}),
+		Content: errBody,
+	}
+}
+
+// repositoryErrors returns the non internal resterrors prototypes, that the
+// errhandler may map the repository errors into, sorted by their codes.
+func repositoryErrors() []resterrors.Error {
+	var errs []resterrors.Error
+	codes := map[string]bool{}
+	for _, restErr := range errhandler.DefaultErrorMap {
+		if restErr.Code == resterrors.ErrInternalError.Code || codes[restErr.Code] {
+			continue
+		}
+		codes[restErr.Code] = true
+		errs = append(errs, restErr)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Code < errs[j].Code })
+	return errs
+}
+
+func errorsDescription(status int, errs []resterrors.Error) string {
+	descriptions := make([]string, len(errs))
+	for i, restErr := range errs {
+		descriptions[i] = fmt.Sprintf("%s - %s", restErr.Code, restErr.Title)
+	}
+	return fmt.Sprintf("%s. Possible errors: %s", http.StatusText(status), strings.Join(descriptions, ", "))
+}
+
+// bodySchema reflects the response body. The body 'Content' field is described
+// as an object with provided 'content' properties. If 'content' is nil the
+// field is not described.
+func (b *builder) bodySchema(body response.Responser, content map[string]*Schema) *Schema {
+	if body == nil {
+		body = &response.DefaultBody{}
+	}
+	t := reflect.TypeOf(body)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return &Schema{}
+	}
+
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if field.Name == "Content" && field.Type.Kind() == reflect.Map {
+			if content != nil {
+				schema.Properties[name] = &Schema{Type: "object", Properties: content}
+			}
+			continue
+		}
+		schema.Properties[name] = b.schemaOf(field.Type)
+	}
+	return schema
+}
+
+// queryParams reflects the query parameters for the type 't' using the same rules
+// as the forms.BindQuery function.
+func (b *builder) queryParams(t reflect.Type, policy *forms.BindPolicy, depth int) []*Parameter {
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		rawTag := field.Tag.Get(policy.Tag)
+		if rawTag == "-" || (policy.TaggedOnly && rawTag == "") {
+			continue
+		}
+		tag, required := forms.ParseTag(rawTag)
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		switch ft.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Chan, reflect.Func, reflect.Array:
+			continue
+		case reflect.Struct:
+			if ft != timeType {
+				if depth > 0 {
+					params = append(params, b.queryParams(ft, policy, depth-1)...)
+				}
+				continue
+			}
+		case reflect.Slice:
+			if ft.Elem().Kind() == reflect.Struct && ft.Elem() != timeType {
+				continue
+			}
+		}
+
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+
+		param := &Parameter{Name: tag, In: "query", Required: required, Schema: b.schemaOf(ft)}
+		if ft.Kind() == reflect.Slice {
+			explode := true
+			param.Explode = &explode
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// pathParamSchema finds the model field bound to the path parameter using
+// the same rules as the forms.BindParams function. If no field is found the
+// parameter is described as a string.
+func (b *builder) pathParamSchema(route handlers.Route, param string) *Schema {
+	policy := route.ParamPolicy
+	if policy == nil {
+		policy = forms.DefaultParamPolicy.Copy()
+	}
+	if t, ok := paramFieldType(route.Model, param, policy, policy.SearchDepthLevel, ""); ok {
+		return b.schemaOf(t)
+	}
+	// the parent scope parameter is the ID of the parent model
+	if route.Parent != nil && route.Parent.Param == param {
+		parent := refutils.GetType(route.Parent.Model)
+		if t, ok := paramFieldType(parent, strings.ToLower(parent.Name()), policy, 0, ""); ok {
+			return b.schemaOf(t)
+		}
+	}
+	return &Schema{Type: "string"}
+}
+
+func paramFieldType(
+	t reflect.Type,
+	param string,
+	policy *forms.ParamPolicy,
+	depth int,
+	modelParam string,
+) (reflect.Type, bool) {
+	if modelParam == "" {
+		modelParam = strings.ToLower(t.Name())
+	}
+	pk, hasPK := forms.PrimaryKeyField(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(policy.Tag)
+		if tag == "-" || (policy.TaggedOnly && tag == "") {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			if depth > 0 {
+				if found, ok := paramFieldType(ft, param, policy, depth-1, tag); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+
+		lowerName := strings.ToLower(field.Name)
+		isPK := hasPK && field.Name == pk.Name
+		if param == modelParam && (isPK || tag == "id") {
+			return ft, true
+		}
+		if tag == param || (tag == "" && lowerName == param) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// convertPath converts the router path into the OpenAPI path template
+// and returns the names of its parameters.
+// Supports '{name}', '{name:regexp}', ':name' and '*name' parameters.
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		switch segment[0] {
+		case ':', '*':
+			if len(segment) > 1 {
+				params = append(params, segment[1:])
+				segments[i] = "{" + segment[1:] + "}"
+			}
+			continue
+		}
+		segments[i] = bracesParam.ReplaceAllStringFunc(segment, func(match string) string {
+			name := bracesParam.FindStringSubmatch(match)[1]
+			params = append(params, name)
+			return "{" + name + "}"
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// schemaOf reflects the schema for the type 't'. Named struct types are stored
+// in the document components and referenced.
+func (b *builder) schemaOf(t reflect.Type) *Schema {
+	var nullable bool
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string", Nullable: nullable}
+	}
+
+	var schema *Schema
+	switch t.Kind() {
+	case reflect.Bool:
+		schema = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		schema = &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		schema = &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		schema = &Schema{Type: "integer", Format: "int64", Minimum: new(float64)}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		schema = &Schema{Type: "integer", Format: "int32", Minimum: new(float64)}
+	case reflect.Float32:
+		schema = &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		schema = &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		schema = &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			schema = &Schema{Type: "string", Format: "byte"}
+		} else {
+			schema = &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+		}
+	case reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			schema = b.structSchema(t)
+		} else {
+			schema = &Schema{Ref: "#/components/schemas/" + b.componentName(t)}
+		}
+	default:
+		schema = &Schema{}
+	}
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+func (b *builder) componentName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	for i := 2; ; i++ {
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			break
+		}
+		name = t.Name() + strconv.Itoa(i)
+	}
+
+	// register the name before reflecting the fields, for the recursive types
+	b.names[t] = name
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.structSchema(t)
+	return name
+}
+
+func (b *builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addProperties(schema, t)
+	return schema
+}
+
+func (b *builder) addProperties(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		// embedded structs without json name are flattened like in encoding/json
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addProperties(schema, ft)
+				continue
+			}
+		}
+
+		access := refutils.FieldAccessOf(field)
+		if access == refutils.AccessHidden {
+			continue
+		}
+		property := b.schemaOf(field.Type)
+		property.ReadOnly = access == refutils.AccessReadOnly
+		property.WriteOnly = access == refutils.AccessWriteOnly
+		schema.Properties[name] = property
+	}
+}
+
+// jsonFieldName returns the name of the field used by the encoding/json package.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if comma := strings.Index(tag, ","); comma >= 0 {
+		tag = tag[:comma]
+	}
+	if tag == "" {
+		tag = field.Name
+	}
+	return tag, true
+}
+
+// jsonPatchSchema describes the JSON Patch (RFC 6902) document.
+func jsonPatchSchema() *Schema {
+	return &Schema{
+		Type: "array",
+		Items: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"op":    {Type: "string", Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"}},
+				"path":  {Type: "string"},
+				"from":  {Type: "string"},
+				"value": {},
+			},
+			Required: []string{"op", "path"},
+		},
+	}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{jsonMediaType: {Schema: schema}}
+}
@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/response"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type User struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name" form:"name,required"`
+	Tags      []string  `json:"tags,omitempty" form:"tag"`
+	Secret    string    `json:"-" form:"-"`
+	Password  string    `json:"password" rest:"writeonly"`
+	Token     string    `json:"token" rest:"hidden"`
+	CreatedAt time.Time `json:"created_at" form:"created" time_format:"2006-01-02"`
+	Address   *Address  `json:"address"`
+	Friends   []*User   `json:"friends"`
+}
+
+func TestConvertPath(t *testing.T) {
+	Convey("Subject: convertPath function", t, func() {
+		path, params := convertPath("/users/{user}/posts/{post:[0-9]+}")
+		So(path, ShouldEqual, "/users/{user}/posts/{post}")
+		So(params, ShouldResemble, []string{"user", "post"})
+
+		path, params = convertPath("/users/:user/files/*file")
+		So(path, ShouldEqual, "/users/{user}/files/{file}")
+		So(params, ShouldResemble, []string{"user", "file"})
+
+		path, params = convertPath("/users")
+		So(path, ShouldEqual, "/users")
+		So(params, ShouldBeEmpty)
+	})
+}
+
+func TestGenerator(t *testing.T) {
+	Convey("Subject: Generator creates OpenAPI document from the registered routes", t, func() {
+		handler, err := handlers.New(&mockrepo.MockRepository{}, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		registry := handlers.NewRouteRegistry()
+
+		list := handler.New().
+			WithQueryPolicy(forms.DefaultBindPolicy.Copy()).
+			WithListParameters(&repository.ListParameters{}).
+			WithSelectCount(true)
+		registry.Register("/users", handlers.OpList, list, User{})
+
+		single := handler.New().
+			WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+			WithResponseBody(&response.DetailedBody{})
+		for _, op := range []handlers.Operation{handlers.OpGet, handlers.OpPatch, handlers.OpDelete} {
+			registry.Register("/users/{user}", op, single, &User{})
+		}
+		registry.Register("/users", handlers.OpCreate, handler, &User{})
+
+		generator := New(registry, Info{Title: "Users", Version: "1.0"})
+		doc := generator.Generate()
+
+		So(doc.OpenAPI, ShouldEqual, Version)
+		So(doc.Info.Title, ShouldEqual, "Users")
+		So(doc.Paths, ShouldContainKey, "/users")
+		So(doc.Paths, ShouldContainKey, "/users/{user}")
+
+		Convey("Model schemas are reflected from the json tags", func() {
+			user := doc.Components.Schemas["User"]
+			So(user, ShouldNotBeNil)
+			So(user.Type, ShouldEqual, "object")
+			So(user.Properties, ShouldContainKey, "id")
+			So(user.Properties, ShouldContainKey, "created_at")
+			So(user.Properties, ShouldNotContainKey, "Secret")
+			So(user.Properties["id"].Type, ShouldEqual, "integer")
+			So(user.Properties["password"].WriteOnly, ShouldBeTrue)
+			So(user.Properties, ShouldNotContainKey, "token")
+			So(user.Properties["created_at"].Format, ShouldEqual, "date-time")
+			So(user.Properties["tags"].Items.Type, ShouldEqual, "string")
+			So(user.Properties["address"].Ref, ShouldEqual, "#/components/schemas/Address")
+			So(user.Properties["friends"].Items.Ref, ShouldEqual, "#/components/schemas/User")
+			So(doc.Components.Schemas["Address"].Properties, ShouldContainKey, "city")
+			So(doc.Components.Schemas["Error"].Properties, ShouldContainKey, "code")
+		})
+
+		Convey("List operation contains query parameters from BindPolicy and ListParameters", func() {
+			op := doc.Paths["/users"].Get
+			So(op, ShouldNotBeNil)
+			So(op.OperationID, ShouldEqual, "listUser")
+
+			names := map[string]*Parameter{}
+			for _, param := range op.Parameters {
+				So(param.In, ShouldEqual, "query")
+				names[param.Name] = param
+			}
+			for _, name := range []string{"id", "name", "tag", "created", "ids", "keys", "limit", "offset", "order"} {
+				So(names, ShouldContainKey, name)
+			}
+			So(names, ShouldNotContainKey, "secret")
+			So(*names["tag"].Explode, ShouldBeTrue)
+			So(names["name"].Required, ShouldBeTrue)
+			So(names["tag"].Required, ShouldBeFalse)
+
+			body := op.Responses["200"].Content[jsonMediaType].Schema
+			content := body.Properties["content"]
+			So(content.Properties["users"].Type, ShouldEqual, "array")
+			So(content.Properties["count"].Type, ShouldEqual, "integer")
+
+			So(op.Responses["400"].Description, ShouldContainSubstring, resterrors.ErrInvalidQueryParameter.Code)
+			So(op.Responses["500"].Description, ShouldContainSubstring, resterrors.ErrInternalError.Code)
+		})
+
+		Convey("Single model operations contain path parameters", func() {
+			item := doc.Paths["/users/{user}"]
+			So(item.Get, ShouldNotBeNil)
+			So(item.Patch, ShouldNotBeNil)
+			So(item.Delete, ShouldNotBeNil)
+
+			param := item.Get.Parameters[0]
+			So(param.Name, ShouldEqual, "user")
+			So(param.In, ShouldEqual, "path")
+			So(param.Required, ShouldBeTrue)
+			So(param.Schema.Type, ShouldEqual, "integer")
+
+			So(item.Patch.RequestBody.Content[jsonMediaType].Schema.Ref, ShouldEqual, "#/components/schemas/User")
+			So(item.Patch.RequestBody.Content[forms.MergePatchMediaType].Schema.Ref, ShouldEqual,
+				"#/components/schemas/User")
+			So(item.Patch.RequestBody.Content[forms.JSONPatchMediaType].Schema.Type, ShouldEqual, "array")
+
+			body := item.Get.Responses["200"].Content[jsonMediaType].Schema
+			So(body.Properties, ShouldContainKey, "result")
+			So(body.Properties, ShouldContainKey, "status")
+			So(body.Properties["result"].Properties, ShouldContainKey, "user")
+		})
+
+		Convey("Create operation responds with 201", func() {
+			op := doc.Paths["/users"].Post
+			So(op, ShouldNotBeNil)
+			So(op.Responses, ShouldContainKey, "201")
+			So(op.RequestBody.Required, ShouldBeTrue)
+			So(op.Responses["400"].Description, ShouldContainSubstring, resterrors.ErrInvalidJSONDocument.Code)
+		})
+
+		Convey("Nested operations describe the parent parameter and 404 response", func() {
+			type Post struct {
+				ID     string `json:"id"`
+				UserID uint64 `json:"user_id"`
+			}
+			nested := single.New().WithParent(&handlers.ParentScope{Model: &User{}})
+			registry.Register("/users/{user}/posts/{post}", handlers.OpGet, nested, &Post{})
+
+			doc := generator.Generate()
+			op := doc.Paths["/users/{user}/posts/{post}"].Get
+			So(op, ShouldNotBeNil)
+			So(op.Parameters, ShouldHaveLength, 2)
+			So(op.Parameters[0].Schema.Type, ShouldEqual, "integer")
+			So(op.Parameters[1].Schema.Type, ShouldEqual, "string")
+			So(op.Responses, ShouldContainKey, "404")
+			So(op.Responses["404"].Description, ShouldContainSubstring, resterrors.ErrResourceNotFound.Code)
+			So(doc.Paths["/users/{user}"].Get.Responses, ShouldNotContainKey, "404")
+		})
+
+		Convey("Authorized operations describe the 403 and 404 responses", func() {
+			authorized := single.New().WithAuthorizer(handlers.AuthorizerFunc(
+				func(*http.Request, handlers.Operation, reflect.Type, interface{}) error { return nil }))
+			registry.Register("/private/{user}", handlers.OpDelete, authorized, &User{})
+
+			doc := generator.Generate()
+			op := doc.Paths["/private/{user}"].Delete
+			So(op, ShouldNotBeNil)
+			So(op.Responses["403"].Description, ShouldContainSubstring, resterrors.ErrInsufficientAccPerm.Code)
+			So(op.Responses, ShouldContainKey, "404")
+			So(doc.Paths["/users/{user}"].Delete.Responses, ShouldNotContainKey, "403")
+		})
+
+		Convey("Update operation with the PUT semantics describes the 201 response", func() {
+			registry.Register("/put/{user}", handlers.OpUpdate, single.New().WithPutSemantics(true), &User{})
+			registry.Register("/replace/{user}", handlers.OpUpdate, single, &User{})
+
+			doc := generator.Generate()
+			So(doc.Paths["/put/{user}"].Put.Responses, ShouldContainKey, "201")
+			So(doc.Paths["/replace/{user}"].Put.Responses, ShouldNotContainKey, "201")
+		})
+
+		Convey("Handler serves the document as JSON", func() {
+			rw := httptest.NewRecorder()
+			generator.Handler()(rw, httptest.NewRequest("GET", "/openapi.json", nil))
+
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Header().Get("Content-Type"), ShouldEqual, jsonMediaType)
+
+			served := map[string]interface{}{}
+			So(json.Unmarshal(rw.Body.Bytes(), &served), ShouldBeNil)
+			So(served["openapi"], ShouldEqual, Version)
+		})
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+	"reflect"
+)
+
+// ParentScope defines the parent resource of the nested GenericHandler.
+// I.e. for the route '/users/{user}/posts/{post}' the handler for the Post model
+// may be scoped by the User parent. Such handler checks if the parent with the ID
+// from the 'user' URL parameter exists and binds its ID into the Post's 'UserID' field,
+// so that List, Count and Create operations are limited to the parent's posts
+// and Get, Update, Patch and Delete operations would not find other user's posts.
+// The updated and patched posts could not be moved to the other user - the patch documents
+// changing the 'UserID' result in the 400 response.
+type ParentScope struct {
+	// Model is the parent model
+	Model interface{}
+
+	// Param is the name of the URL parameter that contains the parent's ID.
+	// By default it is the refutils.ModelName of the parent model i.e. 'user'.
+	Param string
+
+	// ForeignKey is the name of the child model's field that references the parent.
+	// By default it is the parent's struct name followed by 'ID' i.e. 'UserID'.
+	ForeignKey string
+}
+
+// WithParent sets the parent scope for given handler. The empty Param and ForeignKey
+// of the 'parent' are set to their default values.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithParent(parent *ParentScope) *GenericHandler {
+	if parent != nil {
+		scope := *parent
+		if scope.Param == "" {
+			scope.Param = refutils.ModelName(scope.Model)
+		}
+		if scope.ForeignKey == "" {
+			scope.ForeignKey = refutils.StructName(scope.Model) + "ID"
+		}
+		parent = &scope
+	}
+	c.Parent = parent
+	return c
+}
+
+// bindParent checks if the parent of the nested handler exists and binds its ID
+// into the foreign key field of provided 'models'. If the parent does not exist
+// the 404 response is written. Returns false if the response was already written.
+func (c *GenericHandler) bindParent(
+	rw http.ResponseWriter,
+	req *http.Request,
+	models ...interface{},
+) bool {
+	if c.Parent == nil {
+		return true
+	}
+
+	if c.GetParams == nil {
+		c.Log.Errorf("%v: %v", req.URL.Path, ErrNoParamGetterFuncSet)
+		c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, resterrors.ErrInternalError.New()))
+		return false
+	}
+
+	parentID, err := c.GetParams(c.Parent.Param, req)
+	if err != nil {
+		c.Log.Errorf("%v: %v", req.URL.Path, err)
+		c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, resterrors.ErrInternalError.New()))
+		return false
+	}
+
+	parent := refutils.ObjOfPtrType(c.Parent.Model)
+	if parentID == "" || forms.SetID(parent, parentID) != nil {
+		c.parentNotFound(rw, req)
+		return false
+	}
+
+	if _, dbErr := c.repo(req).Get(parent); dbErr != nil {
+		if dbErr.Compare(dberrors.ErrNoResult) {
+			c.parentNotFound(rw, req)
+			return false
+		}
+		c.handleDBError(rw, req, dbErr)
+		return false
+	}
+
+	for _, model := range models {
+		if err := forms.SetField(model, c.Parent.ForeignKey, parentID); err != nil {
+			c.Log.Errorf("%v: %v", req.URL.Path, err)
+			c.JSON(rw, req, 500, c.getResponseBodyErr(req, 500, resterrors.ErrInternalError.New()))
+			return false
+		}
+	}
+	return true
+}
+
+// getInParent gets the stored record selected by the 'whereObj' bound with the parent's
+// foreign key. If the record is not stored within the parent scope the 404 response is written,
+// so that the nested handler would not overwrite the records of the other parents.
+// Returns false if the response was already written.
+func (c *GenericHandler) getInParent(
+	rw http.ResponseWriter,
+	req *http.Request,
+	whereObj interface{},
+) (interface{}, bool) {
+	stored, dbErr := c.repo(req).Get(whereObj)
+	if dbErr != nil {
+		if dbErr.Compare(dberrors.ErrNoResult) {
+			c.writeRestError(rw, req, resterrors.ErrResourceNotFound.New())
+		} else {
+			c.handleDBError(rw, req, dbErr)
+		}
+		return nil, false
+	}
+	return stored, true
+}
+
+// parentChanged checks if the foreign key of the 'patched' model differs from the 'current' one.
+func (c *GenericHandler) parentChanged(current, patched interface{}) bool {
+	currentKey := reflect.Indirect(reflect.ValueOf(current)).FieldByName(c.Parent.ForeignKey)
+	patchedKey := reflect.Indirect(reflect.ValueOf(patched)).FieldByName(c.Parent.ForeignKey)
+	if !currentKey.IsValid() || !patchedKey.IsValid() {
+		return false
+	}
+	return !reflect.DeepEqual(currentKey.Interface(), patchedKey.Interface())
+}
+
+func (c *GenericHandler) parentNotFound(rw http.ResponseWriter, req *http.Request) {
+	restErr := resterrors.ErrResourceNotFound.New()
+	restErr.AddDetailInfo(refutils.StructName(c.Parent.Model) + " not found")
+	c.JSON(rw, req, http.StatusNotFound, c.getResponseBodyErr(req, http.StatusNotFound, restErr))
+}
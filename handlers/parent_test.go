@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository/memrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithParent(t *testing.T) {
+	Convey("Subject: WithParent sets the parent scope of the handler", t, func() {
+		handler, err := New(&mockrepo.MockRepository{}, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		Convey("The default Param and ForeignKey are based on the parent model", func() {
+			parent := &ParentScope{Model: &Model{}}
+			So(handler.WithParent(parent), ShouldEqual, handler)
+			So(handler.Parent.Param, ShouldEqual, "model")
+			So(handler.Parent.ForeignKey, ShouldEqual, "ModelID")
+			So(parent.Param, ShouldBeEmpty)
+		})
+
+		Convey("Provided Param and ForeignKey are not changed", func() {
+			handler.WithParent(&ParentScope{Model: &Model{}, Param: "owner", ForeignKey: "OwnerID"})
+			So(handler.Parent.Param, ShouldEqual, "owner")
+			So(handler.Parent.ForeignKey, ShouldEqual, "OwnerID")
+		})
+	})
+}
+
+func TestParentScopedHandler(t *testing.T) {
+	Convey("Subject: GenericHandler scoped by the parent", t, func() {
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		handler = handler.WithParent(&ParentScope{Model: &Model{}}).
+			WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy())
+
+		Convey("If the parent does not exist the 404 is returned", func() {
+			repo.On("Get", &Model{ID: 2}).Return(nil, dberrors.ErrNoResult.New())
+			handler.WithParamGetterFunc(getParamFuncWithValues(map[string]string{"model": "2", "post": "3"}))
+
+			rw := httptest.NewRecorder()
+			handler.Get(&Post{})(rw, httptest.NewRequest("GET", "/models/2/posts/3", nil))
+
+			So(rw.Code, ShouldEqual, 404)
+			body, err := readBody(rw)
+			So(err, ShouldBeNil)
+			So(body.Errors, ShouldNotBeEmpty)
+			So(body.Errors[0].Compare(resterrors.ErrResourceNotFound), ShouldBeTrue)
+			repo.AssertNotCalled(t, "Get", &Post{ID: 3, ModelID: 2})
+		})
+
+		Convey("If the parent param is not a valid ID the 404 is returned", func() {
+			handler.WithParamGetterFunc(getParamFuncWithValues(map[string]string{"model": "abc", "post": "3"}))
+
+			rw := httptest.NewRecorder()
+			handler.Get(&Post{})(rw, httptest.NewRequest("GET", "/models/abc/posts/3", nil))
+
+			So(rw.Code, ShouldEqual, 404)
+		})
+
+		Convey("If the parent exists", func() {
+			repo.On("Get", &Model{ID: 2}).Return(&Model{ID: 2}, nil)
+			handler.WithParamGetterFunc(getParamFuncWithValues(map[string]string{"model": "2", "post": "3"}))
+
+			Convey("Get is limited to the parent's models", func() {
+				repo.On("Get", &Post{ID: 3, ModelID: 2}).Return(&Post{ID: 3, ModelID: 2}, nil)
+
+				rw := httptest.NewRecorder()
+				handler.Get(&Post{})(rw, httptest.NewRequest("GET", "/models/2/posts/3", nil))
+
+				So(rw.Code, ShouldEqual, 200)
+				repo.AssertCalled(t, "Get", &Post{ID: 3, ModelID: 2})
+			})
+
+			Convey("List and Count are limited to the parent's models", func() {
+				repo.On("List", &Post{ModelID: 2}).Return([]*Post{{ID: 3, ModelID: 2}}, nil)
+				repo.On("Count", &Post{ModelID: 2}).Return(1, nil)
+
+				rw := httptest.NewRecorder()
+				handler.New().WithURLParams(false).WithSelectCount(true).
+					List(&Post{})(rw, httptest.NewRequest("GET", "/models/2/posts", nil))
+
+				So(rw.Code, ShouldEqual, 200)
+				repo.AssertCalled(t, "List", &Post{ModelID: 2})
+				repo.AssertCalled(t, "Count", &Post{ModelID: 2})
+			})
+
+			Convey("Create binds the parent's ID", func() {
+				repo.On("Create", &Post{ModelID: 2}).Return(nil)
+
+				rw := httptest.NewRecorder()
+				req := httptest.NewRequest("POST", "/models/2/posts", strings.NewReader(`{"ModelID":5}`))
+				handler.New().WithURLParams(false).Create(&Post{})(rw, req)
+
+				So(rw.Code, ShouldEqual, 201)
+				repo.AssertCalled(t, "Create", &Post{ModelID: 2})
+			})
+
+			Convey("Delete is limited to the parent's models", func() {
+				repo.On("Delete", &Post{}, &Post{ID: 3, ModelID: 2}).Return(nil)
+
+				rw := httptest.NewRecorder()
+				handler.Delete(&Post{})(rw, httptest.NewRequest("DELETE", "/models/2/posts/3", nil))
+
+				So(rw.Code, ShouldEqual, 200)
+				repo.AssertCalled(t, "Delete", &Post{}, &Post{ID: 3, ModelID: 2})
+			})
+		})
+	})
+}
+
+type PUser struct {
+	ID int
+}
+
+type PPost struct {
+	ID      int
+	PUserID int
+	Title   string
+}
+
+func TestParentScopedUpdate(t *testing.T) {
+	Convey("Subject: Update of the nested GenericHandler is limited to the parent's records", t, func() {
+		repo := memrepo.New()
+		So(repo.Create(&PUser{ID: 1}), ShouldBeNil)
+		So(repo.Create(&PUser{ID: 2}), ShouldBeNil)
+		So(repo.Create(&PPost{ID: 5, PUserID: 2, Title: "owned"}), ShouldBeNil)
+
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+		handler = handler.WithParent(&ParentScope{Model: &PUser{}}).
+			WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy())
+
+		update := func(user string) *httptest.ResponseRecorder {
+			handler.WithParamGetterFunc(getParamFuncWithValues(map[string]string{"puser": user, "ppost": "5"}))
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/pusers/"+user+"/pposts/5", strings.NewReader(`{"ID":5,"Title":"hijacked"}`))
+			handler.Update(&PPost{})(rw, req)
+			return rw
+		}
+
+		Convey("The record of the other parent is not found", func() {
+			So(update("1").Code, ShouldEqual, 404)
+
+			stored, dbErr := repo.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "owned"})
+		})
+
+		Convey("The parent's record is updated", func() {
+			So(update("2").Code, ShouldEqual, 200)
+
+			stored, dbErr := repo.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "hijacked"})
+		})
+	})
+}
+
+func TestParentScopedPatch(t *testing.T) {
+	Convey("Subject: Patch of the nested GenericHandler could not move the record to the other parent", t, func() {
+		repo := memrepo.New()
+		So(repo.Create(&PUser{ID: 1}), ShouldBeNil)
+		So(repo.Create(&PUser{ID: 2}), ShouldBeNil)
+		So(repo.Create(&PPost{ID: 5, PUserID: 2, Title: "owned"}), ShouldBeNil)
+
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+		handler = handler.WithParent(&ParentScope{Model: &PUser{}}).
+			WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+			WithParamGetterFunc(getParamFuncWithValues(map[string]string{"puser": "2", "ppost": "5"}))
+
+		patch := func(mediaType, body string) *httptest.ResponseRecorder {
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/pusers/2/pposts/5", strings.NewReader(body))
+			req.Header.Set("Content-Type", mediaType)
+			handler.Patch(&PPost{})(rw, req)
+			return rw
+		}
+
+		Convey("The foreign key of the JSON body is bound with the parent's ID", func() {
+			So(patch("application/json", `{"PUserID":1,"Title":"moved"}`).Code, ShouldEqual, 200)
+
+			stored, dbErr := repo.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "moved"})
+		})
+
+		Convey("The patch documents changing the foreign key are rejected", func() {
+			rw := patch(forms.JSONPatchMediaType, `[{"op":"replace","path":"/PUserID","value":1}]`)
+			So(rw.Code, ShouldEqual, 400)
+			body, err := readBody(rw)
+			So(err, ShouldBeNil)
+			So(body.Errors, ShouldNotBeEmpty)
+			So(body.Errors[0].Compare(resterrors.ErrInvalidJSONFieldValue), ShouldBeTrue)
+
+			So(patch(forms.MergePatchMediaType, `{"PUserID":1}`).Code, ShouldEqual, 400)
+
+			stored, dbErr := repo.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "owned"})
+		})
+
+		Convey("The patch documents keeping the foreign key are applied", func() {
+			So(patch(forms.MergePatchMediaType, `{"PUserID":2,"Title":"patched"}`).Code, ShouldEqual, 200)
+
+			stored, dbErr := repo.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "patched"})
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"github.com/kucjac/go-rest-sdk/tracing"
+	"net/http"
+	"reflect"
+)
+
+// patchFields handles the JSON Merge Patch and JSON Patch requests.
+// The patch is applied to the current record selected by the 'whereObj' and only the fields
+// present in the patch are stored using the repository.FieldPatcher, so that they could be
+// set to the zero values or nulls. If the handler's repository does not implement the
+// repository.FieldPatcher, or it is a decorator returning the dberrors.ErrNotSupported,
+// the request is responsed with the 415 status.
+func (c *GenericHandler) patchFields(
+	rw http.ResponseWriter,
+	req *http.Request,
+	model, whereObj interface{},
+) {
+	patcher, ok := c.repo(req).(repository.FieldPatcher)
+	if !ok {
+		c.patchNotSupported(rw, req)
+		return
+	}
+
+	current, dbErr := c.repo(req).Get(whereObj)
+	if dbErr != nil {
+		c.handleDBError(rw, req, dbErr)
+		return
+	}
+
+	obj := refutils.ObjOfPtrType(model)
+	_, span := tracing.StartSpan(req.Context(), "forms.BindPatch")
+	fields, err := forms.BindPatch(req, current, obj, c.JSONPolicy)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		restErr, ok := err.(*resterrors.Error)
+		if !ok {
+			c.Log.Errorf("%v: %v", req.URL.Path, err)
+			restErr = resterrors.ErrInternalError.New()
+		}
+		c.writeRestError(rw, req, restErr)
+		return
+	}
+
+	// the patched record could not be moved to the other parent
+	if c.Parent != nil && c.parentChanged(current, obj) {
+		restErr := resterrors.ErrInvalidJSONFieldValue.New()
+		restErr.AddDetailInfo("Field: '" + c.Parent.ForeignKey + "' could not be changed")
+		c.writeRestError(rw, req, restErr)
+		return
+	}
+
+	// the fields set by the before hooks are stored together with the patched fields
+	bound := reflect.New(reflect.Indirect(reflect.ValueOf(obj)).Type()).Elem()
+	bound.Set(reflect.Indirect(reflect.ValueOf(obj)))
+	if !c.beforeHooks(rw, req, OpPatch, obj) {
+		return
+	}
+	fields = appendChangedFields(fields, bound, obj)
+
+	dbErr = patcher.PatchFields(obj, whereObj, fields)
+	if dbErr != nil {
+		// the repository decorator could not patch the fields of the decorated repository
+		if dbErr.Compare(dberrors.ErrNotSupported) {
+			c.patchNotSupported(rw, req)
+			return
+		}
+		c.handleDBError(rw, req, dbErr)
+		return
+	}
+
+	result, dbErr := c.repo(req).Get(whereObj)
+	if dbErr != nil {
+		c.handleDBError(rw, req, dbErr)
+		return
+	}
+
+	if !c.afterHooks(rw, req, OpPatch, result) {
+		return
+	}
+	c.JSON(rw, req, 200, c.getResponseBodyContent(200, result))
+}
+
+// patchNotSupported writes the 415 response for the patch documents, which could not be stored
+// if the handler's repository is not the repository.FieldPatcher.
+func (c *GenericHandler) patchNotSupported(rw http.ResponseWriter, req *http.Request) {
+	restErr := resterrors.ErrUnsupportedMediaType.New()
+	restErr.AddDetailInfo("The patch documents are not supported for this resource")
+	c.writeRestError(rw, req, restErr)
+}
+
+// appendChangedFields appends to the 'fields' the names of the 'obj' struct fields
+// that differ from the 'bound' copy of the 'obj' and are not already listed.
+func appendChangedFields(fields []string, bound reflect.Value, obj interface{}) []string {
+	listed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		listed[field] = true
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" || listed[field.Name] {
+			continue
+		}
+		if !reflect.DeepEqual(v.Field(i).Interface(), bound.Field(i).Interface()) {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type stampedModel struct {
+	ID      int
+	Name    string
+	Stamped string
+}
+
+func (m *stampedModel) HookBeforePatch(ctx context.Context) error {
+	m.Stamped = "stamped"
+	return nil
+}
+
+func TestPatchDocuments(t *testing.T) {
+	Convey("Subject: Patch method handles the merge patch and JSON patch documents", t, func() {
+		server := http.NewServeMux()
+		repo := &mockrepo.MockRepository{}
+
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		handler.WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+			WithParamGetterFunc(getParamFuncWithValues(map[string]string{"model": "123"}))
+		server.Handle("/models/123", handler.Patch(Model{}))
+
+		patchRequest := func(mediaType, body string) *http.Request {
+			req := httptest.NewRequest("PATCH", "/models/123", strings.NewReader(body))
+			req.Header.Set("Content-Type", mediaType)
+			return req
+		}
+
+		Convey("The merge patch passes the present fields to the PatchFields", func() {
+			repo.On("Get", &Model{ID: 123}).Return(&Model{ID: 123, Name: "name"}, nil).Once()
+			repo.On("PatchFields", &Model{ID: 123, Name: ""}, &Model{ID: 123}, []string{"Name"}).
+				Return(nil).Once()
+			repo.On("Get", &Model{ID: 123}).Return(&Model{ID: 123}, nil).Once()
+
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, patchRequest(forms.MergePatchMediaType, `{"Name":""}`))
+
+			So(rw.Code, ShouldEqual, 200)
+			repo.AssertExpectations(t)
+		})
+
+		Convey("The fields set by the HookBeforePatch are patched too", func() {
+			stamped := handler.New().
+				WithParamGetterFunc(getParamFuncWithValues(map[string]string{"stampedmodel": "123"}))
+			server.Handle("/stamped/123", stamped.Patch(stampedModel{}))
+
+			repo.On("Get", &stampedModel{ID: 123}).Return(&stampedModel{ID: 123, Name: "name"}, nil).Once()
+			repo.On("PatchFields", &stampedModel{ID: 123, Name: "other", Stamped: "stamped"},
+				&stampedModel{ID: 123}, []string{"Name", "Stamped"}).Return(nil).Once()
+			repo.On("Get", &stampedModel{ID: 123}).Return(&stampedModel{ID: 123}, nil).Once()
+
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/stamped/123", strings.NewReader(`{"Name":"other"}`))
+			req.Header.Set("Content-Type", forms.MergePatchMediaType)
+			server.ServeHTTP(rw, req)
+
+			So(rw.Code, ShouldEqual, 200)
+			repo.AssertExpectations(t)
+		})
+
+		Convey("The JSON patch is applied to the current record", func() {
+			repo.On("Get", &Model{ID: 123}).Return(&Model{ID: 123, Name: "name"}, nil)
+			repo.On("PatchFields", &Model{ID: 123, Name: "other"}, &Model{ID: 123}, []string{"Name"}).
+				Return(nil)
+
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, patchRequest(forms.JSONPatchMediaType,
+				`[{"op":"replace","path":"/Name","value":"other"}]`))
+			So(rw.Code, ShouldEqual, 200)
+
+			Convey("The failed test operation results in 409", func() {
+				rw := httptest.NewRecorder()
+				server.ServeHTTP(rw, patchRequest(forms.JSONPatchMediaType,
+					`[{"op":"test","path":"/Name","value":"other"}]`))
+
+				body, err := readBody(rw)
+				So(err, ShouldBeNil)
+				So(rw.Code, ShouldEqual, 409)
+				So(body.Errors[0].Compare(resterrors.ErrPatchConflict), ShouldBeTrue)
+			})
+		})
+
+		Convey("The not existing record results in the db error response", func() {
+			repo.On("Get", &Model{ID: 123}).Return(nil, dberrors.ErrNoResult.New())
+
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, patchRequest(forms.MergePatchMediaType, `{"Name":"x"}`))
+			So(rw.Code, ShouldEqual, 400)
+		})
+
+		Convey("The repository without FieldPatcher results in 415", func() {
+			plain, err := New(struct{ repository.Repository }{repo}, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			server.Handle("/plain", plain.Patch(Model{}))
+
+			req := httptest.NewRequest("PATCH", "/plain", strings.NewReader(`{"Name":"x"}`))
+			req.Header.Set("Content-Type", forms.MergePatchMediaType)
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			body, err := readBody(rw)
+			So(err, ShouldBeNil)
+			So(rw.Code, ShouldEqual, 415)
+			So(body.Errors[0].Compare(resterrors.ErrUnsupportedMediaType), ShouldBeTrue)
+		})
+	})
+}
+
+type accessModel struct {
+	ID       int    `rest:"readonly"`
+	Name     string
+	Password string `rest:"writeonly"`
+}
+
+func TestFieldAccess(t *testing.T) {
+	Convey("Subject: GenericHandler enforces the 'rest' field access tags", t, func() {
+		server := http.NewServeMux()
+		repo := &mockrepo.MockRepository{}
+
+		handler, err := New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+		server.Handle("/models", handler.Create(accessModel{}))
+
+		Convey("The readonly field could not be created", func() {
+			req := httptest.NewRequest("POST", "/models", strings.NewReader(`{"ID":5,"Name":"name"}`))
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			body, err := readBody(rw)
+			So(err, ShouldBeNil)
+			So(rw.Code, ShouldEqual, 400)
+			So(body.Errors[0].Compare(resterrors.ErrUnsupportedJSONField), ShouldBeTrue)
+		})
+
+		Convey("The writeonly field is not responsed", func() {
+			repo.On("Create", &accessModel{Name: "name", Password: "secret"}).Return(nil)
+
+			req := httptest.NewRequest("POST", "/models", strings.NewReader(`{"Name":"name","Password":"secret"}`))
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			So(rw.Code, ShouldEqual, 201)
+			So(rw.Body.String(), ShouldContainSubstring, `"Name":"name"`)
+			So(rw.Body.String(), ShouldNotContainSubstring, "secret")
+		})
+	})
+}
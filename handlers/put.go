@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"net/http"
+)
+
+// WithPutSemantics sets the Update method of the handler to use the PUT semantics.
+// The model is stored at its primary key - the record is created if it does not exist
+// and the response has the 201 status, otherwise the record is replaced and the response
+// has the 200 status. If the handler's repository is a repository.Upserter the model is
+// stored using its Upsert method, otherwise (or if the Upsert returns the dberrors.ErrNotSupported)
+// the stored record is checked before the Update.
+// The nested handler checks the stored record within its parent scope and never upserts.
+// Returns given handler so it can be used in a callback manner
+func (c *GenericHandler) WithPutSemantics(usePut bool) *GenericHandler {
+	c.UsePutSemantics = usePut
+	return c
+}
+
+// put stores the bound 'obj' with the PUT semantics and writes the response.
+// The nested handler never upserts the 'obj' at its bare primary key. The record stored
+// within the parent scope is checked first and if the primary key is used by the record
+// of the other parent the 404 response is written.
+func (c *GenericHandler) put(rw http.ResponseWriter, req *http.Request, obj interface{}) {
+	whereObj := updateWhere(obj)
+	if whereObj == nil {
+		if !c.bindParent(rw, req, obj) {
+			return
+		}
+	} else if !c.bindParent(rw, req, obj, whereObj) {
+		return
+	}
+
+	upserter, isUpserter := c.repo(req).(repository.Upserter)
+	if c.Parent != nil {
+		isUpserter = false
+	}
+
+	// the stored record is authorized, and if the repository could not upsert,
+	// it defines whether the record is created
+	var ok, checked bool
+	created := whereObj == nil
+	if whereObj != nil && (c.Authorizer != nil || !isUpserter) {
+		if created, ok = c.checkStored(rw, req, whereObj, obj); !ok {
+			return
+		}
+		checked = true
+	}
+	if created && !c.authorize(rw, req, OpUpdate, obj) {
+		return
+	}
+
+	if !c.beforeHooks(rw, req, OpUpdate, obj) {
+		return
+	}
+
+	var dbErr *dberrors.Error
+	if isUpserter {
+		var upserted bool
+		upserted, dbErr = upserter.Upsert(obj, nil, nil)
+		if dbErr == nil {
+			created = upserted
+		} else if dbErr.Compare(dberrors.ErrNotSupported) {
+			// the repository decorator could not upsert as the decorated repository is not
+			// an Upserter, thus the model is updated as it would be for the plain repository
+			isUpserter = false
+			if whereObj != nil && !checked {
+				if created, ok = c.checkStored(rw, req, whereObj, obj); !ok {
+					return
+				}
+			}
+		}
+	}
+	if !isUpserter {
+		dbErr = c.repo(req).Update(obj)
+	}
+	if dbErr != nil {
+		c.handleDBError(rw, req, dbErr)
+		return
+	}
+
+	if !c.afterHooks(rw, req, OpUpdate, obj) {
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(rw, req, status, c.getResponseBodyContent(status, obj))
+}
+
+// checkStored gets the record selected by the 'whereObj' and authorizes the update
+// of the stored record. Returns true 'created' if the record is not stored and false 'ok'
+// if the response was already written.
+func (c *GenericHandler) checkStored(
+	rw http.ResponseWriter,
+	req *http.Request,
+	whereObj, obj interface{},
+) (created, ok bool) {
+	stored, dbErr := c.repo(req).Get(whereObj)
+	switch {
+	case dbErr == nil:
+		return false, c.authorize(rw, req, OpUpdate, stored)
+	case dbErr.Compare(dberrors.ErrNoResult):
+		if c.Parent != nil && !c.notStoredOutOfParent(rw, req, obj) {
+			return false, false
+		}
+		return true, true
+	default:
+		c.handleDBError(rw, req, dbErr)
+		return false, false
+	}
+}
+
+// notStoredOutOfParent checks if the primary key of the 'obj' is not used by the record
+// stored out of the parent scope. Otherwise the 404 response is written, so that
+// the record of the other parent is neither revealed nor overwritten.
+// Returns false if the response was already written.
+func (c *GenericHandler) notStoredOutOfParent(rw http.ResponseWriter, req *http.Request, obj interface{}) bool {
+	_, dbErr := c.repo(req).Get(updateWhere(obj))
+	switch {
+	case dbErr == nil:
+		c.writeRestError(rw, req, resterrors.ErrResourceNotFound.New())
+		return false
+	case dbErr.Compare(dberrors.ErrNoResult):
+		return true
+	default:
+		c.handleDBError(rw, req, dbErr)
+		return false
+	}
+}
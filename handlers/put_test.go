@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/memrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type putModel struct {
+	ID   int
+	Name string
+}
+
+// plainRepository hides the Upsert method of the embedded repository
+type plainRepository struct {
+	repository.Repository
+}
+
+// failingUpserter is the repository.Upserter which Upsert method should not be called
+type failingUpserter struct {
+	*memrepo.MemoryRepository
+}
+
+func (f failingUpserter) Upsert(req interface{}, conflictFields, updateFields []string) (bool, *dberrors.Error) {
+	return false, dberrors.ErrUnspecifiedError.NewWithMessage("Upsert called")
+}
+
+func TestPutSemantics(t *testing.T) {
+	Convey("Subject: GenericHandler Update with the PUT semantics", t, func() {
+		repo := &mockrepo.MockRepository{}
+
+		put := func(handler *GenericHandler, body string) *httptest.ResponseRecorder {
+			rw := httptest.NewRecorder()
+			handler.Update(putModel{})(rw, httptest.NewRequest("PUT", "/models/5", strings.NewReader(body)))
+			return rw
+		}
+
+		Convey("The repository.Upserter defines whether the record was created", func() {
+			handler, err := New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			handler.WithPutSemantics(true)
+			So(handler.Route(OpUpdate, "/models/{id}", putModel{}).UsePutSemantics, ShouldBeTrue)
+
+			repo.On("Upsert", &putModel{ID: 5, Name: "new"}, []string(nil), []string(nil)).Return(true, nil)
+			repo.On("Upsert", &putModel{ID: 5, Name: "stored"}, []string(nil), []string(nil)).Return(false, nil)
+
+			So(put(handler, `{"ID":5,"Name":"new"}`).Code, ShouldEqual, 201)
+			So(put(handler, `{"ID":5,"Name":"stored"}`).Code, ShouldEqual, 200)
+			repo.AssertNotCalled(t, "Update", &putModel{ID: 5, Name: "new"})
+		})
+
+		Convey("The stored record is checked if the repository could not upsert", func() {
+			handler, err := New(plainRepository{repo}, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			handler.WithPutSemantics(true)
+
+			repo.On("Update", &putModel{ID: 5, Name: "name"}).Return(nil)
+			repo.On("Update", &putModel{Name: "name"}).Return(nil)
+
+			Convey("The record that does not exist is created", func() {
+				repo.On("Get", &putModel{ID: 5}).Return(nil, dberrors.ErrNoResult.New())
+				So(put(handler, `{"ID":5,"Name":"name"}`).Code, ShouldEqual, 201)
+			})
+
+			Convey("The stored record is updated", func() {
+				repo.On("Get", &putModel{ID: 5}).Return(&putModel{ID: 5}, nil)
+				So(put(handler, `{"ID":5,"Name":"name"}`).Code, ShouldEqual, 200)
+			})
+
+			Convey("The model without primary key is created", func() {
+				So(put(handler, `{"Name":"name"}`).Code, ShouldEqual, 201)
+				repo.AssertNotCalled(t, "Get", &putModel{})
+			})
+
+			Convey("The repository errors are handled", func() {
+				repo.On("Get", &putModel{ID: 5}).Return(nil, dberrors.ErrConnExc.New())
+				So(put(handler, `{"ID":5,"Name":"name"}`).Code, ShouldEqual, 500)
+			})
+		})
+
+		Convey("The nested handler does not overwrite the other parent's records", func() {
+			mem := memrepo.New()
+			So(mem.Create(&PUser{ID: 1}), ShouldBeNil)
+			So(mem.Create(&PUser{ID: 2}), ShouldBeNil)
+			So(mem.Create(&PPost{ID: 5, PUserID: 2, Title: "owned"}), ShouldBeNil)
+
+			handler, err := New(failingUpserter{mem}, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			handler = handler.WithPutSemantics(true).
+				WithParent(&ParentScope{Model: &PUser{}}).
+				WithURLParams(true).
+				WithParamPolicy(forms.DefaultParamPolicy.Copy())
+
+			putPost := func(user, post string) *httptest.ResponseRecorder {
+				handler.WithParamGetterFunc(getParamFuncWithValues(map[string]string{"puser": user, "ppost": post}))
+				rw := httptest.NewRecorder()
+				req := httptest.NewRequest("PUT", "/pusers/"+user+"/pposts/"+post, strings.NewReader(`{"Title":"hijacked"}`))
+				handler.Update(&PPost{})(rw, req)
+				return rw
+			}
+
+			So(putPost("1", "5").Code, ShouldEqual, 404)
+			stored, dbErr := mem.Get(&PPost{ID: 5})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 5, PUserID: 2, Title: "owned"})
+
+			So(putPost("2", "5").Code, ShouldEqual, 200)
+			So(putPost("1", "6").Code, ShouldEqual, 201)
+			stored, dbErr = mem.Get(&PPost{ID: 6})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &PPost{ID: 6, PUserID: 1, Title: "hijacked"})
+		})
+
+		Convey("Without the PUT semantics the Update responds with 200", func() {
+			handler, err := New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+
+			repo.On("Update", &putModel{ID: 5, Name: "name"}).Return(nil)
+			So(put(handler, `{"ID":5,"Name":"name"}`).Code, ShouldEqual, 200)
+		})
+	})
+}
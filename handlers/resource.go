@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"net/http"
+	"strings"
+)
+
+// ResourceRoute is a single route of the resource, created by the Resource function.
+// The router adapters mount the routes using their own API.
+type ResourceRoute struct {
+	// Operation is the GenericHandler operation for the route
+	Operation Operation
+
+	// Method is the HTTP method of the route
+	Method string
+
+	// Path is the full route path
+	Path string
+
+	// Model is the model used by the route
+	Model interface{}
+
+	// Handler is the GenericHandler configured for the route
+	Handler *GenericHandler
+}
+
+// ParamFormatter formats the route parameter name into the router specific syntax.
+type ParamFormatter func(name string) string
+
+// BracesParam formats the route parameter as '{name}'.
+// It is used by the go-chi and gorilla/mux routers.
+func BracesParam(name string) string {
+	return "{" + name + "}"
+}
+
+// ColonParam formats the route parameter as ':name'.
+// It is used by the httprouter and gin routers.
+func ColonParam(name string) string {
+	return ":" + name
+}
+
+// ResourceOptions defines the routes created by the Resource function.
+type ResourceOptions struct {
+	// Disabled contains the operations that should not be mounted
+	Disabled map[Operation]bool
+
+	// Handler overrides the GenericHandler used by the resource
+	Handler *GenericHandler
+
+	// Registry if set, records all the resource routes
+	Registry *RouteRegistry
+
+	// SubResources are the resources nested in the resource item path
+	SubResources []SubResource
+}
+
+// SubResource is a resource nested in the parent's item path.
+// I.e. for the parent '/users/{user}' and the sub resource path '/posts'
+// the routes would be '/users/{user}/posts' and '/users/{user}/posts/{post}'.
+// If the sub resource handler has no Parent scope set, the copy of the handler
+// scoped by the parent model is used, so that the sub resources are limited to
+// the existing parent - see ParentScope.
+type SubResource struct {
+	Path    string
+	Model   interface{}
+	Options []ResourceOption
+}
+
+// ResourceOption is a function that sets the ResourceOptions.
+type ResourceOption func(o *ResourceOptions)
+
+// WithoutOperations disables provided operations for the resource.
+func WithoutOperations(ops ...Operation) ResourceOption {
+	return func(o *ResourceOptions) {
+		for _, op := range ops {
+			o.Disabled[op] = true
+		}
+	}
+}
+
+// WithHandler sets the GenericHandler used by the resource. It is useful
+// for the sub resources that need different handler configuration than their parent.
+func WithHandler(handler *GenericHandler) ResourceOption {
+	return func(o *ResourceOptions) {
+		o.Handler = handler
+	}
+}
+
+// WithRegistry records all the resource routes in the 'registry'.
+// The sub resources inherit the registry of their parent.
+func WithRegistry(registry *RouteRegistry) ResourceOption {
+	return func(o *ResourceOptions) {
+		o.Registry = registry
+	}
+}
+
+// WithSubResource nests the resource of the 'model' at the 'path' within the
+// parent resource item path.
+func WithSubResource(path string, model interface{}, opts ...ResourceOption) ResourceOption {
+	return func(o *ResourceOptions) {
+		o.SubResources = append(o.SubResources, SubResource{Path: path, Model: model, Options: opts})
+	}
+}
+
+// Resource creates the routes for all the CRUD operations of the 'model' mounted at the 'path':
+//	# POST	 {path}			- Create
+//	# GET	 {path}			- List
+//	# GET	 {path}/{model}	- Get
+//	# PUT	 {path}/{model}	- Update
+//	# PATCH	 {path}/{model}	- Patch
+//	# DELETE {path}/{model}	- Delete
+// The item parameter name is the refutils.ModelName of the 'model' - the same as used
+// by default in forms.BindParams, formatted by the 'param' formatter.
+// The item routes use the copy of the handler with URL params binding turned on.
+// If the handler has no ParamPolicy, the forms.DefaultParamPolicy is used.
+// The function is used by the router adapters' Resource functions.
+func Resource(
+	handler *GenericHandler,
+	path string,
+	model interface{},
+	param ParamFormatter,
+	opts ...ResourceOption,
+) []ResourceRoute {
+	return resource(handler, nil, nil, path, model, param, opts...)
+}
+
+func resource(
+	handler *GenericHandler,
+	registry *RouteRegistry,
+	parent interface{},
+	path string,
+	model interface{},
+	param ParamFormatter,
+	opts ...ResourceOption,
+) []ResourceRoute {
+	options := &ResourceOptions{
+		Disabled: make(map[Operation]bool),
+		Handler:  handler,
+		Registry: registry,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if parent != nil && options.Handler.Parent == nil {
+		options.Handler = options.Handler.New().WithParent(&ParentScope{Model: parent})
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	itemPath := path + "/" + param(refutils.ModelName(model))
+
+	itemHandler := options.Handler.New().WithURLParams(true)
+	if itemHandler.ParamPolicy == nil {
+		itemHandler.WithParamPolicy(forms.DefaultParamPolicy.Copy())
+	}
+
+	var routes []ResourceRoute
+	for _, op := range Operations {
+		if options.Disabled[op] {
+			continue
+		}
+		route := ResourceRoute{Operation: op, Method: op.Method(), Model: model}
+		switch op {
+		case OpCreate, OpList:
+			route.Path = path
+			route.Handler = options.Handler
+		default:
+			route.Path = itemPath
+			route.Handler = itemHandler
+		}
+		if options.Registry != nil {
+			options.Registry.Add(route.Handler.Route(op, route.Path, model))
+		}
+		routes = append(routes, route)
+	}
+
+	for _, sub := range options.SubResources {
+		routes = append(routes, resource(options.Handler, options.Registry, model,
+			itemPath+"/"+strings.TrimPrefix(sub.Path, "/"), sub.Model, param, sub.Options...)...)
+	}
+	return routes
+}
+
+// HandlerFunc returns the http.HandlerFunc for the route.
+func (r ResourceRoute) HandlerFunc() http.HandlerFunc {
+	return r.Handler.Handler(r.Operation, r.Model)
+}
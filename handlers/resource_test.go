@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+type Post struct {
+	ID      int
+	ModelID int
+}
+
+func TestResource(t *testing.T) {
+	Convey("Subject: Resource function", t, func() {
+		handler, err := New(&mockrepo.MockRepository{}, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		Convey("Creates routes for all operations", func() {
+			routes := Resource(handler, "/models/", &Model{}, BracesParam)
+			So(routes, ShouldHaveLength, len(Operations))
+
+			paths := map[Operation]string{}
+			for _, route := range routes {
+				paths[route.Operation] = route.Method + " " + route.Path
+				So(route.HandlerFunc(), ShouldNotBeNil)
+			}
+			So(paths[OpCreate], ShouldEqual, "POST /models")
+			So(paths[OpList], ShouldEqual, "GET /models")
+			So(paths[OpGet], ShouldEqual, "GET /models/{model}")
+			So(paths[OpUpdate], ShouldEqual, "PUT /models/{model}")
+			So(paths[OpPatch], ShouldEqual, "PATCH /models/{model}")
+			So(paths[OpDelete], ShouldEqual, "DELETE /models/{model}")
+
+			Convey("Item routes bind URL params with default policy", func() {
+				So(routes[OpCreate].Handler, ShouldEqual, handler)
+				So(routes[OpGet].Handler, ShouldNotEqual, handler)
+				So(routes[OpGet].Handler.UseURLParams, ShouldBeTrue)
+				So(routes[OpGet].Handler.ParamPolicy, ShouldResemble, forms.DefaultParamPolicy.Copy())
+				So(handler.UseURLParams, ShouldBeFalse)
+			})
+		})
+
+		Convey("Operations may be disabled", func() {
+			routes := Resource(handler, "/models", &Model{}, ColonParam,
+				WithoutOperations(OpDelete, OpUpdate))
+			So(routes, ShouldHaveLength, len(Operations)-2)
+			for _, route := range routes {
+				So(route.Operation, ShouldNotEqual, OpDelete)
+				So(route.Operation, ShouldNotEqual, OpUpdate)
+			}
+		})
+
+		Convey("Sub resources are nested in the item path and recorded in the registry", func() {
+			registry := NewRouteRegistry()
+			other := handler.New()
+			routes := Resource(handler, "/models", &Model{}, ColonParam,
+				WithRegistry(registry),
+				WithSubResource("/posts", &Post{}, WithHandler(other), WithoutOperations(OpUpdate)),
+			)
+			So(routes, ShouldHaveLength, 2*len(Operations)-1)
+			So(registry.Routes(), ShouldHaveLength, len(routes))
+
+			subRoutes := routes[len(Operations):]
+			So(subRoutes[0].Path, ShouldEqual, "/models/:model/posts")
+			So(subRoutes[0].Handler.Repo, ShouldEqual, other.Repo)
+			So(subRoutes[1].Path, ShouldEqual, "/models/:model/posts/:post")
+			So(subRoutes[2].Path, ShouldEqual, "/models/:model/posts")
+
+			Convey("The sub resource handlers are scoped by the parent", func() {
+				for _, route := range subRoutes {
+					So(route.Handler.Parent, ShouldNotBeNil)
+					So(route.Handler.Parent.Param, ShouldEqual, "model")
+					So(route.Handler.Parent.ForeignKey, ShouldEqual, "ModelID")
+				}
+				So(other.Parent, ShouldBeNil)
+				So(registry.Routes()[len(Operations)].Parent, ShouldNotBeNil)
+			})
+		})
+
+		Convey("The sub resource handler with the Parent set is used as is", func() {
+			other := handler.New().WithParent(&ParentScope{Model: &Model{}, ForeignKey: "OwnerID"})
+			routes := Resource(handler, "/models", &Model{}, BracesParam,
+				WithSubResource("/posts", &Post{}, WithHandler(other)),
+			)
+			So(routes[len(Operations)].Handler, ShouldEqual, other)
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"github.com/kucjac/go-rest-sdk/tracing"
+	"net/http"
+	"sync"
+)
+
+type responseErrorsKey struct{}
+
+// ResponseErrors collects the resterrors written by the GenericHandler in the error responses,
+// so that the middlewares i.e. the metrics or tracing could describe the request failure.
+// ResponseErrors is safe for concurrent use.
+type ResponseErrors struct {
+	mu   sync.Mutex
+	errs []*resterrors.Error
+}
+
+// WithResponseErrors returns the copy of the 'req' for which the GenericHandler
+// collects the written resterrors in the returned ResponseErrors.
+func WithResponseErrors(req *http.Request) (*http.Request, *ResponseErrors) {
+	collected := &ResponseErrors{}
+	return req.WithContext(context.WithValue(req.Context(), responseErrorsKey{}, collected)), collected
+}
+
+// Errors returns the collected errors.
+func (r *ResponseErrors) Errors() []*resterrors.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]*resterrors.Error, len(r.errs))
+	copy(errs, r.errs)
+	return errs
+}
+
+// recordErrors adds the 'errs' to the ResponseErrors of the 'req' if it collects them.
+// The errors are recorded on the request tracing span too.
+func recordErrors(req *http.Request, errs []*resterrors.Error) {
+	if req == nil {
+		return
+	}
+	span := tracing.SpanFromContext(req.Context())
+	for _, err := range errs {
+		span.RecordError(err)
+	}
+
+	collected, ok := req.Context().Value(responseErrorsKey{}).(*ResponseErrors)
+	if !ok {
+		return
+	}
+	collected.mu.Lock()
+	collected.errs = append(collected.errs, errs...)
+	collected.mu.Unlock()
+}
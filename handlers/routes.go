@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/response"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Operation defines the CRUD operation provided by the GenericHandler.
+type Operation int
+
+// Following operations are provided by the GenericHandler
+const (
+	OpCreate Operation = iota
+	OpGet
+	OpList
+	OpUpdate
+	OpPatch
+	OpDelete
+)
+
+var operationNames = []string{
+	"create",
+	"get",
+	"list",
+	"update",
+	"patch",
+	"delete",
+}
+
+var operationMethods = []string{
+	http.MethodPost,
+	http.MethodGet,
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Operations contains all the operations provided by the GenericHandler.
+var Operations = []Operation{OpCreate, OpGet, OpList, OpUpdate, OpPatch, OpDelete}
+
+func (o Operation) String() string {
+	return operationNames[o]
+}
+
+// Method returns the HTTP method used for given operation.
+func (o Operation) Method() string {
+	return operationMethods[o]
+}
+
+// Handler returns the http.HandlerFunc of the GenericHandler for given operation 'op'.
+func (c *GenericHandler) Handler(op Operation, model interface{}) http.HandlerFunc {
+	switch op {
+	case OpCreate:
+		return c.Create(model)
+	case OpGet:
+		return c.Get(model)
+	case OpList:
+		return c.List(model)
+	case OpUpdate:
+		return c.Update(model)
+	case OpPatch:
+		return c.Patch(model)
+	case OpDelete:
+		return c.Delete(model)
+	}
+	return nil
+}
+
+// Route describes the GenericHandler operation mounted on the router.
+// It contains the handler configuration at the moment of registration.
+type Route struct {
+	// Method is the HTTP method of the route
+	Method string
+
+	// Path is the route path as used by the router i.e.: '/users/{user}' or '/users/:user'
+	Path string
+
+	// Operation is the GenericHandler operation used by the route
+	Operation Operation
+
+	// Model is the type of the model used by the route
+	Model reflect.Type
+
+	// Handler configuration
+	QueryPolicy      *forms.BindPolicy
+	ParamPolicy      *forms.ParamPolicy
+	UseURLParams     bool
+	ListParams       *repository.ListParameters
+	IncludeListCount bool
+	ResponseBody     response.Responser
+	Parent           *ParentScope
+
+	// PatchDocuments is true if the Patch operation accepts the JSON Merge Patch
+	// and JSON Patch documents (the handler's repository is a repository.FieldPatcher)
+	PatchDocuments bool
+
+	// Authorized is true if the handler's operations are checked by the Authorizer
+	Authorized bool
+
+	// UsePutSemantics is true if the Update operation responds with the 201 status
+	// when the record is created
+	UsePutSemantics bool
+}
+
+// RouteRegistry records the routes created by the GenericHandlers.
+// The registered routes may be used to generate the API documentation.
+// RouteRegistry is safe for concurrent use.
+type RouteRegistry struct {
+	mu     sync.RWMutex
+	routes []Route
+}
+
+// NewRouteRegistry creates new empty RouteRegistry.
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{}
+}
+
+// Register records the route for given 'path', 'op' operation and 'model' using
+// the 'handler' configuration, and returns the handler's http.HandlerFunc for given operation.
+// I.e.:
+//	router.Post("/users", registry.Register("/users", handlers.OpCreate, handler, User{}))
+func (r *RouteRegistry) Register(
+	path string,
+	op Operation,
+	handler *GenericHandler,
+	model interface{},
+) http.HandlerFunc {
+	r.Add(handler.Route(op, path, model))
+	return handler.Handler(op, model)
+}
+
+// Add adds the 'route' to the registry.
+func (r *RouteRegistry) Add(route Route) {
+	r.mu.Lock()
+	r.routes = append(r.routes, route)
+	r.mu.Unlock()
+}
+
+// Routes returns a copy of the registered routes in the order of registration.
+func (r *RouteRegistry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := make([]Route, len(r.routes))
+	copy(routes, r.routes)
+	return routes
+}
+
+// Route describes given operation 'op' for the 'model' mounted at the 'path'.
+func (c *GenericHandler) Route(op Operation, path string, model interface{}) Route {
+	return Route{
+		Method:           op.Method(),
+		Path:             path,
+		Operation:        op,
+		Model:            refutils.GetType(model),
+		QueryPolicy:      c.QueryPolicy,
+		ParamPolicy:      c.ParamPolicy,
+		UseURLParams:     c.UseURLParams,
+		ListParams:       c.ListParams,
+		IncludeListCount: c.IncludeListCount,
+		ResponseBody:     c.ResponseBody,
+		Parent:           c.Parent,
+		PatchDocuments:   isFieldPatcher(c.Repo),
+		Authorized:       c.Authorizer != nil,
+		UsePutSemantics:  c.UsePutSemantics,
+	}
+}
+
+func isFieldPatcher(repo repository.Repository) bool {
+	_, ok := repo.(repository.FieldPatcher)
+	return ok
+}
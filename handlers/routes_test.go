@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"reflect"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	Convey("Subject: Operation names and methods", t, func() {
+		So(OpCreate.String(), ShouldEqual, "create")
+		So(OpCreate.Method(), ShouldEqual, "POST")
+		So(OpGet.Method(), ShouldEqual, "GET")
+		So(OpList.String(), ShouldEqual, "list")
+		So(OpList.Method(), ShouldEqual, "GET")
+		So(OpUpdate.Method(), ShouldEqual, "PUT")
+		So(OpPatch.Method(), ShouldEqual, "PATCH")
+		So(OpDelete.Method(), ShouldEqual, "DELETE")
+	})
+}
+
+func TestRouteRegistry(t *testing.T) {
+	Convey("Subject: RouteRegistry", t, func() {
+		handler, err := New(&mockrepo.MockRepository{}, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		handler.WithQueryPolicy(forms.DefaultBindPolicy.Copy()).
+			WithListParameters(&repository.ListParameters{Limit: 10})
+
+		registry := NewRouteRegistry()
+
+		Convey("Register records the route and returns the handler func", func() {
+			for _, op := range Operations {
+				So(registry.Register("/models", op, handler, &Model{}), ShouldNotBeNil)
+			}
+
+			routes := registry.Routes()
+			So(routes, ShouldHaveLength, len(Operations))
+
+			list := routes[OpList]
+			So(list.Operation, ShouldEqual, OpList)
+			So(list.Method, ShouldEqual, "GET")
+			So(list.Path, ShouldEqual, "/models")
+			So(list.Model, ShouldEqual, reflect.TypeOf(Model{}))
+			So(list.QueryPolicy, ShouldEqual, handler.QueryPolicy)
+			So(list.ListParams, ShouldEqual, handler.ListParams)
+			So(list.ResponseBody, ShouldEqual, handler.ResponseBody)
+
+			Convey("Routes returns a copy of the registered routes", func() {
+				routes[0].Path = "/changed"
+				So(registry.Routes()[0].Path, ShouldEqual, "/models")
+			})
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/tracing"
+	"net/http"
+)
+
+// bindParams binds the request parameters into the 'obj' within the 'forms.BindParams' span.
+func (c *GenericHandler) bindParams(req *http.Request, obj interface{}) error {
+	_, span := tracing.StartSpan(req.Context(), "forms.BindParams")
+	defer span.End()
+
+	err := forms.BindParams(req, obj, c.GetParams, c.ParamPolicy)
+	span.RecordError(err)
+	return err
+}
+
+// bindQuery binds the request query into the 'obj' within the 'forms.BindQuery' span.
+func (c *GenericHandler) bindQuery(req *http.Request, obj interface{}) error {
+	_, span := tracing.StartSpan(req.Context(), "forms.BindQuery")
+	defer span.End()
+
+	err := forms.BindQuery(req, obj, c.QueryPolicy)
+	span.RecordError(err)
+	return err
+}
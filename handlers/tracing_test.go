@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/tracing"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	Convey("Subject: Tracing the GenericHandler requests", t, func() {
+		exporter := tracing.NewInMemoryExporter()
+		tracer := tracing.NewTracer(exporter)
+		repo := &mockrepo.MockRepository{}
+		handler, err := New(tracing.InstrumentRepository(repo, tracer), errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+
+		Convey("The binding, repository and encoding spans are the children of the request span", func() {
+			repo.On("List", &Model{}).Return([]*Model{{ID: 1, Name: "name"}}, nil)
+
+			req := httptest.NewRequest("GET", "/models", nil)
+			req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			rw := httptest.NewRecorder()
+			tracing.Middleware(tracer, "/models", handler.List(Model{}))(rw, req)
+			So(rw.Code, ShouldEqual, 200)
+
+			root, ok := exporter.Span("GET /models")
+			So(ok, ShouldBeTrue)
+			So(root.Parent.Traceparent(), ShouldEqual, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			So(root.Attributes["http.status_code"], ShouldEqual, "200")
+			So(root.Status, ShouldEqual, tracing.StatusUnset)
+
+			for _, name := range []string{"forms.BindQuery", "Repository.List", "json.Marshal"} {
+				span, ok := exporter.Span(name)
+				So(ok, ShouldBeTrue)
+				So(span.Parent, ShouldResemble, root.SpanContext)
+			}
+			So(exporter.Spans(), ShouldHaveLength, 4)
+		})
+
+		Convey("The errors are recorded with the dberrors and resterrors codes", func() {
+			repo.On("Create", &Model{Name: "name"}).Return(dberrors.ErrUniqueViolation.New())
+
+			req := httptest.NewRequest("POST", "/models", strings.NewReader(`{"Name":"name"}`))
+			tracing.Middleware(tracer, "/models", handler.Create(Model{}))(httptest.NewRecorder(), req)
+
+			_, ok := exporter.Span("forms.BindJSON")
+			So(ok, ShouldBeTrue)
+
+			create, ok := exporter.Span("Repository.Create")
+			So(ok, ShouldBeTrue)
+			So(create.Status, ShouldEqual, tracing.StatusError)
+			So(create.Events[0].Attributes["dberrors.title"], ShouldEqual, dberrors.ErrUniqueViolation.Title)
+
+			restErr, _ := errhandler.New().Handle(dberrors.ErrUniqueViolation.New())
+			root, _ := exporter.Span("POST /models")
+			So(root.Status, ShouldEqual, tracing.StatusError)
+			So(root.Events[0].Attributes["resterrors.code"], ShouldEqual, restErr.Code)
+		})
+
+		Convey("No spans are started without the request span", func() {
+			repo.On("List", &Model{}).Return([]*Model{}, nil)
+			handler.List(Model{})(httptest.NewRecorder(), httptest.NewRequest("GET", "/models", nil))
+
+			spans := exporter.Spans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name, ShouldEqual, "Repository.List")
+			So(spans[0].Parent.IsValid(), ShouldBeFalse)
+		})
+	})
+}
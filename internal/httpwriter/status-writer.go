@@ -0,0 +1,71 @@
+// Package httpwriter contains the http.ResponseWriter decorators shared by the
+// instrumenting middlewares.
+package httpwriter
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusWriter records the status of the response. The http.Flusher and http.Hijacker
+// implementations of the decorated writer are preserved.
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// NewStatusWriter creates the StatusWriter decorating the 'rw'.
+func NewStatusWriter(rw http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: rw}
+}
+
+// WriteHeader implements http.ResponseWriter interface. Only the first status is recorded.
+func (s *StatusWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter interface.
+func (s *StatusWriter) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Status returns the written status or 200 if the handler did not write the status.
+func (s *StatusWriter) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+// Flush implements http.Flusher interface. Does nothing if the decorated writer is not a Flusher.
+func (s *StatusWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		if s.status == 0 {
+			s.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface. Returns an error if the decorated writer
+// is not a Hijacker.
+func (s *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("The response writer is not a http.Hijacker")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the decorated writer, used by the http.ResponseController.
+func (s *StatusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
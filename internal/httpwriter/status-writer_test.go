@@ -0,0 +1,64 @@
+package httpwriter
+
+import (
+	"bufio"
+	. "github.com/smartystreets/goconvey/convey"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hijackRecorder is the httptest.ResponseRecorder implementing the http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestStatusWriter(t *testing.T) {
+	Convey("Subject: StatusWriter records the status of the response", t, func() {
+		rw := httptest.NewRecorder()
+		writer := NewStatusWriter(rw)
+
+		Convey("The first written status is recorded", func() {
+			writer.WriteHeader(http.StatusNoContent)
+			writer.WriteHeader(http.StatusInternalServerError)
+			So(writer.Status(), ShouldEqual, http.StatusNoContent)
+			So(rw.Code, ShouldEqual, http.StatusNoContent)
+		})
+
+		Convey("The written body and the empty response have the status 200", func() {
+			So(writer.Status(), ShouldEqual, http.StatusOK)
+
+			writer.Write([]byte("[]"))
+			writer.WriteHeader(http.StatusInternalServerError)
+			So(writer.Status(), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("The flushed response has the status 200", func() {
+			writer.Flush()
+			writer.WriteHeader(http.StatusInternalServerError)
+			So(rw.Flushed, ShouldBeTrue)
+			So(writer.Status(), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("The decorated http.Hijacker is used", func() {
+			recorder := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+			_, _, err := NewStatusWriter(recorder).Hijack()
+			So(err, ShouldBeNil)
+			So(recorder.hijacked, ShouldBeTrue)
+
+			_, _, err = writer.Hijack()
+			So(err, ShouldBeError)
+		})
+
+		Convey("The decorated writer is unwrapped", func() {
+			So(writer.Unwrap(), ShouldEqual, rw)
+		})
+	})
+}
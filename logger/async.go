@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWriterClosed is returned by the AsyncWriter.Write method if the writer was already closed.
+var ErrWriterClosed = errors.New("logger: writer already closed")
+
+// DropPolicy defines the behaviour of the AsyncWriter when its queue is full.
+type DropPolicy int
+
+// Following drop policies are supported by the AsyncWriter
+const (
+	// Block waits until there is a free space in the queue.
+	Block DropPolicy = iota
+
+	// DropNewest discards the message that is currently being written.
+	DropNewest
+
+	// DropOldest discards the oldest queued message and enqueues the new one.
+	DropOldest
+)
+
+var dropPolicyNames = []string{
+	"Block",
+	"DropNewest",
+	"DropOldest",
+}
+
+func (d DropPolicy) String() string {
+	if d < 0 || int(d) >= len(dropPolicyNames) {
+		return "DropPolicy(" + strconv.Itoa(int(d)) + ")"
+	}
+	return dropPolicyNames[d]
+}
+
+// AsyncWriter is an io.WriteCloser that buffers the written messages in a bounded queue
+// and writes them into the underlying writer in a separate goroutine.
+// It is meant to be used as an 'out' argument of the NewBasicLogger function, so that
+// the logging methods do not wait for the slow outputs (i.e. files or network).
+// When the queue is full, the messages are handled according to the DropPolicy.
+// The number of discarded messages is available by the Dropped() method.
+type AsyncWriter struct {
+	out    io.Writer
+	queue  chan []byte
+	policy DropPolicy
+
+	dropped uint64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncWriter creates new AsyncWriter that writes into 'out' writer.
+// The 'size' argument defines the length of the message queue. If 'size' is lower
+// than one, the queue would have the length equal to one.
+// The 'policy' defines what to do when the queue is full.
+func NewAsyncWriter(out io.Writer, size int, policy DropPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	w := &AsyncWriter{
+		out:    out,
+		queue:  make(chan []byte, size),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write enqueues a copy of the 'p' message. It never returns an error for the discarded
+// messages, the error is returned only if the writer was already closed.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	// the log.Logger reuses its buffer, the message must be copied
+	msg := make([]byte, len(p))
+	copy(msg, p)
+
+	switch w.policy {
+	case DropNewest:
+		select {
+		case w.queue <- msg:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case w.queue <- msg:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				atomic.AddUint64(&w.dropped, 1)
+			default:
+			}
+		}
+	default:
+		w.queue <- msg
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of messages discarded by the writer.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close stops accepting new messages, waits until all queued messages are written
+// and closes the underlying writer if it implements io.Closer.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	for msg := range w.queue {
+		w.out.Write(msg)
+	}
+	close(w.done)
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+)
+
+// blockingWriter blocks writing until the 'release' channel is closed
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+	closed  bool
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *blockingWriter) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestAsyncWriter(t *testing.T) {
+	Convey("Subject: AsyncWriter", t, func() {
+		Convey("Having an AsyncWriter with Block policy", func() {
+			var buf bytes.Buffer
+			w := NewAsyncWriter(&buf, 10, Block)
+
+			Convey("All the written messages should be written in order after Close", func() {
+				for _, msg := range []string{"first\n", "second\n", "third\n"} {
+					n, err := w.Write([]byte(msg))
+					So(err, ShouldBeNil)
+					So(n, ShouldEqual, len(msg))
+				}
+				So(w.Close(), ShouldBeNil)
+				So(buf.String(), ShouldEqual, "first\nsecond\nthird\n")
+				So(w.Dropped(), ShouldEqual, 0)
+
+				Convey("Writing to closed writer returns an error", func() {
+					_, err := w.Write([]byte("closed"))
+					So(err, ShouldEqual, ErrWriterClosed)
+					So(w.Close(), ShouldEqual, ErrWriterClosed)
+				})
+			})
+
+			Convey("The message is copied before enqueuing", func() {
+				msg := []byte("message")
+				w.Write(msg)
+				copy(msg, "changed")
+				w.Close()
+				So(buf.String(), ShouldEqual, "message")
+			})
+		})
+
+		Convey("Having an AsyncWriter over a blocked output", func() {
+			out := &blockingWriter{release: make(chan struct{})}
+
+			Convey("DropNewest policy discards the messages written to full queue", func() {
+				w := NewAsyncWriter(out, 1, DropNewest)
+				for i := 0; i < 5; i++ {
+					w.Write([]byte{byte('0' + i)})
+				}
+				close(out.release)
+				So(w.Close(), ShouldBeNil)
+				So(out.closed, ShouldBeTrue)
+
+				// the first may be taken by the writing goroutine and the second
+				// may be stored in the queue
+				So(out.buf.Len()+int(w.Dropped()), ShouldEqual, 5)
+				So(out.buf.String(), ShouldStartWith, "0")
+			})
+
+			Convey("DropOldest policy keeps the latest message", func() {
+				w := NewAsyncWriter(out, 1, DropOldest)
+				for i := 0; i < 5; i++ {
+					w.Write([]byte{byte('0' + i)})
+				}
+				close(out.release)
+				So(w.Close(), ShouldBeNil)
+
+				So(out.buf.Len()+int(w.Dropped()), ShouldEqual, 5)
+				So(out.buf.String(), ShouldEndWith, "4")
+			})
+		})
+
+		Convey("AsyncWriter may be used as an output of the BasicLogger", func() {
+			var buf bytes.Buffer
+			w := NewAsyncWriter(&buf, 0, Block)
+			logger := NewBasicLogger(w, "", 0)
+			logger.Info("async")
+			id := logSequenceID
+			w.Close()
+
+			So(buf.String(), ShouldEqual, fmtMsg(&Message{id: id, level: INFO, args: []interface{}{"async"}}))
+		})
+
+		Convey("DropPolicy implements Stringer", func() {
+			So(Block.String(), ShouldEqual, "Block")
+			So(DropNewest.String(), ShouldEqual, "DropNewest")
+			So(DropOldest.String(), ShouldEqual, "DropOldest")
+			So(DropPolicy(7).String(), ShouldEqual, "DropPolicy(7)")
+			So(DropPolicy(-1).String(), ShouldEqual, "DropPolicy(-1)")
+		})
+	})
+}
@@ -1,10 +1,13 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
 	"sync/atomic"
 )
 
@@ -45,6 +48,52 @@ func (l Level) String() string {
 	return level_names[l]
 }
 
+// ErrUnknownLevel is returned when parsing unknown level name.
+var ErrUnknownLevel = errors.New("logger: unknown level")
+
+// ParseLevel parses the level by its name. The name is case insensitive.
+// Apart from the level names, it accepts also 'WARN', 'FATAL' and 'PRINT'.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARNING", "WARN":
+		return WARNING, nil
+	case "ERROR":
+		return ERROR, nil
+	case "CRITICAL", "FATAL":
+		return CRITICAL, nil
+	case "PRINT":
+		return PRINT, nil
+	}
+	return DEBUG, ErrUnknownLevel
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+// The PRINT level is marshaled as 'PRINT' so that it can be parsed back.
+func (l Level) MarshalText() ([]byte, error) {
+	if l < DEBUG || l > PRINT {
+		return nil, ErrUnknownLevel
+	}
+	if l == PRINT {
+		return []byte("PRINT"), nil
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+// The level is parsed using ParseLevel function.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
 /**
 
 Message
@@ -55,6 +104,7 @@ Message
 type Message struct {
 	id      uint64
 	level   Level
+	name    string
 	fmt     *string
 	message *string
 	args    []interface{}
@@ -80,9 +130,22 @@ func (m *Message) getMessage() string {
 	return *m.message
 }
 
+// sampleKey returns the key that distinguishes the messages in the Sampler.
+// For the formatted messages it is the format, otherwise the message content.
+func (m *Message) sampleKey() string {
+	if m.fmt != nil {
+		return *m.fmt
+	}
+	return m.getMessage()
+}
+
 // String returns string that concantates:
 // id hash - 4 digits|time formatted in RFC339|level|message
+// If the message was logged by the named logger, the name is added after the id.
 func (m *Message) String() string {
+	if m.name != "" {
+		return fmt.Sprintf("%s|%04x|%s: %s", m.level, m.id, m.name, m.getMessage())
+	}
 	msg := fmt.Sprintf("%s|%04x: %s", m.level, m.id, m.getMessage())
 	return msg
 }
@@ -104,9 +167,18 @@ BasicLogger
 // It allows to filter the logs by given level.
 // I.e. Having BasicLogger with level Set to WARNING, then there would be
 // no DEBUG and INFO logs (the hierarchy goes up only).
+// The number of repeated messages may be limited by setting the Sampler with
+// SetSampler() method. In order not to block on slow outputs, the BasicLogger
+// may write into the AsyncWriter, that may wrap i.e. RotatingFile.
+// The level may be changed at runtime. Named child loggers with independent levels
+// are created by the Child() method and may be controlled by the LevelRegistry.
 type BasicLogger struct {
 	stdLogger *log.Logger
-	level     Level
+	name      string
+	sampler   *Sampler
+
+	mu    sync.RWMutex
+	level Level
 }
 
 // NewBasicLogger creates new BasicLogger that shares common sequence id.
@@ -121,9 +193,47 @@ func NewBasicLogger(out io.Writer, prefix string, flags int) *BasicLogger {
 	return logger
 }
 
+// Child creates new named BasicLogger that shares the output and the current Sampler
+// with its parent. The child logger starts with the current level of the parent,
+// but its level may be changed independently.
+// If the parent is also named the child name is prefixed with the parent's name
+// i.e.: 'db.queries'.
+func (l *BasicLogger) Child(name string) *BasicLogger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &BasicLogger{
+		stdLogger: l.stdLogger,
+		name:      name,
+		sampler:   l.sampler,
+		level:     l.Level(),
+	}
+}
+
+// Name returns the name of the logger. The root logger has an empty name.
+func (l *BasicLogger) Name() string {
+	return l.name
+}
+
 // SetLevel sets the level of logging for given Logger.
+// It is safe to change the level while logging in other goroutines.
 func (l *BasicLogger) SetLevel(level Level) {
+	l.mu.Lock()
 	l.level = level
+	l.mu.Unlock()
+}
+
+// Level returns the current logging level for given Logger.
+func (l *BasicLogger) Level() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// SetSampler sets the Sampler that limits the number of repeated messages.
+// Setting nil Sampler disables sampling.
+func (l *BasicLogger) SetSampler(sampler *Sampler) {
+	l.sampler = sampler
 }
 
 // Logs a message with DEBUG level.
@@ -215,14 +325,18 @@ func (l *BasicLogger) log(level Level, format *string, args ...interface{}) {
 		return
 	}
 	msg := &Message{
-		id:    atomic.AddUint64(&logSequenceID, 1),
 		level: level,
+		name:  l.name,
 		fmt:   format,
 		args:  args,
 	}
+	if l.sampler != nil && !l.sampler.Sample(level, msg.sampleKey()) {
+		return
+	}
+	msg.id = atomic.AddUint64(&logSequenceID, 1)
 	l.stdLogger.Output(2, msg.String())
 }
 
 func (l *BasicLogger) isLevelEnabled(level Level) bool {
-	return level >= l.level
+	return level >= l.Level()
 }
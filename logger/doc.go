@@ -13,6 +13,8 @@ logging packages.
 
 There is also BasicLogger logger that implements 'LeveledLogger' interface.
 It is very simple and lightweight implementation of leveled logger.
+Its output may be limited with the Sampler and written asynchronously with the AsyncWriter
+into the RotatingFile.
 */
 
 package logger
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// LevelHandler creates the http.HandlerFunc that allows to read and change
+// the levels of the loggers registered in the 'registry' at runtime.
+// It may be mounted on any router next to the GenericHandler routes.
+//
+// Supported methods:
+//	# GET - returns the levels of all loggers as a JSON object i.e.: {"db":"DEBUG","http":"ERROR"}.
+//		The 'name' query parameter limits the result to a single logger.
+//	# PUT - sets the levels provided in the JSON object of the same form as returned by GET.
+//		The levels are parsed using ParseLevel. No level is changed if any of the names
+//		or levels is unknown.
+// Unknown logger names result in 404 status, unknown levels or malformed body in 400.
+func LevelHandler(registry *LevelRegistry) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			getLevels(registry, rw, req)
+		case http.MethodPut:
+			putLevels(registry, rw, req)
+		default:
+			rw.Header().Set("Allow", "GET, PUT")
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func getLevels(registry *LevelRegistry, rw http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("name")
+	if name == "" {
+		writeLevels(rw, registry.Levels())
+		return
+	}
+
+	level, err := registry.Level(name)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeLevels(rw, map[string]Level{name: level})
+}
+
+func putLevels(registry *LevelRegistry, rw http.ResponseWriter, req *http.Request) {
+	levels := map[string]Level{}
+	if err := json.NewDecoder(req.Body).Decode(&levels); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// check all names before changing any level
+	for name := range levels {
+		if _, err := registry.Logger(name); err != nil {
+			http.Error(rw, err.Error()+": "+name, http.StatusNotFound)
+			return
+		}
+	}
+
+	for name, level := range levels {
+		registry.SetLevel(name, level)
+	}
+	writeLevels(rw, levels)
+}
+
+func writeLevels(rw http.ResponseWriter, levels map[string]Level) {
+	marshaled, err := json.Marshal(levels)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(marshaled)
+}
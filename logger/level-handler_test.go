@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	Convey("Subject: LevelHandler", t, func() {
+		var buf bytes.Buffer
+		root := NewBasicLogger(&buf, "", 0)
+		registry := NewLevelRegistry()
+		registry.Register("root", root)
+		registry.Register("db", root.Child("db"))
+
+		handler := LevelHandler(registry)
+
+		serve := func(method, target, body string) *httptest.ResponseRecorder {
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest(method, target, strings.NewReader(body))
+			handler(rw, req)
+			return rw
+		}
+
+		Convey("GET returns the levels of all loggers", func() {
+			rw := serve("GET", "/levels", "")
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Body.String(), ShouldEqual, `{"db":"DEBUG","root":"DEBUG"}`)
+		})
+
+		Convey("GET with the name returns single level", func() {
+			rw := serve("GET", "/levels?name=db", "")
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Body.String(), ShouldEqual, `{"db":"DEBUG"}`)
+
+			rw = serve("GET", "/levels?name=unknown", "")
+			So(rw.Code, ShouldEqual, 404)
+		})
+
+		Convey("PUT changes the levels", func() {
+			rw := serve("PUT", "/levels", `{"db":"error"}`)
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Body.String(), ShouldEqual, `{"db":"ERROR"}`)
+
+			level, _ := registry.Level("db")
+			So(level, ShouldEqual, ERROR)
+			level, _ = registry.Level("root")
+			So(level, ShouldEqual, DEBUG)
+		})
+
+		Convey("PUT with unknown level or logger does not change anything", func() {
+			rw := serve("PUT", "/levels", `{"db":"verbose"}`)
+			So(rw.Code, ShouldEqual, 400)
+
+			rw = serve("PUT", "/levels", `{"db":"ERROR","unknown":"ERROR"}`)
+			So(rw.Code, ShouldEqual, 404)
+
+			So(registry.Levels(), ShouldResemble, map[string]Level{"root": DEBUG, "db": DEBUG})
+		})
+
+		Convey("Other methods are not allowed", func() {
+			rw := serve("POST", "/levels", "")
+			So(rw.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+	})
+}
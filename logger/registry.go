@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrLoggerNotFound is returned when the logger with given name is not registered.
+	ErrLoggerNotFound = errors.New("logger: logger not found")
+
+	// ErrLoggerAlreadyRegistered is returned when registering the logger with
+	// the name that is already in use.
+	ErrLoggerAlreadyRegistered = errors.New("logger: logger already registered")
+)
+
+// LevelRegistry contains named BasicLoggers, which levels may be controlled at runtime.
+// I.e. Having separate loggers for the database and http packages, the database logger
+// may be set to DEBUG level while the http logger stays at ERROR level.
+// The levels may be changed over HTTP using the handler created by LevelHandler function.
+// LevelRegistry is safe for concurrent use.
+type LevelRegistry struct {
+	mu      sync.RWMutex
+	loggers map[string]*BasicLogger
+}
+
+// NewLevelRegistry creates new empty LevelRegistry.
+func NewLevelRegistry() *LevelRegistry {
+	return &LevelRegistry{loggers: make(map[string]*BasicLogger)}
+}
+
+// Register adds the 'logger' to the registry with given 'name'.
+// If the name is already registered the function returns ErrLoggerAlreadyRegistered.
+func (r *LevelRegistry) Register(name string, logger *BasicLogger) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.loggers[name]; ok {
+		return ErrLoggerAlreadyRegistered
+	}
+	r.loggers[name] = logger
+	return nil
+}
+
+// Logger returns the logger registered with given 'name'.
+// If no logger is registered with the 'name' the function returns ErrLoggerNotFound.
+func (r *LevelRegistry) Logger(name string) (*BasicLogger, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logger, ok := r.loggers[name]
+	if !ok {
+		return nil, ErrLoggerNotFound
+	}
+	return logger, nil
+}
+
+// Names returns sorted names of the registered loggers.
+func (r *LevelRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.loggers))
+	for name := range r.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Level returns the current level of the logger registered with given 'name'.
+func (r *LevelRegistry) Level(name string) (Level, error) {
+	logger, err := r.Logger(name)
+	if err != nil {
+		return DEBUG, err
+	}
+	return logger.Level(), nil
+}
+
+// SetLevel sets the 'level' for the logger registered with given 'name'.
+func (r *LevelRegistry) SetLevel(name string, level Level) error {
+	logger, err := r.Logger(name)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	return nil
+}
+
+// Levels returns the current levels of all registered loggers mapped by their names.
+func (r *LevelRegistry) Levels() map[string]Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]Level, len(r.loggers))
+	for name, logger := range r.loggers {
+		levels[name] = logger.Level()
+	}
+	return levels
+}
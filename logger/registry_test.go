@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	Convey("Subject: ParseLevel function and Level text marshaling", t, func() {
+		Convey("Known level names are parsed case insensitive", func() {
+			for name, level := range map[string]Level{
+				"debug": DEBUG, "INFO": INFO, "Warning": WARNING, "warn": WARNING,
+				"ERROR": ERROR, "critical": CRITICAL, "fatal": CRITICAL, "print": PRINT,
+			} {
+				parsed, err := ParseLevel(name)
+				So(err, ShouldBeNil)
+				So(parsed, ShouldEqual, level)
+			}
+		})
+
+		Convey("Unknown level name returns an error", func() {
+			_, err := ParseLevel("verbose")
+			So(err, ShouldEqual, ErrUnknownLevel)
+
+			var level Level
+			So(level.UnmarshalText([]byte("verbose")), ShouldEqual, ErrUnknownLevel)
+		})
+
+		Convey("Marshaled levels can be parsed back", func() {
+			for _, level := range []Level{DEBUG, INFO, WARNING, ERROR, CRITICAL, PRINT} {
+				text, err := level.MarshalText()
+				So(err, ShouldBeNil)
+
+				var parsed Level
+				So(parsed.UnmarshalText(text), ShouldBeNil)
+				So(parsed, ShouldEqual, level)
+			}
+			_, err := Level(10).MarshalText()
+			So(err, ShouldEqual, ErrUnknownLevel)
+		})
+	})
+}
+
+func TestChildLogger(t *testing.T) {
+	Convey("Subject: Child() method for BasicLogger", t, func() {
+		var buf bytes.Buffer
+		root := NewBasicLogger(&buf, "", 0)
+		root.SetLevel(WARNING)
+
+		db := root.Child("db")
+		So(db.Name(), ShouldEqual, "db")
+		So(db.Level(), ShouldEqual, WARNING)
+		So(db.Child("queries").Name(), ShouldEqual, "db.queries")
+
+		Convey("Child level is independent from the parent", func() {
+			db.SetLevel(DEBUG)
+			So(root.Level(), ShouldEqual, WARNING)
+
+			root.Debug("root")
+			So(buf.String(), ShouldBeEmpty)
+
+			db.Debug("child")
+			So(buf.String(), ShouldEqual, fmtMsg(&Message{
+				id: logSequenceID, level: DEBUG, name: "db", args: []interface{}{"child"},
+			}))
+		})
+	})
+}
+
+func TestLevelRegistry(t *testing.T) {
+	Convey("Subject: LevelRegistry", t, func() {
+		var buf bytes.Buffer
+		root := NewBasicLogger(&buf, "", 0)
+		registry := NewLevelRegistry()
+
+		So(registry.Register("root", root), ShouldBeNil)
+		So(registry.Register("db", root.Child("db")), ShouldBeNil)
+
+		Convey("Registering the same name twice returns an error", func() {
+			So(registry.Register("db", root), ShouldEqual, ErrLoggerAlreadyRegistered)
+		})
+
+		Convey("The registered loggers may be obtained by name", func() {
+			logger, err := registry.Logger("root")
+			So(err, ShouldBeNil)
+			So(logger, ShouldEqual, root)
+
+			_, err = registry.Logger("unknown")
+			So(err, ShouldEqual, ErrLoggerNotFound)
+
+			So(registry.Names(), ShouldResemble, []string{"db", "root"})
+		})
+
+		Convey("The levels may be changed by name", func() {
+			So(registry.SetLevel("db", ERROR), ShouldBeNil)
+			level, err := registry.Level("db")
+			So(err, ShouldBeNil)
+			So(level, ShouldEqual, ERROR)
+
+			So(registry.Levels(), ShouldResemble, map[string]Level{"root": DEBUG, "db": ERROR})
+
+			So(registry.SetLevel("unknown", ERROR), ShouldEqual, ErrLoggerNotFound)
+			_, err = registry.Level("unknown")
+			So(err, ShouldEqual, ErrLoggerNotFound)
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an io.WriteCloser that writes into the file and rotates it
+// when its size exceeds given limit. The rotated files are renamed by adding
+// a numeric suffix i.e.: 'app.log.1', 'app.log.2' where the lower number is the newer file.
+// At most 'maxBackups' rotated files are kept, the older ones are removed.
+// RotatingFile may be used as an 'out' argument of the NewBasicLogger function
+// or as an output for the AsyncWriter.
+type RotatingFile struct {
+	filename   string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens or creates the file with the given 'filename' in an append mode.
+// The file is rotated when writing a message would exceed the 'maxSize' bytes.
+// If 'maxSize' is lower than one, the file is never rotated automatically.
+func NewRotatingFile(filename string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		filename:   filename,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes the 'p' into the current file. If the size of the file would exceed
+// the limit, the file is rotated before writing.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, ErrWriterClosed
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with the backup suffix
+// and opens a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return ErrWriterClosed
+	}
+	return r.rotate()
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return ErrWriterClosed
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts the backups and opens a new file. The current
+// file is reopened also if the rotation fails, so that the writer remains usable.
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err == nil {
+		err = r.shift()
+	}
+	if openErr := r.open(); err == nil {
+		err = openErr
+	}
+	return err
+}
+
+// shift removes the oldest backup and renames the others and the current file
+// to the next backup names. Without backups the current file is removed.
+func (r *RotatingFile) shift() error {
+	if r.maxBackups < 1 {
+		if err := os.Remove(r.filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	oldest := r.backupName(r.maxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(r.backupName(i), r.backupName(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.filename, r.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *RotatingFile) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", r.filename, i)
+}
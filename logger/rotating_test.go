@@ -0,0 +1,105 @@
+package logger
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	Convey("Subject: RotatingFile", t, func() {
+		dir, err := ioutil.TempDir("", "rotating")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "app.log")
+
+		readFile := func(name string) string {
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				return ""
+			}
+			return string(data)
+		}
+
+		Convey("Having a RotatingFile with size limit and two backups", func() {
+			r, err := NewRotatingFile(filename, 10, 2)
+			So(err, ShouldBeNil)
+			defer r.Close()
+
+			Convey("Writing within the limit does not rotate the file", func() {
+				r.Write([]byte("12345"))
+				r.Write([]byte("67890"))
+				So(readFile(filename), ShouldEqual, "1234567890")
+				_, err := os.Stat(filename + ".1")
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+
+			Convey("Exceeding the limit rotates the files", func() {
+				for _, msg := range []string{"first-msg", "second-msg", "third-msg", "fourth-msg"} {
+					_, err := r.Write([]byte(msg))
+					So(err, ShouldBeNil)
+				}
+				So(readFile(filename), ShouldEqual, "fourth-msg")
+				So(readFile(filename+".1"), ShouldEqual, "third-msg")
+				So(readFile(filename+".2"), ShouldEqual, "second-msg")
+
+				_, err := os.Stat(filename + ".3")
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+
+			Convey("Rotate() rotates the file on demand", func() {
+				r.Write([]byte("before"))
+				So(r.Rotate(), ShouldBeNil)
+				r.Write([]byte("after"))
+				So(readFile(filename), ShouldEqual, "after")
+				So(readFile(filename+".1"), ShouldEqual, "before")
+			})
+
+			Convey("Writing to closed file returns an error", func() {
+				So(r.Close(), ShouldBeNil)
+				_, err := r.Write([]byte("closed"))
+				So(err, ShouldEqual, ErrWriterClosed)
+				So(r.Rotate(), ShouldEqual, ErrWriterClosed)
+			})
+		})
+
+		Convey("The failed rotation reopens the current file", func() {
+			r, err := NewRotatingFile(filename, 10, 1)
+			So(err, ShouldBeNil)
+			defer r.Close()
+
+			// the not empty directory could not be removed as the oldest backup
+			So(os.MkdirAll(filepath.Join(filename+".1", "dir"), 0755), ShouldBeNil)
+
+			r.Write([]byte("before"))
+			So(r.Rotate(), ShouldNotBeNil)
+			_, err = r.Write([]byte("-"))
+			So(err, ShouldBeNil)
+			So(readFile(filename), ShouldEqual, "before-")
+
+			So(os.RemoveAll(filename+".1"), ShouldBeNil)
+			_, err = r.Write([]byte("after"))
+			So(err, ShouldBeNil)
+			So(readFile(filename), ShouldEqual, "after")
+			So(readFile(filename+".1"), ShouldEqual, "before-")
+		})
+
+		Convey("Without backups the file is truncated on rotation", func() {
+			r, err := NewRotatingFile(filename, 5, 0)
+			So(err, ShouldBeNil)
+			defer r.Close()
+
+			r.Write([]byte("12345"))
+			r.Write([]byte("678"))
+			So(readFile(filename), ShouldEqual, "678")
+		})
+
+		Convey("Opening a file in non existing directory returns an error", func() {
+			_, err := NewRotatingFile(filepath.Join(dir, "missing", "app.log"), 5, 0)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const samplerBuckets = 1024
+
+// Sampler limits the number of logged messages that share the same content.
+// Within each 'tick' period it allows the 'first' messages with the same level and
+// content, and afterwards only every 'thereafter' message.
+// I.e. Having a Sampler with first = 10 and thereafter = 100 and a failing database,
+// the logger would write first 10 equal error messages per tick and then every 100th.
+// Messages with CRITICAL level are never sampled.
+// The messages are distinguished by the format (for the formatted methods) or
+// by the message content. The counters are kept in a fixed number of buckets so
+// that the memory used by the Sampler is constant.
+type Sampler struct {
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	mu       sync.Mutex
+	counters [PRINT + 1][samplerBuckets]samplerCounter
+
+	now func() time.Time
+}
+
+type samplerCounter struct {
+	resetAt time.Time
+	count   uint64
+}
+
+// NewSampler creates new Sampler that allows 'first' messages per 'tick' and then
+// every 'thereafter' message. If 'tick' is equal to zero, the counters are never reset.
+// If 'thereafter' is lower than one, all the messages after the 'first' are dropped
+// until the end of the tick.
+func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
+	s := &Sampler{
+		tick: tick,
+		now:  time.Now,
+	}
+	if first > 0 {
+		s.first = uint64(first)
+	}
+	if thereafter > 0 {
+		s.thereafter = uint64(thereafter)
+	}
+	return s
+}
+
+// Sample checks if the message with given 'level' and 'key' should be logged.
+func (s *Sampler) Sample(level Level, key string) bool {
+	if level == CRITICAL || level < DEBUG || level > PRINT {
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter := &s.counters[level][h.Sum32()%samplerBuckets]
+	if s.tick > 0 {
+		now := s.now()
+		if !now.Before(counter.resetAt) {
+			counter.resetAt = now.Add(s.tick)
+			counter.count = 0
+		}
+	}
+	counter.count++
+
+	if counter.count <= s.first {
+		return true
+	}
+	if s.thereafter == 0 {
+		return false
+	}
+	return (counter.count-s.first)%s.thereafter == 0
+}
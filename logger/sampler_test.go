@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	Convey("Subject: Sampler", t, func() {
+		now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+		sampler := NewSampler(time.Second, 2, 3)
+		sampler.now = func() time.Time { return now }
+
+		Convey("First messages pass, and then every 'thereafter' message", func() {
+			var passed []int
+			for i := 1; i <= 11; i++ {
+				if sampler.Sample(ERROR, "key") {
+					passed = append(passed, i)
+				}
+			}
+			So(passed, ShouldResemble, []int{1, 2, 5, 8, 11})
+
+			Convey("Messages with other key or level are counted separately", func() {
+				So(sampler.Sample(ERROR, "other"), ShouldBeTrue)
+				So(sampler.Sample(WARNING, "key"), ShouldBeTrue)
+			})
+
+			Convey("CRITICAL messages are never sampled", func() {
+				for i := 0; i < 10; i++ {
+					So(sampler.Sample(CRITICAL, "key"), ShouldBeTrue)
+				}
+			})
+
+			Convey("The counters are reset after the tick", func() {
+				So(sampler.Sample(ERROR, "key"), ShouldBeFalse)
+				now = now.Add(time.Second)
+				So(sampler.Sample(ERROR, "key"), ShouldBeTrue)
+				So(sampler.Sample(ERROR, "key"), ShouldBeTrue)
+				So(sampler.Sample(ERROR, "key"), ShouldBeFalse)
+			})
+		})
+
+		Convey("Zero 'thereafter' drops all messages after the first", func() {
+			sampler := NewSampler(0, 1, 0)
+			So(sampler.Sample(INFO, "key"), ShouldBeTrue)
+			for i := 0; i < 10; i++ {
+				So(sampler.Sample(INFO, "key"), ShouldBeFalse)
+			}
+		})
+
+		Convey("Having a BasicLogger with the Sampler", func() {
+			var buf bytes.Buffer
+			logger := NewBasicLogger(&buf, "", 0)
+			logger.SetSampler(NewSampler(time.Minute, 1, 0))
+
+			for i := 0; i < 5; i++ {
+				logger.Errorf("Connection failed: %d", i)
+				logger.Error("Same message")
+			}
+			So(strings.Count(buf.String(), "\n"), ShouldEqual, 2)
+
+			Convey("Setting nil sampler disables sampling", func() {
+				logger.SetSampler(nil)
+				buf.Reset()
+				logger.Error("Same message")
+				So(buf.String(), ShouldNotBeEmpty)
+			})
+		})
+	})
+}
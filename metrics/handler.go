@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/internal/httpwriter"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The metric names of the instrumented handlers.
+const (
+	HTTPRequestsTotal   = "http_requests_total"
+	HTTPDurationSeconds = "http_request_duration_seconds"
+)
+
+// InstrumentHandler decorates the 'handler' mounted at the 'route' path with the metrics
+// registered in the 'registry'. The requests are counted by the route, method, response
+// status and the code of the first resterrors.Error written by the handlers.GenericHandler
+// (empty for the successful requests). The durations are observed by the route and method.
+func InstrumentHandler(registry *Registry, route string, handler http.HandlerFunc) http.HandlerFunc {
+	requests := registry.CounterVec(HTTPRequestsTotal,
+		"The number of the HTTP requests.", "route", "method", "status", "code")
+	duration := registry.HistogramVec(HTTPDurationSeconds,
+		"The duration of the HTTP requests in seconds.", nil, "route", "method")
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		req, collected := handlers.WithResponseErrors(req)
+		writer := httpwriter.NewStatusWriter(rw)
+
+		handler(writer, req)
+
+		var code string
+		if errs := collected.Errors(); len(errs) > 0 {
+			code = errs[0].Code
+		}
+		duration.Observe(time.Since(start).Seconds(), route, req.Method)
+		requests.Inc(route, req.Method, strconv.Itoa(writer.Status()), code)
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInstrumentHandler(t *testing.T) {
+	Convey("Subject: InstrumentHandler counts the requests by their labels", t, func() {
+		registry := NewRegistry()
+
+		Convey("The requests are labeled with the route, method and status", func() {
+			deleted := InstrumentHandler(registry, "/foos/{foo}", func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusNoContent)
+				rw.WriteHeader(http.StatusInternalServerError)
+			})
+			deleted(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/foos/1", nil))
+			deleted(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/foos/2", nil))
+
+			written := InstrumentHandler(registry, "/foos", func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte("[]"))
+			})
+			written(httptest.NewRecorder(), httptest.NewRequest("GET", "/foos", nil))
+
+			empty := InstrumentHandler(registry, "/foos", func(rw http.ResponseWriter, req *http.Request) {})
+			empty(httptest.NewRecorder(), httptest.NewRequest("HEAD", "/foos", nil))
+
+			requests := registry.CounterVec(HTTPRequestsTotal, "")
+			So(requests.Value("/foos/{foo}", "DELETE", "204", ""), ShouldEqual, 2)
+			So(requests.Value("/foos/{foo}", "DELETE", "500", ""), ShouldEqual, 0)
+			So(requests.Value("/foos", "GET", "200", ""), ShouldEqual, 1)
+			So(requests.Value("/foos", "HEAD", "200", ""), ShouldEqual, 1)
+
+			duration := registry.HistogramVec(HTTPDurationSeconds, "", nil)
+			So(duration.Count("/foos/{foo}", "DELETE"), ShouldEqual, 2)
+			So(duration.Count("/foos", "GET"), ShouldEqual, 1)
+		})
+
+		Convey("The error responses are labeled with the resterrors code", func() {
+			mock := &mockrepo.MockRepository{}
+			handler, err := handlers.New(mock, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			mock.On("Get", &Foo{}).Return(nil, dberrors.ErrNoResult.New())
+
+			get := InstrumentHandler(registry, "/foos/{foo}", handler.Get(Foo{}))
+			rw := httptest.NewRecorder()
+			get(rw, httptest.NewRequest("GET", "/foos/1", nil))
+
+			restErr, _ := errhandler.New().Handle(dberrors.ErrNoResult.New())
+			So(restErr.Code, ShouldNotBeEmpty)
+			requests := registry.CounterVec(HTTPRequestsTotal, "")
+			So(requests.Value("/foos/{foo}", "GET", "400", restErr.Code), ShouldEqual, 1)
+			So(requests.Value("/foos/{foo}", "GET", "400", ""), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,318 @@
+// Package metrics contains the instrumentation of the repositories and the handlers.
+// The metrics are collected in the Registry and exposed in the Prometheus text
+// exposition format, without the dependency on the Prometheus client libraries.
+// I.e.:
+//	registry := metrics.NewRegistry()
+//	repo := metrics.InstrumentRepository(gormRepo, registry)
+//	handler, _ := handlers.New(repo, errhandler.New(), nil, nil)
+//
+//	router.Get("/users/{user}", metrics.InstrumentHandler(registry, "/users/{user}", handler.Get(User{})))
+//	router.Get("/metrics", registry.Handler())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default upper bounds (in seconds) of the duration histograms.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry contains the metric families exposed by its Handler.
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry creates new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]family{}}
+}
+
+// CounterVec returns the counter family with given 'name', 'help' and 'labels'.
+// If the counter with given name is already registered it is returned.
+// Panics if the name is used by other metric type.
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		counter, ok := f.(*CounterVec)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is not a counter", name))
+		}
+		return counter
+	}
+	counter := &CounterVec{series: newSeries(name, help, labels)}
+	r.families[name] = counter
+	return counter
+}
+
+// HistogramVec returns the histogram family with given 'name', 'help', 'buckets' upper bounds
+// and 'labels'. If the 'buckets' are nil the DefaultBuckets are used. If the histogram
+// with given name is already registered it is returned.
+// Panics if the name is used by other metric type.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		histogram, ok := f.(*HistogramVec)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is not a histogram", name))
+		}
+		return histogram
+	}
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	histogram := &HistogramVec{series: newSeries(name, help, labels), buckets: buckets}
+	r.families[name] = histogram
+	return histogram
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// The families are sorted by their names and the series by their label values.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]family, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buf := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buf)
+	}
+	err := buf.Flush()
+	return counter.n, err
+}
+
+// Handler returns the http.HandlerFunc that responds with the registry metrics.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", ContentType)
+		r.WriteTo(rw)
+	}
+}
+
+// series is the common part of the metric families - the values of the label combinations.
+type series struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]interface{}
+}
+
+func newSeries(name, help string, labels []string) series {
+	return series{name: name, help: help, labels: labels, values: map[string]interface{}{}}
+}
+
+// value returns the value for the label 'values' created by the 'create' if not exists.
+// Must be called with the mutex locked.
+func (s *series) value(values []string, create func() interface{}) interface{} {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s requires %d label values, %d provided", s.name, len(s.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v, ok := s.values[key]
+	if !ok {
+		v = create()
+		s.values[key] = v
+	}
+	return v
+}
+
+// sortedKeys returns the keys of the values sorted. Must be called with the mutex locked.
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *series) writeHeader(w *bufio.Writer, typ string) {
+	w.WriteString("# HELP " + s.name + " " + escapeHelp(s.help) + "\n")
+	w.WriteString("# TYPE " + s.name + " " + typ + "\n")
+}
+
+// labelPairs formats the labels with the 'key' values and the 'extra' label pair.
+func (s *series) labelPairs(key string, extra ...string) string {
+	var pairs []string
+	if len(s.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, s.labels[i]+`="`+escapeLabel(value)+`"`)
+		}
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+escapeLabel(extra[1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is the family of the counters partitioned by the label values.
+type CounterVec struct {
+	series
+}
+
+// Inc increments the counter with the label 'values' by one.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds the 'delta' to the counter with the label 'values'.
+// Panics if the 'delta' is negative or the number of values does not match the labels.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter could not be decreased")
+	}
+	c.mu.Lock()
+	*c.value(values, func() interface{} { return new(float64) }).(*float64) += delta
+	c.mu.Unlock()
+}
+
+// Value returns the value of the counter with the label 'values'.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.values[strings.Join(values, "\xff")].(*float64); ok {
+		return *v
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w, "counter")
+	for _, key := range c.sortedKeys() {
+		w.WriteString(c.name + c.labelPairs(key) + " " + formatFloat(*c.values[key].(*float64)) + "\n")
+	}
+}
+
+// HistogramVec is the family of the histograms partitioned by the label values.
+type HistogramVec struct {
+	series
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds the 'v' observation to the histogram with the label 'values'.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist := h.value(values, func() interface{} {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	}).(*histogram)
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+// Count returns the number of the observations of the histogram with the label 'values'.
+func (h *HistogramVec) Count(values ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hist, ok := h.values[strings.Join(values, "\xff")].(*histogram); ok {
+		return hist.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w, "histogram")
+	for _, key := range h.sortedKeys() {
+		hist := h.values[key].(*histogram)
+		for i, bound := range h.buckets {
+			w.WriteString(h.name + "_bucket" + h.labelPairs(key, "le", formatFloat(bound)) +
+				" " + strconv.FormatUint(hist.counts[i], 10) + "\n")
+		}
+		w.WriteString(h.name + "_bucket" + h.labelPairs(key, "le", "+Inf") +
+			" " + strconv.FormatUint(hist.count, 10) + "\n")
+		w.WriteString(h.name + "_sum" + h.labelPairs(key) + " " + formatFloat(hist.sum) + "\n")
+		w.WriteString(h.name + "_count" + h.labelPairs(key) + " " + strconv.FormatUint(hist.count, 10) + "\n")
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
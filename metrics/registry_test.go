@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Subject: Registry exposes the metrics in the Prometheus text format", t, func() {
+		registry := NewRegistry()
+
+		Convey("The counters are written with their labels", func() {
+			counter := registry.CounterVec("calls_total", "The number\nof calls.", "name")
+			counter.Inc("b")
+			counter.Add(2, `a"\`)
+			So(registry.CounterVec("calls_total", ""), ShouldEqual, counter)
+			So(counter.Value("b"), ShouldEqual, 1)
+			So(counter.Value("unknown"), ShouldEqual, 0)
+
+			var buf bytes.Buffer
+			_, err := registry.WriteTo(&buf)
+			So(err, ShouldBeNil)
+			So(buf.String(), ShouldEqual, `# HELP calls_total The number\nof calls.
+# TYPE calls_total counter
+calls_total{name="a\"\\"} 2
+calls_total{name="b"} 1
+`)
+			So(func() { counter.Add(-1, "b") }, ShouldPanic)
+			So(func() { counter.Inc() }, ShouldPanic)
+		})
+
+		Convey("The histograms are written with the cumulative buckets", func() {
+			histogram := registry.HistogramVec("duration_seconds", "Duration.", []float64{1, 0.1})
+			histogram.Observe(0.05)
+			histogram.Observe(0.5)
+			histogram.Observe(2)
+			So(histogram.Count(), ShouldEqual, 3)
+
+			var buf bytes.Buffer
+			registry.WriteTo(&buf)
+			So(buf.String(), ShouldEqual, `# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.1"} 1
+duration_seconds_bucket{le="1"} 2
+duration_seconds_bucket{le="+Inf"} 3
+duration_seconds_sum 2.55
+duration_seconds_count 3
+`)
+			So(func() { registry.CounterVec("duration_seconds", "") }, ShouldPanic)
+		})
+
+		Convey("Handler serves the metrics", func() {
+			registry.CounterVec("calls_total", "Calls.").Inc()
+
+			rw := httptest.NewRecorder()
+			registry.Handler()(rw, httptest.NewRequest("GET", "/metrics", nil))
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Header().Get("Content-Type"), ShouldEqual, ContentType)
+			So(rw.Body.String(), ShouldContainSubstring, "calls_total 1\n")
+		})
+	})
+}
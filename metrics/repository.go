@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"time"
+)
+
+// The metric names of the instrumented repositories.
+const (
+	RepositoryCallsTotal      = "repository_calls_total"
+	RepositoryDurationSeconds = "repository_call_duration_seconds"
+)
+
+// Repository is the repository.Repository decorator that counts the calls and observes
+// their durations partitioned by the operation (method name) and the model name.
+// The calls are counted also by the title of the returned dberrors prototype,
+// the 'error' label of the successful calls is empty.
+type Repository struct {
+	repo     repository.Repository
+	calls    *CounterVec
+	duration *HistogramVec
+}
+
+// InstrumentRepository decorates the 'repo' with the metrics registered in the 'registry'.
+func InstrumentRepository(repo repository.Repository, registry *Registry) *Repository {
+	return &Repository{
+		repo: repo,
+		calls: registry.CounterVec(RepositoryCallsTotal,
+			"The number of the repository calls.", "operation", "model", "error"),
+		duration: registry.HistogramVec(RepositoryDurationSeconds,
+			"The duration of the repository calls in seconds.", nil, "operation", "model"),
+	}
+}
+
+// WithContext returns the instrumented repository which decorated repository is bound
+// to the 'ctx', if it is a repository.ContextBinder.
+// Implements repository.ContextBinder interface.
+func (r *Repository) WithContext(ctx context.Context) repository.Repository {
+	binder, ok := r.repo.(repository.ContextBinder)
+	if !ok {
+		return r
+	}
+	return &Repository{repo: binder.WithContext(ctx), calls: r.calls, duration: r.duration}
+}
+
+func (r *Repository) Create(req interface{}) (dbErr *dberrors.Error) {
+	defer r.observe("Create", req, time.Now(), &dbErr)
+	return r.repo.Create(req)
+}
+
+func (r *Repository) Get(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	defer r.observe("Get", req, time.Now(), &dbErr)
+	return r.repo.Get(req)
+}
+
+func (r *Repository) List(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	defer r.observe("List", req, time.Now(), &dbErr)
+	return r.repo.List(req)
+}
+
+func (r *Repository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (res interface{}, dbErr *dberrors.Error) {
+	defer r.observe("ListWithParams", req, time.Now(), &dbErr)
+	return r.repo.ListWithParams(req, params)
+}
+
+func (r *Repository) Count(req interface{}) (count int, dbErr *dberrors.Error) {
+	defer r.observe("Count", req, time.Now(), &dbErr)
+	return r.repo.Count(req)
+}
+
+func (r *Repository) Update(req interface{}) (dbErr *dberrors.Error) {
+	defer r.observe("Update", req, time.Now(), &dbErr)
+	return r.repo.Update(req)
+}
+
+func (r *Repository) Patch(req, where interface{}) (dbErr *dberrors.Error) {
+	defer r.observe("Patch", req, time.Now(), &dbErr)
+	return r.repo.Patch(req, where)
+}
+
+// PatchFields counts and observes the PatchFields call of the decorated repository.
+// The dberrors.ErrNotSupported is counted and returned for the decorated repository
+// which does not implement the repository.FieldPatcher.
+// Implements repository.FieldPatcher interface.
+func (r *Repository) PatchFields(req, where interface{}, fields []string) (dbErr *dberrors.Error) {
+	defer r.observe("PatchFields", req, time.Now(), &dbErr)
+	patcher, ok := r.repo.(repository.FieldPatcher)
+	if !ok {
+		return dberrors.ErrNotSupported.NewWithMessage("Repository could not patch the fields")
+	}
+	return patcher.PatchFields(req, where, fields)
+}
+
+// Upsert counts and observes the Upsert call of the decorated repository,
+// or of the dberrors.ErrNotSupported if it is not a repository.Upserter.
+// Implements repository.Upserter interface.
+func (r *Repository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (created bool, dbErr *dberrors.Error) {
+	defer r.observe("Upsert", req, time.Now(), &dbErr)
+	upserter, ok := r.repo.(repository.Upserter)
+	if !ok {
+		return false, dberrors.ErrNotSupported.NewWithMessage("Repository could not upsert")
+	}
+	return upserter.Upsert(req, conflictFields, updateFields)
+}
+
+func (r *Repository) Delete(req, where interface{}) (dbErr *dberrors.Error) {
+	defer r.observe("Delete", req, time.Now(), &dbErr)
+	return r.repo.Delete(req, where)
+}
+
+// observe records the call of the 'op' operation started at the 'start' which returned the 'dbErr'.
+func (r *Repository) observe(op string, req interface{}, start time.Time, dbErr **dberrors.Error) {
+	model := modelName(req)
+	r.duration.Observe(time.Since(start).Seconds(), op, model)
+	r.calls.Inc(op, model, errorTitle(*dbErr))
+}
+
+// modelName returns the struct name of the 'req' model.
+func modelName(req interface{}) string {
+	if req == nil {
+		return ""
+	}
+	return refutils.GetType(req).Name()
+}
+
+// errorTitle returns the title of the 'dbErr' prototype or an empty string for nil error.
+func errorTitle(dbErr *dberrors.Error) string {
+	if dbErr == nil {
+		return ""
+	}
+	if proto, err := dbErr.GetPrototype(); err == nil {
+		return proto.Title
+	}
+	return dbErr.Title
+}
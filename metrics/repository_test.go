@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/repository/memrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type Foo struct {
+	ID   int
+	Name string
+}
+
+func TestInstrumentation(t *testing.T) {
+	Convey("Subject: Instrumenting the repositories and handlers", t, func() {
+		registry := NewRegistry()
+		mock := &mockrepo.MockRepository{}
+		repo := InstrumentRepository(mock, registry)
+
+		mock.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1}, nil)
+		mock.On("Get", &Foo{ID: 2}).Return(nil, dberrors.ErrNoResult.New())
+
+		Convey("The repository calls are counted by the error prototypes", func() {
+			repo.Get(&Foo{ID: 1})
+			repo.Get(&Foo{ID: 2})
+			repo.Get(&Foo{ID: 2})
+
+			calls := registry.CounterVec(RepositoryCallsTotal, "")
+			So(calls.Value("Get", "Foo", ""), ShouldEqual, 1)
+			So(calls.Value("Get", "Foo", dberrors.ErrNoResult.Title), ShouldEqual, 2)
+			So(registry.HistogramVec(RepositoryDurationSeconds, "", nil).Count("Get", "Foo"), ShouldEqual, 3)
+		})
+
+		Convey("The repository without Upsert is updated with the PUT semantics", func() {
+			mem := memrepo.New()
+			handler, err := handlers.New(InstrumentRepository(mem, registry), errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			handler.WithPutSemantics(true)
+
+			rw := httptest.NewRecorder()
+			handler.Update(Foo{})(rw, httptest.NewRequest("PUT", "/items/7", strings.NewReader(`{"ID":7,"Name":"name"}`)))
+			So(rw.Code, ShouldEqual, 201)
+
+			stored, dbErr := mem.Get(&Foo{ID: 7})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &Foo{ID: 7, Name: "name"})
+			So(registry.CounterVec(RepositoryCallsTotal, "").
+				Value("Upsert", "Foo", dberrors.ErrNotSupported.Title), ShouldEqual, 1)
+		})
+
+		Convey("The handler requests are counted by the status and resterrors code", func() {
+			handler, err := handlers.New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+			get := InstrumentHandler(registry, "/foos/{foo}", handler.Get(Foo{}))
+
+			mock.On("Get", &Foo{}).Return(nil, dberrors.ErrNoResult.New())
+			rw := httptest.NewRecorder()
+			get(rw, httptest.NewRequest("GET", "/foos/1", nil))
+			So(rw.Code, ShouldEqual, 400)
+
+			restErr, _ := errhandler.New().Handle(dberrors.ErrNoResult.New())
+			requests := registry.CounterVec(HTTPRequestsTotal, "")
+			So(requests.Value("/foos/{foo}", "GET", "400", restErr.Code), ShouldEqual, 1)
+
+			create := InstrumentHandler(registry, "/foos", handler.Create(Foo{}))
+			mock.On("Create", &Foo{Name: "name"}).Return(nil)
+			create(httptest.NewRecorder(), httptest.NewRequest("POST", "/foos", strings.NewReader(`{"Name":"name"}`)))
+			So(requests.Value("/foos", "POST", "201", ""), ShouldEqual, 1)
+
+			var buf strings.Builder
+			registry.WriteTo(&buf)
+			So(buf.String(), ShouldContainSubstring, `http_requests_total{route="/foos",method="POST",status="201",code=""} 1`)
+			So(buf.String(), ShouldContainSubstring, `repository_calls_total{operation="Create",model="Foo",error=""} 1`)
+		})
+	})
+}
@@ -0,0 +1,248 @@
+package refutils
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AccessTag is the struct tag that defines the FieldAccess of the model's field
+// i.e. `rest:"readonly"`.
+const AccessTag = "rest"
+
+// FieldAccess defines how the model's field may be accessed by the API clients.
+type FieldAccess int
+
+const (
+	// AccessReadWrite is the default access - the field is readable and writable
+	AccessReadWrite FieldAccess = iota
+
+	// AccessReadOnly - the field is not writable i.e. `rest:"readonly"`
+	AccessReadOnly
+
+	// AccessWriteOnly - the field is not readable i.e. `rest:"writeonly"`
+	AccessWriteOnly
+
+	// AccessCreateOnly - the field is writable only on create i.e. `rest:"createonly"`
+	AccessCreateOnly
+
+	// AccessHidden - the field is neither readable nor writable i.e. `rest:"hidden"`
+	AccessHidden
+)
+
+// Readable checks if the field with given access may be returned to the clients.
+func (a FieldAccess) Readable() bool {
+	return a != AccessWriteOnly && a != AccessHidden
+}
+
+// Writable checks if the field with given access may be set by the clients.
+// The 'create' argument defines if the model is being created.
+func (a FieldAccess) Writable(create bool) bool {
+	switch a {
+	case AccessReadOnly, AccessHidden:
+		return false
+	case AccessCreateOnly:
+		return create
+	}
+	return true
+}
+
+// String implements fmt.Stringer interface.
+func (a FieldAccess) String() string {
+	switch a {
+	case AccessReadOnly:
+		return "readonly"
+	case AccessWriteOnly:
+		return "writeonly"
+	case AccessCreateOnly:
+		return "createonly"
+	case AccessHidden:
+		return "hidden"
+	}
+	return "readwrite"
+}
+
+// FieldAccessOf returns the FieldAccess defined by the 'rest' tag of the 'field'.
+func FieldAccessOf(field reflect.StructField) FieldAccess {
+	for _, option := range strings.Split(field.Tag.Get(AccessTag), ",") {
+		switch strings.TrimSpace(option) {
+		case "readonly":
+			return AccessReadOnly
+		case "writeonly":
+			return AccessWriteOnly
+		case "createonly":
+			return AccessCreateOnly
+		case "hidden":
+			return AccessHidden
+		}
+	}
+	return AccessReadWrite
+}
+
+// JSONField is the struct field as encoded by the encoding/json package.
+type JSONField struct {
+	// Name is the JSON member name of the field
+	Name string
+
+	// Field is the struct field. The fields of embedded structs are promoted.
+	Field reflect.StructField
+
+	// Index is the index sequence of the field within the struct, including
+	// the indexes of the embedded structs (see reflect.Value.FieldByIndex)
+	Index []int
+
+	// Access is the field access defined by the 'rest' tag
+	Access FieldAccess
+}
+
+var (
+	jsonFieldsCache  sync.Map
+	accessRulesCache sync.Map
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// JSONFields returns the fields of the struct type 't' encoded by the encoding/json package.
+// The fields of the embedded structs without the json name are promoted.
+// The result is cached for each type.
+func JSONFields(t reflect.Type) []JSONField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.([]JSONField)
+	}
+
+	fields := jsonFields(t)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// JSONFieldByName returns the JSON field of the struct type 't' with given JSON 'name'.
+// The names are matched case insensitive, as by the encoding/json package.
+func JSONFieldByName(t reflect.Type, name string) (JSONField, bool) {
+	fields := JSONFields(t)
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return JSONField{}, false
+}
+
+func jsonFields(t reflect.Type) []JSONField {
+	var fields []JSONField
+	names := map[string]bool{}
+
+	var promoted []JSONField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name = tag[:idx]
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, embedded := range jsonFields(ft) {
+					embedded.Index = append([]int{i}, embedded.Index...)
+					promoted = append(promoted, embedded)
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+		fields = append(fields, JSONField{Name: name, Field: field, Index: []int{i}, Access: FieldAccessOf(field)})
+	}
+
+	// the fields of the outer struct have precedence over the promoted ones
+	for _, field := range promoted {
+		if !names[field.Name] {
+			names[field.Name] = true
+			fields = append(fields, field)
+		}
+	}
+
+	// the promoted fields are encoded in place of their embedded struct
+	sort.SliceStable(fields, func(i, j int) bool {
+		return lessIndex(fields[i].Index, fields[j].Index)
+	})
+	return fields
+}
+
+// lessIndex checks if the field of index sequence 'a' precedes the field of index 'b'.
+func lessIndex(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// HasAccessRules checks if the type 't' or any of its nested types contains
+// the field with the access other than AccessReadWrite.
+// The types implementing json.Marshaler or encoding.TextMarshaler are not searched.
+func HasAccessRules(t reflect.Type) bool {
+	if has, ok := accessRulesCache.Load(t); ok {
+		return has.(bool)
+	}
+	has := hasAccessRules(t, map[reflect.Type]bool{})
+	accessRulesCache.Store(t, has)
+	return has
+}
+
+// IsJSONMarshaler checks if the values of type 't' are encoded with their own
+// json.Marshaler or encoding.TextMarshaler implementation.
+func IsJSONMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+func hasAccessRules(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+	if t.Kind() != reflect.Struct || visited[t] || IsJSONMarshaler(t) {
+		return false
+	}
+	visited[t] = true
+
+	for _, field := range JSONFields(t) {
+		if field.Access != AccessReadWrite || hasAccessRules(field.Field.Type, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package refutils
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type accessBase struct {
+	ID        int       `json:"id" rest:"readonly"`
+	CreatedAt time.Time `json:"created_at" rest:"readonly"`
+}
+
+type accessModel struct {
+	accessBase
+	Name     string         `json:"name"`
+	Password string         `json:"password" rest:"writeonly"`
+	Login    string         `rest:"createonly"`
+	IsAdmin  bool           `json:"is_admin" rest:"hidden"`
+	Ignored  string         `json:"-" rest:"readonly"`
+	Friends  []*accessModel `json:"friends"`
+	hidden   string
+}
+
+type accessRef struct {
+	Models map[string]accessModel
+}
+
+func TestFieldAccess(t *testing.T) {
+	Convey("Subject: FieldAccess defined by the 'rest' tag", t, func() {
+		Convey("The access is read from the tag", func() {
+			field, _ := reflect.TypeOf(accessModel{}).FieldByName("Password")
+			So(FieldAccessOf(field), ShouldEqual, AccessWriteOnly)
+
+			field, _ = reflect.TypeOf(accessModel{}).FieldByName("Name")
+			So(FieldAccessOf(field), ShouldEqual, AccessReadWrite)
+		})
+
+		Convey("Readable and Writable define the access rules", func() {
+			So(AccessReadWrite.Readable(), ShouldBeTrue)
+			So(AccessReadWrite.Writable(false), ShouldBeTrue)
+
+			So(AccessReadOnly.Readable(), ShouldBeTrue)
+			So(AccessReadOnly.Writable(true), ShouldBeFalse)
+
+			So(AccessWriteOnly.Readable(), ShouldBeFalse)
+			So(AccessWriteOnly.Writable(false), ShouldBeTrue)
+
+			So(AccessCreateOnly.Readable(), ShouldBeTrue)
+			So(AccessCreateOnly.Writable(true), ShouldBeTrue)
+			So(AccessCreateOnly.Writable(false), ShouldBeFalse)
+
+			So(AccessHidden.Readable(), ShouldBeFalse)
+			So(AccessHidden.Writable(true), ShouldBeFalse)
+		})
+
+		Convey("JSONFields returns the fields as encoded by encoding/json", func() {
+			fields := JSONFields(reflect.TypeOf(&accessModel{}))
+			names := map[string]FieldAccess{}
+			for _, field := range fields {
+				names[field.Name] = field.Access
+			}
+			So(names, ShouldResemble, map[string]FieldAccess{
+				"id": AccessReadOnly, "created_at": AccessReadOnly, "name": AccessReadWrite,
+				"password": AccessWriteOnly, "Login": AccessCreateOnly, "is_admin": AccessHidden,
+				"friends": AccessReadWrite,
+			})
+
+			field, ok := JSONFieldByName(reflect.TypeOf(accessModel{}), "login")
+			So(ok, ShouldBeTrue)
+			So(field.Field.Name, ShouldEqual, "Login")
+		})
+
+		Convey("HasAccessRules searches the nested types", func() {
+			So(HasAccessRules(reflect.TypeOf(accessModel{})), ShouldBeTrue)
+			So(HasAccessRules(reflect.TypeOf([]*accessRef{})), ShouldBeTrue)
+			So(HasAccessRules(reflect.TypeOf(Foo{})), ShouldBeFalse)
+			So(HasAccessRules(reflect.TypeOf(time.Time{})), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,276 @@
+package cacherepo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheRepository is the repository decorator that caches the results of the Get, List
+// and ListWithParams calls. The results are cached by the model type and the query fields
+// (and the list parameters). The results are encoded with the 'encoding/gob' package,
+// so that the models should not contain the interface fields of not registered types.
+// The results containing the pointers to the zero values, which the gob decodes as nil,
+// are not cached.
+//
+// The Create, Update, Patch, PatchFields, Upsert and Delete calls invalidate all the cached
+// results of the model type. The invalidation changes the model's generation stored in the
+// Cache, which is the part of the result keys, so that the remote caches do not need
+// to remove the keys by their prefix - the results of the previous generations expire.
+// The Cache which is the PrefixDeleter (i.e. the LRU) has the results of the previous
+// generation removed, so that they are not stored endlessly without the TTL.
+type CacheRepository struct {
+	repo  repository.Repository
+	cache Cache
+
+	// TTL - the time after which the cached result expires. If zero the results
+	// expire only when they are invalidated or evicted.
+	TTL time.Duration
+}
+
+// New creates the CacheRepository that caches the results of the 'repo' in the 'cache'
+// for the 'ttl' duration.
+func New(repo repository.Repository, cache Cache, ttl time.Duration) (*CacheRepository, error) {
+	if repo == nil || cache == nil {
+		return nil, errors.New("Nil pointer as an argument provided.")
+	}
+	return &CacheRepository{repo: repo, cache: cache, TTL: ttl}, nil
+}
+
+// WithContext returns the CacheRepository which decorated repository is bound to the 'ctx',
+// if it is a repository.ContextBinder.
+// Implements repository.ContextBinder interface.
+func (c *CacheRepository) WithContext(ctx context.Context) repository.Repository {
+	binder, ok := c.repo.(repository.ContextBinder)
+	if !ok {
+		return c
+	}
+	return &CacheRepository{repo: binder.WithContext(ctx), cache: c.cache, TTL: c.TTL}
+}
+
+func (c *CacheRepository) Create(req interface{}) *dberrors.Error {
+	defer c.invalidate(req)
+	return c.repo.Create(req)
+}
+
+// Get returns the cached result for the 'req' or gets it from the decorated repository.
+func (c *CacheRepository) Get(req interface{}) (interface{}, *dberrors.Error) {
+	key, cacheable := c.key(req, "get", nil)
+	if cacheable {
+		res := refutils.ObjOfPtrType(req)
+		if c.load(key, res) {
+			return res, nil
+		}
+	}
+
+	res, dbErr := c.repo.Get(req)
+	if dbErr == nil && cacheable {
+		c.store(req, key, res)
+	}
+	return res, dbErr
+}
+
+// List returns the cached result for the 'req' or lists it using the decorated repository.
+func (c *CacheRepository) List(req interface{}) (interface{}, *dberrors.Error) {
+	return c.list(req, nil, func() (interface{}, *dberrors.Error) {
+		return c.repo.List(req)
+	})
+}
+
+// ListWithParams returns the cached result for the 'req' and 'params' or lists it using
+// the decorated repository.
+func (c *CacheRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	return c.list(req, params, func() (interface{}, *dberrors.Error) {
+		return c.repo.ListWithParams(req, params)
+	})
+}
+
+func (c *CacheRepository) Count(req interface{}) (int, *dberrors.Error) {
+	return c.repo.Count(req)
+}
+
+func (c *CacheRepository) Update(req interface{}) *dberrors.Error {
+	defer c.invalidate(req)
+	return c.repo.Update(req)
+}
+
+func (c *CacheRepository) Patch(req, where interface{}) *dberrors.Error {
+	defer c.invalidate(req)
+	return c.repo.Patch(req, where)
+}
+
+// PatchFields patches the 'fields' using the decorated repository and invalidates
+// the cached results. The cache is left untouched and the dberrors.ErrNotSupported
+// is returned if the decorated repository could not patch the fields.
+// Implements repository.FieldPatcher interface.
+func (c *CacheRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	patcher, ok := c.repo.(repository.FieldPatcher)
+	if !ok {
+		return dberrors.ErrNotSupported.New()
+	}
+	defer c.invalidate(req)
+	return patcher.PatchFields(req, where, fields)
+}
+
+// Upsert upserts the 'req' using the decorated repository and invalidates the cached
+// results. For the decorated repository which is not a repository.Upserter
+// the dberrors.ErrNotSupported is returned, so that the caller may fall back to the Update.
+// Implements repository.Upserter interface.
+func (c *CacheRepository) Upsert(req interface{}, conflictFields, updateFields []string) (bool, *dberrors.Error) {
+	upserter, ok := c.repo.(repository.Upserter)
+	if !ok {
+		return false, dberrors.ErrNotSupported.New()
+	}
+	defer c.invalidate(req)
+	return upserter.Upsert(req, conflictFields, updateFields)
+}
+
+func (c *CacheRepository) Delete(req, where interface{}) *dberrors.Error {
+	defer c.invalidate(req)
+	return c.repo.Delete(req, where)
+}
+
+// Invalidate removes the cached results of the 'model' type.
+// It should be used if the records are changed without the CacheRepository.
+func (c *CacheRepository) Invalidate(model interface{}) {
+	c.invalidate(model)
+}
+
+func (c *CacheRepository) list(
+	req interface{},
+	params *repository.ListParameters,
+	list func() (interface{}, *dberrors.Error),
+) (interface{}, *dberrors.Error) {
+	key, cacheable := c.key(req, "list", params)
+	if cacheable {
+		res := refutils.PtrSliceOfPtrType(req)
+		if c.load(key, res) {
+			// the empty slices are decoded as nil
+			slice := reflect.ValueOf(res).Elem()
+			if slice.IsNil() {
+				slice = reflect.MakeSlice(slice.Type(), 0, 0)
+			}
+			return slice.Interface(), nil
+		}
+	}
+
+	res, dbErr := list()
+	if dbErr == nil && cacheable {
+		c.store(req, key, res)
+	}
+	return res, dbErr
+}
+
+// key returns the cache key of the 'op' result for the 'req' and 'params'.
+// Returns false if the query could not be encoded.
+func (c *CacheRepository) key(req interface{}, op string, params *repository.ListParameters) (string, bool) {
+	generation, ok := c.generation(req)
+	if !ok {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if !writeKey(&buf, reflect.ValueOf(req), 0) {
+		return "", false
+	}
+	if params != nil && !writeKey(&buf, reflect.ValueOf(params), 0) {
+		return "", false
+	}
+	hash := sha1.Sum(buf.Bytes())
+	return modelKey(req) + ":" + generation + ":" + op + ":" + hex.EncodeToString(hash[:]), true
+}
+
+// generation returns the current generation of the 'model' type cached results.
+func (c *CacheRepository) generation(model interface{}) (string, bool) {
+	key := modelKey(model) + ":generation"
+	value, ok, err := c.cache.Get(key)
+	if err != nil {
+		return "", false
+	}
+	if ok {
+		return string(value), true
+	}
+
+	generation := newGeneration()
+	if err = c.cache.Set(key, []byte(generation), 0); err != nil {
+		return "", false
+	}
+	return generation, true
+}
+
+// invalidate changes the generation of the 'model' type, so that its cached results are not used.
+// The results of the previous generation are removed from the PrefixDeleter cache.
+func (c *CacheRepository) invalidate(model interface{}) {
+	key := modelKey(model) + ":generation"
+	previous, ok, _ := c.cache.Get(key)
+	if err := c.cache.Set(key, []byte(newGeneration()), 0); err != nil {
+		// the generation could not be changed, remove it so that the new one is created
+		c.cache.Delete(key)
+	}
+	if deleter, isDeleter := c.cache.(PrefixDeleter); isDeleter && ok {
+		deleter.DeletePrefix(modelKey(model) + ":" + string(previous) + ":")
+	}
+}
+
+// load decodes the cached value into the 'res' pointer. Returns false on cache miss.
+func (c *CacheRepository) load(key string, res interface{}) bool {
+	value, ok, err := c.cache.Get(key)
+	if err != nil || !ok {
+		return false
+	}
+	return gob.NewDecoder(bytes.NewReader(value)).Decode(res) == nil
+}
+
+// store caches the 'res' of the 'req' at the 'key'. The 'res' which would not be decoded
+// as it is is not stored.
+func (c *CacheRepository) store(req interface{}, key string, res interface{}) {
+	if !gobLossless(reflect.ValueOf(res), 0) {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(res); err != nil {
+		return
+	}
+	if err := c.cache.Set(key, buf.Bytes(), c.TTL); err != nil {
+		return
+	}
+
+	// the result stored while its generation was invalidated would not be removed
+	// from the PrefixDeleter cache
+	if _, ok := c.cache.(PrefixDeleter); ok {
+		generation, ok, err := c.cache.Get(modelKey(req) + ":generation")
+		if err != nil || !ok || !strings.HasPrefix(key, modelKey(req)+":"+string(generation)+":") {
+			c.cache.Delete(key)
+		}
+	}
+}
+
+// modelKey returns the key prefix of the model type.
+func modelKey(model interface{}) string {
+	t := refutils.GetType(model)
+	return "cacherepo:" + t.PkgPath() + "." + t.Name()
+}
+
+// newGeneration returns the random generation, so that the application instances sharing
+// the Cache do not create the same generations.
+func newGeneration() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b[:])
+}
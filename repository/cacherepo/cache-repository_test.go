@@ -0,0 +1,261 @@
+package cacherepo
+
+import (
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/memrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/repository/repotest"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+type Foo struct {
+	ID   uint
+	Name string
+}
+
+type Bar struct {
+	ID uint
+}
+
+type Flag struct {
+	ID     uint
+	Active *bool
+}
+
+// failingCache is the remote cache which is not available
+type failingCache struct{}
+
+func (failingCache) Get(string) ([]byte, bool, error)        { return nil, false, errors.New("unavailable") }
+func (failingCache) Set(string, []byte, time.Duration) error { return errors.New("unavailable") }
+func (failingCache) Delete(...string) error                  { return errors.New("unavailable") }
+
+func TestLRU(t *testing.T) {
+	Convey("Subject: LRU in-memory cache", t, func() {
+		lru := NewLRU(2)
+		now := time.Now()
+		lru.now = func() time.Time { return now }
+
+		Convey("The least recently used values are evicted", func() {
+			So(lru.Set("a", []byte("1"), 0), ShouldBeNil)
+			lru.Set("b", []byte("2"), 0)
+			lru.Get("a")
+			lru.Set("c", []byte("3"), 0)
+
+			So(lru.Len(), ShouldEqual, 2)
+			_, ok, _ := lru.Get("b")
+			So(ok, ShouldBeFalse)
+			value, ok, err := lru.Get("a")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "1")
+		})
+
+		Convey("The values expire after their TTL", func() {
+			lru.Set("a", []byte("1"), time.Minute)
+			now = now.Add(time.Minute)
+			_, ok, _ := lru.Get("a")
+			So(ok, ShouldBeFalse)
+			So(lru.Len(), ShouldEqual, 0)
+		})
+
+		Convey("The values are deleted by the key prefix", func() {
+			lru.Set("a:1", []byte("1"), 0)
+			lru.Set("b:1", []byte("2"), 0)
+			So(lru.DeletePrefix("a:"), ShouldBeNil)
+			_, ok, _ := lru.Get("a:1")
+			So(ok, ShouldBeFalse)
+			_, ok, _ = lru.Get("b:1")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("The values are deleted", func() {
+			lru.Set("a", []byte("1"), 0)
+			So(lru.Delete("a", "unknown"), ShouldBeNil)
+			_, ok, _ := lru.Get("a")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestCacheRepository(t *testing.T) {
+	Convey("Subject: CacheRepository caches the query results", t, func() {
+		mock := &mockrepo.MockRepository{}
+		repo, err := New(mock, NewLRU(100), time.Minute)
+		So(err, ShouldBeNil)
+
+		_, err = New(mock, nil, 0)
+		So(err, ShouldNotBeNil)
+
+		mock.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "first"}, nil)
+		mock.On("List", &Foo{}).Return([]*Foo{{ID: 1}, {ID: 2}}, nil)
+
+		Convey("The Get results are cached by the query fields", func() {
+			for i := 0; i < 3; i++ {
+				res, dbErr := repo.Get(&Foo{ID: 1})
+				So(dbErr, ShouldBeNil)
+				So(res, ShouldResemble, &Foo{ID: 1, Name: "first"})
+			}
+			mock.AssertNumberOfCalls(t, "Get", 1)
+
+			mock.On("Get", &Foo{ID: 2}).Return(nil, dberrors.ErrNoResult.New())
+			_, dbErr := repo.Get(&Foo{ID: 2})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+			_, dbErr = repo.Get(&Foo{ID: 2})
+			So(dbErr, ShouldNotBeNil)
+			mock.AssertNumberOfCalls(t, "Get", 3)
+		})
+
+		Convey("The List results are cached with the list parameters", func() {
+			res, _ := repo.List(&Foo{})
+			res, _ = repo.List(&Foo{})
+			So(res, ShouldResemble, []*Foo{{ID: 1}, {ID: 2}})
+			mock.AssertNumberOfCalls(t, "List", 1)
+
+			params := &repository.ListParameters{Limit: 1}
+			mock.On("ListWithParams", &Foo{}, params).Return([]*Foo{{ID: 1}}, nil)
+			repo.ListWithParams(&Foo{}, params)
+			res, _ = repo.ListWithParams(&Foo{}, params)
+			So(res, ShouldResemble, []*Foo{{ID: 1}})
+			mock.AssertNumberOfCalls(t, "ListWithParams", 1)
+
+			mock.On("List", &Bar{}).Return([]*Bar{}, nil)
+			repo.List(&Bar{})
+			res, _ = repo.List(&Bar{})
+			So(res, ShouldResemble, []*Bar{})
+		})
+
+		Convey("The writes invalidate the results of the model type", func() {
+			mock.On("List", &Bar{}).Return([]*Bar{}, nil)
+			mock.On("Patch", &Foo{Name: "patched"}, &Foo{ID: 1}).Return(nil)
+
+			repo.Get(&Foo{ID: 1})
+			repo.List(&Bar{})
+			So(repo.Patch(&Foo{Name: "patched"}, &Foo{ID: 1}), ShouldBeNil)
+
+			repo.Get(&Foo{ID: 1})
+			mock.AssertNumberOfCalls(t, "Get", 2)
+			repo.List(&Bar{})
+			mock.AssertNumberOfCalls(t, "List", 1)
+
+			repo.Invalidate(&Foo{})
+			repo.Get(&Foo{ID: 1})
+			mock.AssertNumberOfCalls(t, "Get", 3)
+		})
+
+		Convey("The optional methods of the plain repository are not supported", func() {
+			plain, err := New(struct{ repository.Repository }{mock}, NewLRU(10), 0)
+			So(err, ShouldBeNil)
+
+			plain.Get(&Foo{ID: 1})
+			_, dbErr := plain.Upsert(&Foo{ID: 1}, nil, nil)
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+			dbErr = plain.PatchFields(&Foo{}, &Foo{ID: 1}, []string{"Name"})
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+
+			// the not supported calls do not invalidate the cached results
+			plain.Get(&Foo{ID: 1})
+			mock.AssertNumberOfCalls(t, "Get", 1)
+		})
+
+		Convey("The unavailable cache does not break the repository", func() {
+			repo, err = New(mock, failingCache{}, 0)
+			So(err, ShouldBeNil)
+			mock.On("Delete", &Foo{}, &Foo{ID: 1}).Return(nil)
+
+			res, dbErr := repo.Get(&Foo{ID: 1})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldResemble, &Foo{ID: 1, Name: "first"})
+			So(repo.Delete(&Foo{}, &Foo{ID: 1}), ShouldBeNil)
+		})
+	})
+}
+
+func TestCacheRepositoryCopies(t *testing.T) {
+	Convey("Subject: CacheRepository returns the copies of the stored records", t, func() {
+		repo, err := New(memrepo.New(), NewLRU(10), 0)
+		So(err, ShouldBeNil)
+
+		foo := &Foo{Name: "name"}
+		So(repo.Create(foo), ShouldBeNil)
+		res, _ := repo.Get(&Foo{ID: foo.ID})
+		So(res.(*Foo).Name, ShouldEqual, "name")
+
+		// the cached result is a copy
+		res.(*Foo).Name = "changed"
+		res, _ = repo.Get(&Foo{ID: foo.ID})
+		So(res.(*Foo).Name, ShouldEqual, "name")
+
+		So(repo.Update(&Foo{ID: foo.ID, Name: "updated"}), ShouldBeNil)
+		res, _ = repo.Get(&Foo{ID: foo.ID})
+		So(res.(*Foo).Name, ShouldEqual, "updated")
+	})
+}
+
+func TestCacheRepositoryGenerations(t *testing.T) {
+	Convey("Subject: CacheRepository invalidates the results by the model generations", t, func() {
+		lru := NewLRU(0)
+		repo, err := New(memrepo.New(), lru, 0)
+		So(err, ShouldBeNil)
+
+		Convey("The results of the invalidated generations are removed from the LRU", func() {
+			foo := &Foo{Name: "name"}
+			So(repo.Create(foo), ShouldBeNil)
+			for i := 0; i < 10; i++ {
+				repo.Get(&Foo{ID: foo.ID})
+				repo.List(&Foo{})
+				So(repo.Update(&Foo{ID: foo.ID, Name: "updated"}), ShouldBeNil)
+			}
+			// the generation of the Foo
+			So(lru.Len(), ShouldEqual, 1)
+
+			res, _ := repo.Get(&Foo{ID: foo.ID})
+			So(res.(*Foo).Name, ShouldEqual, "updated")
+			So(lru.Len(), ShouldEqual, 2)
+		})
+
+		Convey("The generations are not repeated by the instances sharing the cache", func() {
+			generations := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				generations[newGeneration()] = true
+			}
+			So(generations, ShouldHaveLength, 100)
+		})
+	})
+}
+
+func TestCacheRepositoryPointerQueries(t *testing.T) {
+	Convey("Subject: CacheRepository distinguishes the nil pointers from the pointers to zero values", t, func() {
+		repo, err := New(memrepo.New(), NewLRU(10), 0)
+		So(err, ShouldBeNil)
+
+		active, inactive := true, false
+		So(repo.Create(&Flag{Active: &active}), ShouldBeNil)
+		So(repo.Create(&Flag{Active: &inactive}), ShouldBeNil)
+
+		res, dbErr := repo.List(&Flag{})
+		So(dbErr, ShouldBeNil)
+		So(res, ShouldHaveLength, 2)
+
+		for i := 0; i < 2; i++ {
+			res, dbErr = repo.List(&Flag{Active: &inactive})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldHaveLength, 1)
+			So(res.([]*Flag)[0].Active, ShouldNotBeNil)
+			So(*res.([]*Flag)[0].Active, ShouldBeFalse)
+		}
+	})
+}
+
+func TestCacheRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, models ...interface{}) repository.Repository {
+		repo, err := New(memrepo.New(), NewLRU(100), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
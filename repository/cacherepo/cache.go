@@ -0,0 +1,143 @@
+package cacherepo
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the encoded repository results. It may be implemented by the remote caches
+// i.e. redis or memcached clients, so that the cache is shared by many application instances.
+// The errors returned by the Cache are not returned by the CacheRepository - the failed
+// reads are treated as misses.
+type Cache interface {
+	// Get returns the value stored at the 'key'. If the key is not stored or has expired
+	// the 'ok' is false.
+	Get(key string) (value []byte, ok bool, err error)
+
+	// Set stores the 'value' at the 'key'. If the 'ttl' is greater than zero the value
+	// expires after the 'ttl' duration.
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the 'keys' from the cache.
+	Delete(keys ...string) error
+}
+
+// PrefixDeleter is the Cache which may remove the values by the prefix of their keys.
+// The CacheRepository removes the results of the invalidated generations from such cache.
+type PrefixDeleter interface {
+	DeletePrefix(prefix string) error
+}
+
+// LRU is the in-memory Cache which evicts the least recently used values
+// when its capacity is exceeded. LRU is safe for concurrent use.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU creates the LRU cache which stores at most 'capacity' values.
+// If the 'capacity' is not greater than zero the number of values is not limited - the results
+// of the invalidated generations are removed by the CacheRepository (see PrefixDeleter).
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored at the 'key' and marks it as recently used.
+// Implements Cache interface.
+func (l *LRU) Get(key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !l.now().Before(entry.expires) {
+		l.remove(elem)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores the 'value' at the 'key' and evicts the least recently used values
+// if the capacity is exceeded. Implements Cache interface.
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(elem)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.capacity > 0 && l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the 'keys' from the cache. Implements Cache interface.
+func (l *LRU) Delete(keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			l.remove(elem)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix removes the values which keys start with the 'prefix'.
+// Implements PrefixDeleter interface.
+func (l *LRU) DeletePrefix(prefix string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, elem := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of the stored values, including the expired ones not yet removed.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,173 @@
+package cacherepo
+
+import (
+	"bytes"
+	"encoding"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// maxKeyDepth limits the depth of the encoded query values, so that the cyclic
+// values are not encoded endlessly.
+const maxKeyDepth = 32
+
+// writeKey writes the query 'v' into the 'buf', so that the queries are written equally
+// only if they select the same records. Unlike the 'encoding/gob' the nil pointers
+// are distinguished from the pointers to the zero values, as i.e. the query with the
+// '*bool' field set to false selects other records than the query without it.
+// Returns false if the 'v' could not be written.
+func writeKey(buf *bytes.Buffer, v reflect.Value, depth int) bool {
+	if depth > maxKeyDepth {
+		return false
+	}
+	if !v.IsValid() {
+		buf.WriteString("nil;")
+		return true
+	}
+
+	if v.Kind() == reflect.Struct && v.CanInterface() {
+		if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+			if err != nil {
+				return false
+			}
+			buf.WriteString(strconv.Quote(string(text)) + ";")
+			return true
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteString("nil;")
+			return true
+		}
+		buf.WriteByte('&')
+		return writeKey(buf, v.Elem(), depth+1)
+	case reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("nil;")
+			return true
+		}
+		buf.WriteString("(" + v.Elem().Type().String() + ")")
+		return writeKey(buf, v.Elem(), depth+1)
+	case reflect.Struct:
+		t := v.Type()
+		buf.WriteByte('{')
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			buf.WriteString(t.Field(i).Name + ":")
+			if !writeKey(buf, v.Field(i), depth+1) {
+				return false
+			}
+		}
+		buf.WriteString("};")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("nil;")
+			return true
+		}
+		buf.WriteString("[" + strconv.Itoa(v.Len()) + ":")
+		for i := 0; i < v.Len(); i++ {
+			if !writeKey(buf, v.Index(i), depth+1) {
+				return false
+			}
+		}
+		buf.WriteString("];")
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("nil;")
+			return true
+		}
+		// the map entries are written in the order of their written keys
+		entries := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			var entry bytes.Buffer
+			if !writeKey(&entry, key, depth+1) || !writeKey(&entry, v.MapIndex(key), depth+1) {
+				return false
+			}
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		buf.WriteString("map[" + strconv.Itoa(len(entries)) + ":")
+		for _, entry := range entries {
+			buf.WriteString(entry)
+		}
+		buf.WriteString("];")
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()) + ";")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10) + ";")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10) + ";")
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64) + ";")
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		buf.WriteString(strconv.FormatFloat(real(c), 'g', -1, 64) + "," +
+			strconv.FormatFloat(imag(c), 'g', -1, 64) + ";")
+	case reflect.String:
+		buf.WriteString(strconv.Quote(v.String()) + ";")
+	default:
+		// channels, functions and unsafe pointers do not define the query
+		return false
+	}
+	return true
+}
+
+// gobLossless checks if the 'v' is decoded from the 'encoding/gob' as it was encoded.
+// The gob does not send the zero values, thus the non-nil pointer to the zero value
+// of the basic type (i.e. the '*bool' set to false) is decoded as nil.
+func gobLossless(v reflect.Value, depth int) bool {
+	if depth > maxKeyDepth {
+		return false
+	}
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+		elem := v.Elem()
+		if elem.Kind() != reflect.Struct && elem.Kind() != reflect.Ptr && isZero(elem) {
+			return false
+		}
+		return gobLossless(elem, depth+1)
+	case reflect.Interface:
+		return v.IsNil() || gobLossless(v.Elem(), depth+1)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" && !gobLossless(v.Field(i), depth+1) {
+				return false
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !gobLossless(v.Index(i), depth+1) {
+				return false
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if !gobLossless(key, depth+1) || !gobLossless(v.MapIndex(key), depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isZero checks if the 'v' of the basic, slice or map type is the zero (or empty) value.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+)
+
+// ContextBinder is the Repository extension that binds the context to the repository calls.
+// The handlers.GenericHandler binds the request context, so that the repository could
+// use the request scoped values i.e. to select the database or cancel the query.
+type ContextBinder interface {
+	// WithContext returns the Repository that uses the 'ctx' for its calls.
+	WithContext(ctx context.Context) Repository
+}
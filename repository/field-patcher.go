@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+)
+
+// FieldPatcher is the Repository extension that patches the explicitly selected fields.
+// Unlike the Patch method, the zero values and nulls of the selected fields are also stored,
+// so it is used for the JSON Merge Patch and JSON Patch requests.
+// The repository decorators implement the FieldPatcher regardless of the decorated
+// repository and return the dberrors.ErrNotSupported if it is not a FieldPatcher.
+type FieldPatcher interface {
+	// PatchFields updates the 'fields' (struct field names) of the 'req' object
+	// in the records selected by the 'where' object.
+	// If no fields are provided the records are not changed.
+	PatchFields(req, where interface{}, fields []string) (err *dberrors.Error)
+}
@@ -1,6 +1,8 @@
 package gormrepo
 
 import (
+	"bytes"
+	"database/sql"
 	"errors"
 	"github.com/jinzhu/gorm"
 	"github.com/kucjac/go-rest-sdk/dberrors"
@@ -8,6 +10,7 @@ import (
 	"github.com/kucjac/go-rest-sdk/refutils"
 	"github.com/kucjac/go-rest-sdk/repository"
 	"reflect"
+	"strings"
 )
 
 // GORMRepository is an implementation of Repository interface for 'jinzhu/gorm' package.
@@ -71,6 +74,9 @@ func (g *GORMRepository) List(
 	return reflect.ValueOf(res).Elem().Interface(), nil
 }
 
+// ListWithParams lists the 'req' models using the 'params'. The params IDs and Keys are
+// the primary key values of the models - gorm queries them using the model's
+// primary key field, which may be of numeric or string (i.e. UUID) type.
 func (g *GORMRepository) ListWithParams(
 	req interface{}, params *repository.ListParameters,
 ) (res interface{}, dberr *dberrors.Error) {
@@ -78,7 +84,7 @@ func (g *GORMRepository) ListWithParams(
 		return g.List(req)
 	}
 
-	if params.Offset == 0 && params.Limit == 0 && params.Order == "" && len(params.IDs) == 0 {
+	if !params.ContainsParameters() {
 		return g.List(req)
 	}
 	if params.Limit == 0 {
@@ -88,12 +94,17 @@ func (g *GORMRepository) ListWithParams(
 	res = refutils.PtrSliceOfPtrType(req)
 
 	var err error
-	if len(params.IDs) > 0 {
+	if len(params.IDs) > 0 || len(params.Keys) > 0 {
+		// the numeric IDs are queried as they are, unless the string keys are provided too
+		var keys interface{} = params.IDs
+		if len(params.Keys) > 0 {
+			keys = params.PrimaryKeys()
+		}
 		err = g.db.
 			Offset(params.Offset).
 			Limit(params.Limit).
 			Order(params.Order).
-			Where(params.IDs).
+			Where(keys).
 			Find(res, req).
 			Error
 	} else {
@@ -111,8 +122,10 @@ func (g *GORMRepository) ListWithParams(
 	return reflect.ValueOf(res).Elem().Interface(), nil
 }
 
+// Count returns the number of records matching the non-zero fields of the 'req' object.
+// The 'req' fields are used as the query conditions, as in the List method.
 func (g *GORMRepository) Count(req interface{}) (count int, dberr *dberrors.Error) {
-	err := g.db.Model(req).Count(&count).Error
+	err := g.db.Model(req).Where(req).Count(&count).Error
 	if err != nil {
 		return count, g.converter.Convert(err)
 	}
@@ -127,30 +140,268 @@ func (g *GORMRepository) Update(req interface{}) (dberr *dberrors.Error) {
 	return nil
 }
 
+// Patch updates the non-zero fields of the 'req' object in the records selected by the 'where'
+// object. The nil 'where' patches all the records of the model's table. If no record was
+// patched the ErrNoResult is returned.
 func (g *GORMRepository) Patch(req, where interface{}) (dberr *dberrors.Error) {
-	var db *gorm.DB
-	db = g.db.Model(req).Where(where).Update(req)
-	err := db.Error
-	rows := db.RowsAffected
-	if rows == 0 && err == nil {
-		err = dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	db := g.where(where).Model(req).Update(req)
+	if db.Error != nil {
+		return g.converter.Convert(db.Error)
 	}
-	if err != nil {
-		return g.converter.Convert(err)
+	if db.RowsAffected == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+	return nil
+}
+
+// PatchFields updates the 'fields' of the 'req' object in the records selected
+// by the 'where' object, or all the records if it is nil. The zero values and nil pointers
+// of the fields are stored as well.
+// Implements repository.FieldPatcher interface.
+func (g *GORMRepository) PatchFields(req, where interface{}, fields []string) (dberr *dberrors.Error) {
+	scope := g.db.NewScope(req)
+	values := map[string]interface{}{}
+	for _, name := range fields {
+		field, ok := scope.FieldByName(name)
+		if !ok || field.IsIgnored || field.Relationship != nil {
+			return dberrors.ErrUnspecifiedError.NewWithMessage("Field: '" + name + "' cannot be patched")
+		}
+		values[field.DBName] = field.Field.Interface()
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	db := g.where(where).Model(req).Updates(values)
+	if db.Error != nil {
+		return g.converter.Convert(db.Error)
+	}
+	if db.RowsAffected == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
 	}
 	return nil
 }
 
+// Upsert inserts the 'req' object or updates the 'updateFields' of the stored record that
+// conflicts with it on the 'conflictFields'. The statement uses the 'ON CONFLICT' clause
+// for the postgres and sqlite3 dialects and the 'ON DUPLICATE KEY UPDATE' for mysql, which
+// checks all the unique keys of the table. The 'req' is set to the stored record.
+// The 'created' result is reported by the statement itself - by the 'RETURNING (xmax = 0)'
+// clause for postgres and by the number of affected rows for mysql (which requires the
+// connection without the 'clientFoundRows' option). For sqlite3 the conflicting record is
+// counted before the statement, within the transaction that serializes the writes.
+// Implements repository.Upserter interface.
+func (g *GORMRepository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (created bool, dberr *dberrors.Error) {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false, dberrors.ErrUnspecifiedError.NewWithMessage("Upsert requires the pointer to struct")
+	}
+	scope := g.db.NewScope(req)
+
+	conflict, dberr := upsertFields(scope, conflictFields, true)
+	if dberr != nil {
+		return false, dberr
+	}
+	update, dberr := upsertFields(scope, updateFields, false)
+	if dberr != nil {
+		return false, dberr
+	}
+
+	conditions := map[string]interface{}{}
+	for _, field := range conflict {
+		// the record with blank primary key does not conflict with any stored record
+		if field.IsPrimaryKey && field.IsBlank {
+			return true, g.Create(req)
+		}
+		conditions[field.DBName] = field.Field.Interface()
+	}
+
+	now := gorm.NowFunc()
+	if field, ok := scope.FieldByName("CreatedAt"); ok && field.IsBlank {
+		field.Set(now)
+	}
+	if field, ok := scope.FieldByName("UpdatedAt"); ok {
+		field.Set(now)
+	}
+
+	// the blank primary keys are generated by the database
+	var columns []*gorm.Field
+	for _, field := range scope.Fields() {
+		if isColumn(field) && !(field.IsPrimaryKey && field.IsBlank) {
+			columns = append(columns, field)
+		}
+	}
+	if len(update) == 0 {
+		update = upsertUpdateFields(columns, conflict)
+	}
+
+	tx := g.db.Begin()
+	if tx.Error != nil {
+		return false, g.converter.Convert(tx.Error)
+	}
+	defer func() {
+		if dberr != nil {
+			tx.Rollback()
+		}
+	}()
+
+	statement, values := upsertSQL(scope, columns, conflict, update)
+	switch scope.Dialect().GetName() {
+	case "postgres":
+		// the inserted row has no deleting transaction, the conflicting row with
+		// 'DO NOTHING' is not returned at all
+		err := tx.Raw(statement+" RETURNING (xmax = 0)", values...).Row().Scan(&created)
+		if err != nil && err != sql.ErrNoRows {
+			return false, g.converter.Convert(err)
+		}
+	case "mysql":
+		// the inserted row is affected once, the updated row twice and the unchanged not at all
+		db := tx.Exec(statement, values...)
+		if db.Error != nil {
+			return false, g.converter.Convert(db.Error)
+		}
+		created = db.RowsAffected == 1
+	default:
+		// the existence of the conflicting record defines if the upsert creates the record
+		var count int
+		if err := tx.Model(refutils.ObjOfPtrType(req)).Where(conditions).Count(&count).Error; err != nil {
+			return false, g.converter.Convert(err)
+		}
+		if err := tx.Exec(statement, values...).Error; err != nil {
+			return false, g.converter.Convert(err)
+		}
+		created = count == 0
+	}
+
+	// reload the stored record, so that the 'req' contains the generated and not updated values
+	res := refutils.ObjOfPtrType(req)
+	if err := tx.Where(conditions).First(res).Error; err != nil {
+		return false, g.converter.Convert(err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, g.converter.Convert(err)
+	}
+	v.Elem().Set(reflect.ValueOf(res).Elem())
+	return created, nil
+}
+
+// Delete deletes the records selected by the 'where' object. The nil 'where' deletes
+// all the records of the 'req' model's table. If no record was deleted the ErrNoResult is returned.
 func (g *GORMRepository) Delete(req, where interface{}) *dberrors.Error {
-	var db *gorm.DB
-	db = g.db.Where(where).Delete(req)
-	err := db.Error
-	rows := db.RowsAffected
-	if rows == 0 && err == nil {
-		err = dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	db := g.where(where).Delete(req)
+	if db.Error != nil {
+		return g.converter.Convert(db.Error)
 	}
-	if err != nil {
-		return g.converter.Convert(err)
+	if db.RowsAffected == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
 	}
 	return nil
 }
+
+// where returns the db with the 'where' conditions. The nil 'where' selects all the records.
+func (g *GORMRepository) where(where interface{}) *gorm.DB {
+	if where == nil {
+		return g.db
+	}
+	return g.db.Where(where)
+}
+
+// upsertFields returns the column fields of the 'scope' with the 'names'. If no names
+// are provided and the 'primary' is true the primary key fields are returned.
+func upsertFields(scope *gorm.Scope, names []string, primary bool) ([]*gorm.Field, *dberrors.Error) {
+	if len(names) == 0 && primary {
+		if len(scope.PrimaryFields()) == 0 {
+			return nil, dberrors.ErrUnspecifiedError.NewWithMessage("Model: '" + scope.TableName() + "' has no primary key")
+		}
+		return scope.PrimaryFields(), nil
+	}
+
+	fields := make([]*gorm.Field, 0, len(names))
+	for _, name := range names {
+		field, ok := scope.FieldByName(name)
+		if !ok || !isColumn(field) {
+			return nil, dberrors.ErrUnspecifiedError.NewWithMessage("Field: '" + name + "' cannot be upserted")
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// upsertUpdateFields returns the 'columns' that are not the 'conflict' fields
+// nor the primary key or the creation time.
+func upsertUpdateFields(columns, conflict []*gorm.Field) []*gorm.Field {
+	var update []*gorm.Field
+	for _, column := range columns {
+		isConflict := false
+		for _, field := range conflict {
+			if field.DBName == column.DBName {
+				isConflict = true
+				break
+			}
+		}
+		if !isConflict && !column.IsPrimaryKey && column.Name != "CreatedAt" {
+			update = append(update, column)
+		}
+	}
+	return update
+}
+
+// upsertSQL returns the dialect specific upsert statement with its values.
+func upsertSQL(scope *gorm.Scope, columns, conflict, update []*gorm.Field) (string, []interface{}) {
+	var sql bytes.Buffer
+	values := make([]interface{}, 0, len(columns))
+
+	sql.WriteString("INSERT INTO " + scope.QuotedTableName() + " (")
+	for i, column := range columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(scope.Quote(column.DBName))
+		values = append(values, column.Field.Interface())
+	}
+	sql.WriteString(") VALUES (" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")")
+
+	if scope.Dialect().GetName() == "mysql" {
+		sql.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(update) == 0 {
+			// the no-op assignment keeps the stored record unchanged
+			update = conflict
+		}
+		for i, field := range update {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			column := scope.Quote(field.DBName)
+			sql.WriteString(column + " = VALUES(" + column + ")")
+		}
+		return sql.String(), values
+	}
+
+	sql.WriteString(" ON CONFLICT (")
+	for i, field := range conflict {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(scope.Quote(field.DBName))
+	}
+	if len(update) == 0 {
+		sql.WriteString(") DO NOTHING")
+		return sql.String(), values
+	}
+	sql.WriteString(") DO UPDATE SET ")
+	for i, field := range update {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		column := scope.Quote(field.DBName)
+		sql.WriteString(column + " = excluded." + column)
+	}
+	return sql.String(), values
+}
+
+// isColumn checks if the field is stored in the table column.
+func isColumn(field *gorm.Field) bool {
+	return field.IsNormal && !field.IsIgnored && field.Relationship == nil
+}
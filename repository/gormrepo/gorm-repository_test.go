@@ -6,6 +6,7 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/kucjac/go-rest-sdk/dberrors"
 	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/repotest"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
 )
@@ -28,6 +29,11 @@ type Foobar struct {
 	Name string `gorm:"type:varchar(5);unique"`
 }
 
+type Slug struct {
+	Code string `gorm:"primary_key"`
+	Name string
+}
+
 type NotInDB struct{}
 
 func TestNewGORMRepository(t *testing.T) {
@@ -391,6 +397,25 @@ func TestGORMRepositorySelectWithParams(t *testing.T) {
 					}
 				})
 
+				Convey(`Using the keys field for struct with non-numeric primary key
+					should select only those entites with provided ids`, func() {
+					slugs := []*Slug{{Code: "first", Name: "First"}, {Code: "second", Name: "Second"},
+						{Code: "third", Name: "Third"}}
+					for _, slug := range slugs {
+						So(gormRepo.Create(slug), ShouldBeNil)
+					}
+
+					params = &repository.ListParameters{Keys: []string{"first", "third"}}
+					res, err := gormRepo.ListWithParams(&Slug{}, params)
+					So(err, ShouldBeNil)
+
+					list, ok := res.([]*Slug)
+					So(ok, ShouldBeTrue)
+					So(list, ShouldHaveLength, 2)
+					So(list, ShouldContain, slugs[0])
+					So(list, ShouldContain, slugs[2])
+				})
+
 				Convey("Using the Order param orders the query", func() {
 					var order string = "name desc"
 					params = &repository.ListParameters{Order: order}
@@ -598,6 +623,51 @@ func TestGORMRepositoryPatch(t *testing.T) {
 
 }
 
+func TestGORMRepositoryPatchFields(t *testing.T) {
+
+	Convey("Subject: Updates the selected fields including the zero values.", t, func() {
+
+		db, err := openGormSqlite()
+		So(err, ShouldBeNil)
+
+		defer db.Close()
+		defer clearDB(db)
+
+		var bars []*Bar = seedBars(db)
+
+		gormRepo, err := New(db)
+		So(err, ShouldBeNil)
+
+		Convey("The selected fields are set to the zero values", func() {
+			req := &Bar{ID: bars[0].ID, Name: "", Property: 0}
+			dbErr := gormRepo.PatchFields(req, &Bar{ID: bars[0].ID}, []string{"Property"})
+			So(dbErr, ShouldBeNil)
+
+			var getted *Bar = &Bar{ID: bars[0].ID}
+			db.Find(getted)
+			So(getted.Property, ShouldEqual, 0)
+
+			Convey("Fields not selected should not change", func() {
+				So(getted.Name, ShouldEqual, bars[0].Name)
+			})
+		})
+
+		Convey("No fields does not change the record", func() {
+			dbErr := gormRepo.PatchFields(&Bar{}, &Bar{ID: bars[0].ID}, nil)
+			So(dbErr, ShouldBeNil)
+		})
+
+		Convey("Unknown field or not existing record results in error", func() {
+			dbErr := gormRepo.PatchFields(&Bar{}, &Bar{ID: bars[0].ID}, []string{"Unknown"})
+			So(dbErr, ShouldBeError)
+
+			dbErr = gormRepo.PatchFields(&Bar{Name: "name"}, &Bar{ID: 12345}, []string{"Name"})
+			So(dbErr, ShouldBeError)
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+		})
+	})
+}
+
 func TestGORMRepositoryDelete(t *testing.T) {
 
 	Convey("Subject: Deleting the database records that matches the 'req' object.", t, func() {
@@ -670,14 +740,14 @@ func openGormSqlite() (*gorm.DB, error) {
 
 func migrateModels(db *gorm.DB) error {
 	if db != nil {
-		db.AutoMigrate(&Bar{}, &Foo{}, &Foobar{})
+		db.AutoMigrate(&Bar{}, &Foo{}, &Foobar{}, &Slug{})
 		return nil
 	}
 	return errors.New("Nil pointer provided")
 }
 
 func clearDB(db *gorm.DB) {
-	db.DropTableIfExists(&Bar{}, &Foo{}, &Foobar{})
+	db.DropTableIfExists(&Bar{}, &Foo{}, &Foobar{}, &Slug{})
 }
 
 func seedBars(db *gorm.DB) (bars []*Bar) {
@@ -691,3 +761,108 @@ func seedBars(db *gorm.DB) (bars []*Bar) {
 	}
 	return bars
 }
+
+func TestGORMRepositoryUpsert(t *testing.T) {
+	Convey("Subject: GORMRepository method Upsert", t, func() {
+		db, err := openGormSqlite()
+		So(err, ShouldBeNil)
+		defer db.Close()
+		defer clearDB(db)
+
+		repo, err := New(db)
+		So(err, ShouldBeNil)
+
+		Convey("The model with blank primary key is created", func() {
+			bar := &Bar{Name: "First"}
+			created, dbErr := repo.Upsert(bar, nil, nil)
+			So(dbErr, ShouldBeNil)
+			So(created, ShouldBeTrue)
+			So(bar.ID, ShouldNotEqual, 0)
+
+			Convey("The model conflicting on the primary key is updated", func() {
+				updated := &Bar{ID: bar.ID, Name: "Second", Property: 5}
+				created, dbErr = repo.Upsert(updated, nil, nil)
+				So(dbErr, ShouldBeNil)
+				So(created, ShouldBeFalse)
+
+				count, _ := repo.Count(&Bar{Name: "Second", Property: 5})
+				So(count, ShouldEqual, 1)
+				count, _ = repo.Count(&Bar{})
+				So(count, ShouldEqual, 1)
+			})
+
+			Convey("The model with not stored primary key is created", func() {
+				created, dbErr = repo.Upsert(&Bar{ID: 100, Name: "Third"}, nil, nil)
+				So(dbErr, ShouldBeNil)
+				So(created, ShouldBeTrue)
+
+				res, dbErr := repo.Get(&Bar{ID: 100})
+				So(dbErr, ShouldBeNil)
+				So(res.(*Bar).Name, ShouldEqual, "Third")
+			})
+		})
+
+		Convey("Only the update fields of the conflicting record are updated", func() {
+			So(repo.Create(&Bar{Name: "First", Property: 1}), ShouldBeNil)
+			bar := &Bar{ID: 1, Name: "Changed", Property: 2}
+			created, dbErr := repo.Upsert(bar, []string{"ID"}, []string{"Property"})
+			So(dbErr, ShouldBeNil)
+			So(created, ShouldBeFalse)
+
+			// the model is set to the stored record
+			So(bar.Name, ShouldEqual, "First")
+			So(bar.Property, ShouldEqual, 2)
+		})
+
+		Convey("The conflict on the unique field keeps the stored record", func() {
+			stored := &Foobar{Name: "name"}
+			So(repo.Create(stored), ShouldBeNil)
+
+			foobar := &Foobar{Name: "name"}
+			created, dbErr := repo.Upsert(foobar, []string{"Name"}, nil)
+			So(dbErr, ShouldBeNil)
+			So(created, ShouldBeFalse)
+			So(foobar.ID, ShouldEqual, stored.ID)
+		})
+
+		Convey("The non-numeric primary keys are upserted", func() {
+			_, dbErr := repo.Upsert(&Slug{Code: "code", Name: "first"}, nil, nil)
+			So(dbErr, ShouldBeNil)
+			created, dbErr := repo.Upsert(&Slug{Code: "code", Name: "second"}, nil, nil)
+			So(dbErr, ShouldBeNil)
+			So(created, ShouldBeFalse)
+
+			res, _ := repo.Get(&Slug{Code: "code"})
+			So(res.(*Slug).Name, ShouldEqual, "second")
+		})
+
+		Convey("The unknown fields could not be upserted", func() {
+			_, dbErr := repo.Upsert(&Bar{Name: "First"}, []string{"Unknown"}, nil)
+			So(dbErr, ShouldNotBeNil)
+			So(dbErr.Compare(dberrors.ErrUnspecifiedError), ShouldBeTrue)
+
+			_, dbErr = repo.Upsert(Bar{}, nil, nil)
+			So(dbErr, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGORMRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, models ...interface{}) repository.Repository {
+		db, err := gorm.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.DB().SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+
+		if err = db.AutoMigrate(models...).Error; err != nil {
+			t.Fatal(err)
+		}
+		gormRepo, err := New(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return gormRepo
+	})
+}
@@ -0,0 +1,363 @@
+package memrepo
+
+import (
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"reflect"
+	"sync"
+)
+
+// MemoryRepository is an in-memory implementation of the Repository interface.
+// It is meant to be used in tests and prototypes instead of the real database.
+//
+// The models are stored by their primary key (forms.PrimaryKeyField), the zero numeric
+// primary keys are auto incremented on create. The query objects match the records with
+// equal non-zero fields, as in the gormrepo.GORMRepository. The ErrUniqueViolation is returned
+// for duplicated primary keys and the fields tagged with the `gorm:"unique"` or
+// `gorm:"unique_index"` tags, and the ErrNoResult if no record was found or modified.
+//
+// The records are stored as the shallow copies of the models, the pointers, slices and maps
+// within the models are shared between the stored and the provided or returned objects.
+// MemoryRepository is safe for concurrent use.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	tables map[reflect.Type]*table
+}
+
+// New creates new empty MemoryRepository.
+func New() *MemoryRepository {
+	return &MemoryRepository{tables: map[reflect.Type]*table{}}
+}
+
+// Create stores the 'req' model. The zero numeric primary key is set to the next
+// value of the model's sequence.
+func (m *MemoryRepository) Create(req interface{}) *dberrors.Error {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.table(v.Type()).insert(v)
+}
+
+// Get returns the first record, ordered by the primary key, that matches the 'req'.
+func (m *MemoryRepository) Get(req interface{}) (interface{}, *dberrors.Error) {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t := m.tableOf(v.Type())
+	if t != nil {
+		for _, record := range t.sorted() {
+			if t.matches(record, v) {
+				return copyOf(record).Interface(), nil
+			}
+		}
+	}
+	return nil, dberrors.ErrNoResult.NewWithMessage("Record not found")
+}
+
+// List returns all the records that match the 'req' ordered by the primary key.
+// The result is the slice of pointers to the model i.e. []*Foo.
+func (m *MemoryRepository) List(req interface{}) (interface{}, *dberrors.Error) {
+	return m.ListWithParams(req, nil)
+}
+
+// ListWithParams lists the 'req' models using the 'params'. The 'Order' parameter
+// contains the comma separated column or field names, optionally followed by the
+// 'asc' or 'desc' direction i.e. 'name desc, id'. If any of the parameters is set
+// and the 'Limit' is zero, at most 10 records are returned, as in the gormrepo.
+func (m *MemoryRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(v.Type())), 0, 0)
+	t := m.tableOf(v.Type())
+	if t == nil {
+		t = newTable(v.Type())
+	}
+
+	var ids map[string]bool
+	if params != nil && params.PrimaryKeys() != nil {
+		ids = map[string]bool{}
+		for _, id := range params.PrimaryKeys() {
+			ids[id] = true
+		}
+	}
+
+	var records []reflect.Value
+	for _, record := range t.sorted() {
+		if !t.matches(record, v) {
+			continue
+		}
+		if ids != nil && !ids[fmt.Sprint(t.key(record))] {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if params != nil && params.ContainsParameters() {
+		if params.Order != "" {
+			if dbErr = t.order(records, params.Order); dbErr != nil {
+				return nil, dbErr
+			}
+		}
+		limit := params.Limit
+		if limit == 0 {
+			limit = 10
+		}
+		records = page(records, params.Offset, limit)
+	}
+
+	for _, record := range records {
+		result = reflect.Append(result, copyOf(record))
+	}
+	return result.Interface(), nil
+}
+
+// Count returns the number of records that match the 'req'.
+func (m *MemoryRepository) Count(req interface{}) (int, *dberrors.Error) {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return 0, dbErr
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int
+	if t := m.tableOf(v.Type()); t != nil {
+		for _, record := range t.records {
+			if t.matches(record, v) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// Update replaces the stored record with the 'req'. If the 'req' has no primary key
+// set or the record does not exist, the 'req' is created.
+func (m *MemoryRepository) Update(req interface{}) *dberrors.Error {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.table(v.Type())
+	key := t.key(v)
+	if _, ok := t.records[key]; !ok || t.primaryKey(v).IsZero() {
+		return t.insert(v)
+	}
+	if dbErr = t.checkUnique(t.records, v, key); dbErr != nil {
+		return dbErr
+	}
+	t.records[key] = copyValue(v)
+	return nil
+}
+
+// Patch sets the non-zero fields of the 'req' in all the records that match the 'where'.
+// If the 'req' has the primary key set, only the record with that key is patched.
+// If the 'where' is nil all the records are patched.
+func (m *MemoryRepository) Patch(req, where interface{}) *dberrors.Error {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tableOf(v.Type())
+	var columns []column
+	if t != nil {
+		for _, col := range t.columns {
+			if !col.primaryKey && !v.FieldByIndex(col.index).IsZero() {
+				columns = append(columns, col)
+			}
+		}
+	}
+	return m.patch(t, v, where, columns)
+}
+
+// PatchFields sets the 'fields' of the 'req' in all the records that match the 'where'.
+// The zero values and nil pointers of the fields are stored as well.
+// Implements repository.FieldPatcher interface.
+func (m *MemoryRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tableOf(v.Type())
+	if t == nil {
+		t = newTable(v.Type())
+	}
+	columns := make([]column, 0, len(fields))
+	for _, name := range fields {
+		col, ok := t.columnByName(name)
+		if !ok {
+			return dberrors.ErrUnspecifiedError.NewWithMessage("Field: '" + name + "' cannot be patched")
+		}
+		columns = append(columns, col)
+	}
+	return m.patch(t, v, where, columns)
+}
+
+// Delete deletes all the records that match the 'where'. If the 'where' is nil
+// all the records of the 'req' model are deleted.
+func (m *MemoryRepository) Delete(req, where interface{}) *dberrors.Error {
+	v, dbErr := modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	whereValue, dbErr := whereValueOf(v, where)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int
+	if t := m.tableOf(v.Type()); t != nil {
+		for key, record := range t.records {
+			if (!t.primaryKey(v).IsZero() && key != t.key(v)) || !t.matches(record, whereValue) {
+				continue
+			}
+			delete(t.records, key)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+	return nil
+}
+
+// patch sets the 'columns' of the 'v' in the records that match the 'where'.
+func (m *MemoryRepository) patch(t *table, v reflect.Value, where interface{}, columns []column) *dberrors.Error {
+	whereValue, dbErr := whereValueOf(v, where)
+	if dbErr != nil {
+		return dbErr
+	}
+	if t == nil {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+
+	// the records are checked for the unique violations after all of them were patched,
+	// as within the single statement
+	records := make(map[interface{}]reflect.Value, len(t.records))
+	var patched []interface{}
+	for key, record := range t.records {
+		records[key] = record
+		if (!t.primaryKey(v).IsZero() && key != t.key(v)) || !t.matches(record, whereValue) {
+			continue
+		}
+		updated := copyValue(record)
+		for _, col := range columns {
+			updated.FieldByIndex(col.index).Set(v.FieldByIndex(col.index))
+		}
+		records[key] = updated
+		patched = append(patched, key)
+	}
+	if len(patched) == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+	for _, key := range patched {
+		if dbErr := t.checkUnique(records, records[key], key); dbErr != nil {
+			return dbErr
+		}
+	}
+	t.records = records
+	return nil
+}
+
+func (m *MemoryRepository) table(modelType reflect.Type) *table {
+	t, ok := m.tables[modelType]
+	if !ok {
+		t = newTable(modelType)
+		m.tables[modelType] = t
+	}
+	return t
+}
+
+func (m *MemoryRepository) tableOf(modelType reflect.Type) *table {
+	return m.tables[modelType]
+}
+
+// modelValue returns the addressable struct value of the 'req' model.
+func modelValue(req interface{}) (reflect.Value, *dberrors.Error) {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, dberrors.ErrUnspecifiedError.NewWithMessage(
+			fmt.Sprintf("Unsupported model: %T. The pointer to struct is required", req))
+	}
+	return v.Elem(), nil
+}
+
+// whereValueOf returns the struct value of the 'where' model of the same type as the 'v'
+// or invalid value if the 'where' is nil.
+func whereValueOf(v reflect.Value, where interface{}) (reflect.Value, *dberrors.Error) {
+	if where == nil {
+		return reflect.Value{}, nil
+	}
+	whereValue := reflect.ValueOf(where)
+	if whereValue.Kind() == reflect.Ptr && !whereValue.IsNil() {
+		whereValue = whereValue.Elem()
+	}
+	if whereValue.Type() != v.Type() {
+		return reflect.Value{}, dberrors.ErrUnspecifiedError.NewWithMessage(
+			fmt.Sprintf("Unsupported query: %T for model: %s", where, v.Type().Name()))
+	}
+	return whereValue, nil
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// copyOf returns the pointer to the copy of the record.
+func copyOf(record reflect.Value) reflect.Value {
+	c := reflect.New(record.Type())
+	c.Elem().Set(record)
+	return c
+}
+
+func page(records []reflect.Value, offset, limit int) []reflect.Value {
+	if offset >= len(records) {
+		return nil
+	}
+	records = records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
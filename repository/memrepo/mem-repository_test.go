@@ -0,0 +1,252 @@
+package memrepo
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/repotest"
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Foo struct {
+	ID      uint
+	Name    string
+	Email   *string `gorm:"unique"`
+	Age     int
+	Bar     *Bar
+	Created time.Time
+}
+
+type Bar struct {
+	ID   uint
+	Name string
+}
+
+type Slug struct {
+	Code  string `gorm:"primary_key"`
+	Group string `gorm:"unique_index:idx_group_name"`
+	Name  string `gorm:"unique_index:idx_group_name"`
+}
+
+type NoPrimaryKey struct {
+	Name string
+}
+
+func TestMemoryRepositoryCreate(t *testing.T) {
+	Convey("Subject: Creating new model records in the MemoryRepository", t, func() {
+		repo := New()
+
+		Convey("The numeric primary keys are auto incremented", func() {
+			first, second := &Foo{Name: "first"}, &Foo{Name: "second"}
+			So(repo.Create(first), ShouldBeNil)
+			So(repo.Create(second), ShouldBeNil)
+			So(first.ID, ShouldEqual, 1)
+			So(second.ID, ShouldEqual, 2)
+
+			So(repo.Create(&Foo{ID: 10}), ShouldBeNil)
+			third := &Foo{}
+			So(repo.Create(third), ShouldBeNil)
+			So(third.ID, ShouldEqual, 11)
+		})
+
+		Convey("The duplicated primary key results in ErrUniqueViolation", func() {
+			So(repo.Create(&Slug{Code: "code"}), ShouldBeNil)
+			dbErr := repo.Create(&Slug{Code: "code", Name: "other"})
+			So(dbErr, ShouldNotBeNil)
+			So(dbErr.Compare(dberrors.ErrUniqueViolation), ShouldBeTrue)
+		})
+
+		Convey("The unique fields are checked", func() {
+			email := "john@example.com"
+			So(repo.Create(&Foo{Email: &email}), ShouldBeNil)
+			So(repo.Create(&Foo{}), ShouldBeNil)
+			So(repo.Create(&Foo{}), ShouldBeNil)
+
+			other := email
+			dbErr := repo.Create(&Foo{Email: &other})
+			So(dbErr, ShouldNotBeNil)
+			So(dbErr.Compare(dberrors.ErrUniqueViolation), ShouldBeTrue)
+
+			Convey("The composite unique index is checked with all of its fields", func() {
+				So(repo.Create(&Slug{Code: "a", Group: "g", Name: "n"}), ShouldBeNil)
+				So(repo.Create(&Slug{Code: "b", Group: "g", Name: "m"}), ShouldBeNil)
+				dbErr := repo.Create(&Slug{Code: "c", Group: "g", Name: "n"})
+				So(dbErr, ShouldNotBeNil)
+				So(dbErr.Compare(dberrors.ErrUniqueViolation), ShouldBeTrue)
+			})
+		})
+
+		Convey("The model without primary key or not a pointer could not be created", func() {
+			So(repo.Create(&NoPrimaryKey{}), ShouldNotBeNil)
+			So(repo.Create(Foo{}), ShouldNotBeNil)
+		})
+
+		Convey("The stored record is a copy of the model", func() {
+			foo := &Foo{Name: "name"}
+			So(repo.Create(foo), ShouldBeNil)
+			foo.Name = "changed"
+
+			res, dbErr := repo.Get(&Foo{ID: foo.ID})
+			So(dbErr, ShouldBeNil)
+			So(res.(*Foo).Name, ShouldEqual, "name")
+		})
+	})
+}
+
+func TestMemoryRepositoryQueries(t *testing.T) {
+	Convey("Subject: Querying the MemoryRepository records", t, func() {
+		repo := New()
+		for i, name := range []string{"john", "jane", "john", "adam"} {
+			So(repo.Create(&Foo{Name: name, Age: 20 + i}), ShouldBeNil)
+		}
+
+		Convey("Get returns the first matching record", func() {
+			res, dbErr := repo.Get(&Foo{Name: "john"})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldResemble, &Foo{ID: 1, Name: "john", Age: 20})
+
+			_, dbErr = repo.Get(&Foo{Name: "none"})
+			So(dbErr, ShouldNotBeNil)
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+
+			_, dbErr = repo.Get(&Bar{ID: 1})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+		})
+
+		Convey("List returns the matching records ordered by the primary key", func() {
+			res, dbErr := repo.List(&Foo{Name: "john"})
+			So(dbErr, ShouldBeNil)
+			foos := res.([]*Foo)
+			So(foos, ShouldHaveLength, 2)
+			So(foos[0].ID, ShouldEqual, 1)
+			So(foos[1].ID, ShouldEqual, 3)
+
+			res, dbErr = repo.List(&Bar{})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldBeEmpty)
+		})
+
+		Convey("ListWithParams honours the parameters", func() {
+			res, dbErr := repo.ListWithParams(&Foo{}, &repository.ListParameters{Order: "name desc, age", Limit: 3})
+			So(dbErr, ShouldBeNil)
+			foos := res.([]*Foo)
+			So(foos, ShouldHaveLength, 3)
+			So(foos[0].ID, ShouldEqual, 1)
+			So(foos[1].ID, ShouldEqual, 3)
+			So(foos[2].Name, ShouldEqual, "jane")
+
+			res, dbErr = repo.ListWithParams(&Foo{}, &repository.ListParameters{IDs: []int{2, 4}, Offset: 1})
+			So(dbErr, ShouldBeNil)
+			So(res.([]*Foo), ShouldHaveLength, 1)
+			So(res.([]*Foo)[0].ID, ShouldEqual, 4)
+
+			_, dbErr = repo.ListWithParams(&Foo{}, &repository.ListParameters{Order: "unknown"})
+			So(dbErr.Compare(dberrors.ErrInvalidSyntax), ShouldBeTrue)
+		})
+
+		Convey("Count returns the number of matching records", func() {
+			count, dbErr := repo.Count(&Foo{Name: "john"})
+			So(dbErr, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+
+			count, _ = repo.Count(&Foo{})
+			So(count, ShouldEqual, 4)
+		})
+	})
+}
+
+func TestMemoryRepositoryModifications(t *testing.T) {
+	Convey("Subject: Modifying the MemoryRepository records", t, func() {
+		repo := New()
+		for _, name := range []string{"john", "jane", "john"} {
+			So(repo.Create(&Foo{Name: name}), ShouldBeNil)
+		}
+
+		Convey("Update replaces or creates the record", func() {
+			So(repo.Update(&Foo{ID: 1, Name: "adam"}), ShouldBeNil)
+			res, _ := repo.Get(&Foo{ID: 1})
+			So(res.(*Foo).Name, ShouldEqual, "adam")
+
+			created := &Foo{Name: "new"}
+			So(repo.Update(created), ShouldBeNil)
+			So(created.ID, ShouldEqual, 4)
+		})
+
+		Convey("Patch sets the non-zero fields of the matching records", func() {
+			So(repo.Patch(&Foo{Age: 30}, &Foo{Name: "john"}), ShouldBeNil)
+			count, _ := repo.Count(&Foo{Age: 30})
+			So(count, ShouldEqual, 2)
+
+			dbErr := repo.Patch(&Foo{Age: 30}, &Foo{Name: "none"})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+
+			Convey("The primary key of the model limits the patched records", func() {
+				So(repo.Patch(&Foo{ID: 3, Age: 40}, &Foo{Name: "john"}), ShouldBeNil)
+				count, _ := repo.Count(&Foo{Age: 40})
+				So(count, ShouldEqual, 1)
+			})
+
+			Convey("The patch violating the unique index is not applied", func() {
+				email := "same@example.com"
+				dbErr := repo.Patch(&Foo{Email: &email}, &Foo{Name: "john"})
+				So(dbErr.Compare(dberrors.ErrUniqueViolation), ShouldBeTrue)
+				count, _ := repo.Count(&Foo{Email: &email})
+				So(count, ShouldEqual, 0)
+			})
+		})
+
+		Convey("PatchFields sets the zero values as well", func() {
+			So(repo.Patch(&Foo{Age: 30}, nil), ShouldBeNil)
+			So(repo.PatchFields(&Foo{Age: 0}, &Foo{ID: 2}, []string{"Age"}), ShouldBeNil)
+			res, _ := repo.Get(&Foo{ID: 2})
+			So(res.(*Foo).Age, ShouldEqual, 0)
+
+			dbErr := repo.PatchFields(&Foo{}, &Foo{ID: 2}, []string{"Bar"})
+			So(dbErr.Compare(dberrors.ErrUnspecifiedError), ShouldBeTrue)
+		})
+
+		Convey("Delete removes the matching records", func() {
+			So(repo.Delete(&Foo{}, &Foo{Name: "john"}), ShouldBeNil)
+			count, _ := repo.Count(&Foo{})
+			So(count, ShouldEqual, 1)
+
+			dbErr := repo.Delete(&Foo{}, &Foo{Name: "john"})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+
+			So(repo.Delete(&Foo{}, &Bar{}), ShouldNotBeNil)
+			So(repo.Delete(&Foo{}, nil), ShouldBeNil)
+			count, _ = repo.Count(&Foo{})
+			So(count, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestMemoryRepositoryConcurrency(t *testing.T) {
+	Convey("Subject: MemoryRepository is safe for concurrent use", t, func() {
+		repo := New()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				repo.Create(&Foo{Name: strconv.Itoa(i)})
+				repo.List(&Foo{})
+			}(i)
+		}
+		wg.Wait()
+
+		count, dbErr := repo.Count(&Foo{})
+		So(dbErr, ShouldBeNil)
+		So(count, ShouldEqual, 50)
+	})
+}
+
+func TestMemoryRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, models ...interface{}) repository.Repository {
+		return New()
+	})
+}
@@ -0,0 +1,349 @@
+package memrepo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// column is the model's field stored in the table.
+type column struct {
+	name       string
+	index      []int
+	primaryKey bool
+}
+
+// table stores the records of single model type by their primary key values.
+type table struct {
+	columns  []column
+	pk       column
+	unique   [][]column
+	records  map[interface{}]reflect.Value
+	sequence int64
+}
+
+func newTable(modelType reflect.Type) *table {
+	t := &table{records: map[interface{}]reflect.Value{}}
+
+	pkField, hasPK := forms.PrimaryKeyField(modelType)
+	uniqueIndexes := map[string][]column{}
+	var indexNames []string
+
+	for _, field := range columnFields(modelType, nil) {
+		col := column{name: field.Name, index: field.Index}
+		if hasPK && field.Name == pkField.Name {
+			col.primaryKey = true
+			t.pk = col
+		}
+		t.columns = append(t.columns, col)
+
+		for _, option := range strings.Split(field.Tag.Get("gorm"), ";") {
+			option = strings.TrimSpace(option)
+			name, value := option, ""
+			if i := strings.Index(option, ":"); i != -1 {
+				name, value = option[:i], option[i+1:]
+			}
+			switch strings.ToUpper(name) {
+			case "UNIQUE":
+				t.unique = append(t.unique, []column{col})
+			case "UNIQUE_INDEX":
+				if value == "" {
+					t.unique = append(t.unique, []column{col})
+					continue
+				}
+				// the fields with the same index name define the composite unique index
+				if _, ok := uniqueIndexes[value]; !ok {
+					indexNames = append(indexNames, value)
+				}
+				uniqueIndexes[value] = append(uniqueIndexes[value], col)
+			}
+		}
+	}
+	for _, name := range indexNames {
+		t.unique = append(t.unique, uniqueIndexes[name])
+	}
+	return t
+}
+
+// columnFields returns the fields of the struct type that are stored as the columns.
+// The fields of the embedded structs are promoted, while the relationships
+// (structs, slices and pointers to structs) and the fields tagged with `gorm:"-"` are skipped.
+func columnFields(t reflect.Type, index []int) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int{}, index...), i)
+		if field.Tag.Get("gorm") == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !isValueType(field.Type) {
+			fields = append(fields, columnFields(field.Type, field.Index)...)
+			continue
+		}
+		if field.PkgPath != "" || !isColumnType(field.Type) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func isColumnType(t reflect.Type) bool {
+	if isValueType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return isColumnType(t.Elem())
+	case reflect.Struct, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+// isValueType checks if the struct type is stored as a single value i.e. time.Time.
+func isValueType(t reflect.Type) bool {
+	return t == timeType || t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)
+}
+
+func (t *table) primaryKey(v reflect.Value) reflect.Value {
+	if t.pk.index == nil {
+		return reflect.Value{}
+	}
+	return v.FieldByIndex(t.pk.index)
+}
+
+// key returns the map key of the record 'v'.
+func (t *table) key(v reflect.Value) interface{} {
+	pk := t.primaryKey(v)
+	if !pk.IsValid() {
+		return nil
+	}
+	if pk.Kind() == reflect.Ptr {
+		if pk.IsNil() {
+			return nil
+		}
+		pk = pk.Elem()
+	}
+	if !pk.Type().Comparable() {
+		return fmt.Sprint(pk.Interface())
+	}
+	return pk.Interface()
+}
+
+func (t *table) columnByName(name string) (column, bool) {
+	for _, col := range t.columns {
+		if col.name == name {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// columnByOrder returns the column for the 'name' used in the order clause. The name
+// matches the field name or its snake case column name case insensitive.
+func (t *table) columnByOrder(name string) (column, bool) {
+	name = strings.Replace(name, "_", "", -1)
+	for _, col := range t.columns {
+		if strings.EqualFold(col.name, name) {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// insert stores the copy of the 'v'. The zero integer primary key is set to the next
+// value of the sequence.
+func (t *table) insert(v reflect.Value) *dberrors.Error {
+	if t.pk.index == nil {
+		return dberrors.ErrUnspecifiedError.NewWithMessage(
+			fmt.Sprintf("Model: %s has no primary key", v.Type().Name()))
+	}
+
+	pk := t.primaryKey(v)
+	switch pk.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if pk.Int() == 0 {
+			pk.SetInt(t.nextSequence())
+		} else if pk.Int() > t.sequence {
+			t.sequence = pk.Int()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if pk.Uint() == 0 {
+			pk.SetUint(uint64(t.nextSequence()))
+		} else if int64(pk.Uint()) > t.sequence {
+			t.sequence = int64(pk.Uint())
+		}
+	}
+
+	key := t.key(v)
+	if _, ok := t.records[key]; ok {
+		return dberrors.ErrUniqueViolation.NewWithMessage(
+			fmt.Sprintf("Duplicated primary key: %v", key))
+	}
+	if dbErr := t.checkUnique(t.records, v, key); dbErr != nil {
+		return dbErr
+	}
+	t.records[key] = copyValue(v)
+	return nil
+}
+
+// nextSequence returns the next value of the sequence. The sequence is always greater
+// than the stored integer primary keys.
+func (t *table) nextSequence() int64 {
+	t.sequence++
+	return t.sequence
+}
+
+// checkUnique checks if the 'v' stored with the 'key' does not violate the unique
+// indexes of the other 'records'. The nil pointers are not compared, as the NULL values.
+func (t *table) checkUnique(records map[interface{}]reflect.Value, v reflect.Value, key interface{}) *dberrors.Error {
+	for _, index := range t.unique {
+		if hasNull(v, index) {
+			continue
+		}
+		for recordKey, record := range records {
+			if recordKey == key {
+				continue
+			}
+			if equalColumns(record, v, index) {
+				return dberrors.ErrUniqueViolation.NewWithMessage(
+					fmt.Sprintf("Duplicated value of the unique field: '%s'", index[0].name))
+			}
+		}
+	}
+	return nil
+}
+
+func hasNull(v reflect.Value, columns []column) bool {
+	for _, col := range columns {
+		if field := v.FieldByIndex(col.index); field.Kind() == reflect.Ptr && field.IsNil() {
+			return true
+		}
+	}
+	return false
+}
+
+func equalColumns(a, b reflect.Value, columns []column) bool {
+	for _, col := range columns {
+		if !reflect.DeepEqual(a.FieldByIndex(col.index).Interface(), b.FieldByIndex(col.index).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches checks if the 'record' has the values of all the non-zero columns of the 'where'.
+// The invalid 'where' matches all the records.
+func (t *table) matches(record, where reflect.Value) bool {
+	if !where.IsValid() {
+		return true
+	}
+	for _, col := range t.columns {
+		value := where.FieldByIndex(col.index)
+		if value.IsZero() {
+			continue
+		}
+		if !reflect.DeepEqual(record.FieldByIndex(col.index).Interface(), value.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// sorted returns the records ordered by the primary key.
+func (t *table) sorted() []reflect.Value {
+	records := make([]reflect.Value, 0, len(t.records))
+	for _, record := range t.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return less(t.primaryKey(records[i]), t.primaryKey(records[j]))
+	})
+	return records
+}
+
+// order sorts the 'records' by the 'order' clause i.e. 'name desc, id'.
+func (t *table) order(records []reflect.Value, order string) *dberrors.Error {
+	type orderBy struct {
+		col  column
+		desc bool
+	}
+	var orders []orderBy
+	for _, part := range strings.Split(order, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return dberrors.ErrInvalidSyntax.NewWithMessage("Invalid order: '" + order + "'")
+		}
+		col, ok := t.columnByOrder(words[0])
+		if !ok {
+			return dberrors.ErrInvalidSyntax.NewWithMessage("Unknown order column: '" + words[0] + "'")
+		}
+		by := orderBy{col: col}
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				by.desc = true
+			default:
+				return dberrors.ErrInvalidSyntax.NewWithMessage("Invalid order: '" + order + "'")
+			}
+		}
+		orders = append(orders, by)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, by := range orders {
+			a, b := records[i].FieldByIndex(by.col.index), records[j].FieldByIndex(by.col.index)
+			if by.desc {
+				a, b = b, a
+			}
+			if less(a, b) {
+				return true
+			}
+			if less(b, a) {
+				return false
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// less compares the column values. The nil pointers are ordered first.
+func less(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && !b.IsNil()
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Before(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
@@ -156,6 +156,22 @@ func (_m *MockRepository) Patch(req interface{}, where interface{}) *dberrors.Er
 	return r0
 }
 
+// PatchFields provides a mock function with given fields: req, where, fields
+func (_m *MockRepository) PatchFields(req interface{}, where interface{}, fields []string) *dberrors.Error {
+	ret := _m.Called(req, where, fields)
+
+	var r0 *dberrors.Error
+	if rf, ok := ret.Get(0).(func(interface{}, interface{}, []string) *dberrors.Error); ok {
+		r0 = rf(req, where, fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dberrors.Error)
+		}
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: req
 func (_m *MockRepository) Update(req interface{}) *dberrors.Error {
 	ret := _m.Called(req)
@@ -171,3 +187,26 @@ func (_m *MockRepository) Update(req interface{}) *dberrors.Error {
 
 	return r0
 }
+
+// Upsert provides a mock function with given fields: req, conflictFields, updateFields
+func (_m *MockRepository) Upsert(req interface{}, conflictFields []string, updateFields []string) (bool, *dberrors.Error) {
+	ret := _m.Called(req, conflictFields, updateFields)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(interface{}, []string, []string) bool); ok {
+		r0 = rf(req, conflictFields, updateFields)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 *dberrors.Error
+	if rf, ok := ret.Get(1).(func(interface{}, []string, []string) *dberrors.Error); ok {
+		r1 = rf(req, conflictFields, updateFields)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*dberrors.Error)
+		}
+	}
+
+	return r0, r1
+}
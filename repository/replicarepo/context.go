@@ -0,0 +1,173 @@
+package replicarepo
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"sync/atomic"
+)
+
+type contextKey struct{}
+
+// consistency defines the reads of the context.
+type consistency struct {
+	// primary is true if the reads use the primary repository
+	primary bool
+
+	// wrote is set to 1 after the first write of the ReadYourWrites context
+	wrote int32
+}
+
+// ReadPrimary returns the context for which all the reads use the primary repository.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &consistency{primary: true})
+}
+
+// ReadYourWrites returns the context for which the reads use the primary repository
+// after the first write made with the context, so that the written records are read
+// regardless of the replication lag.
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &consistency{})
+}
+
+// readsPrimary checks if the reads of the 'ctx' use the primary repository.
+func readsPrimary(ctx context.Context) bool {
+	c := consistencyOf(ctx)
+	return c != nil && (c.primary || atomic.LoadInt32(&c.wrote) == 1)
+}
+
+// wrote marks the ReadYourWrites 'ctx' as written.
+func wrote(ctx context.Context) {
+	if c := consistencyOf(ctx); c != nil {
+		atomic.StoreInt32(&c.wrote, 1)
+	}
+}
+
+func consistencyOf(ctx context.Context) *consistency {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(contextKey{}).(*consistency)
+	return c
+}
+
+// contextRepository is the ReplicaRepository bound to the context. The 'primary' and
+// 'replicas' are the repositories of the ReplicaRepository bound to the 'ctx', while
+// the health of the replicas is shared.
+type contextRepository struct {
+	r        *ReplicaRepository
+	ctx      context.Context
+	primary  repository.Repository
+	replicas []repository.Repository
+}
+
+func (c *contextRepository) Create(req interface{}) *dberrors.Error {
+	wrote(c.ctx)
+	return c.primary.Create(req)
+}
+
+func (c *contextRepository) Get(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	c.read(func(repo repository.Repository) *dberrors.Error {
+		res, dbErr = repo.Get(req)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (c *contextRepository) List(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	c.read(func(repo repository.Repository) *dberrors.Error {
+		res, dbErr = repo.List(req)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (c *contextRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (res interface{}, dbErr *dberrors.Error) {
+	c.read(func(repo repository.Repository) *dberrors.Error {
+		res, dbErr = repo.ListWithParams(req, params)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (c *contextRepository) Count(req interface{}) (count int, dbErr *dberrors.Error) {
+	c.read(func(repo repository.Repository) *dberrors.Error {
+		count, dbErr = repo.Count(req)
+		return dbErr
+	})
+	return count, dbErr
+}
+
+func (c *contextRepository) Update(req interface{}) *dberrors.Error {
+	wrote(c.ctx)
+	return c.primary.Update(req)
+}
+
+func (c *contextRepository) Patch(req, where interface{}) *dberrors.Error {
+	wrote(c.ctx)
+	return c.primary.Patch(req, where)
+}
+
+func (c *contextRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	patcher, ok := c.primary.(repository.FieldPatcher)
+	if !ok {
+		return dberrors.ErrNotSupported.NewWithMessage("Primary repository could not patch the fields")
+	}
+	wrote(c.ctx)
+	return patcher.PatchFields(req, where, fields)
+}
+
+func (c *contextRepository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (bool, *dberrors.Error) {
+	upserter, ok := c.primary.(repository.Upserter)
+	if !ok {
+		return false, dberrors.ErrNotSupported.NewWithMessage("Primary repository could not upsert")
+	}
+	wrote(c.ctx)
+	return upserter.Upsert(req, conflictFields, updateFields)
+}
+
+func (c *contextRepository) Delete(req, where interface{}) *dberrors.Error {
+	wrote(c.ctx)
+	return c.primary.Delete(req, where)
+}
+
+// read calls the 'fn' with the healthy replica. If the replica returns the ErrConnExc
+// the next one is used. If the context routes the reads to the primary or no replica
+// is available the 'fn' is called with the primary repository.
+func (c *contextRepository) read(fn func(repo repository.Repository) *dberrors.Error) {
+	r := c.r
+	if len(r.replicas) == 0 || readsPrimary(c.ctx) {
+		fn(c.primary)
+		return
+	}
+
+	now := r.now()
+	start := atomic.AddUint32(&r.next, 1) - 1
+	for i := range r.replicas {
+		index := (int(start) + i) % len(r.replicas)
+		replica := r.replicas[index]
+		if !replica.available(now, r.RetryAfter) {
+			continue
+		}
+		dbErr := fn(c.replicas[index])
+		if dbErr == nil || !dbErr.Compare(dberrors.ErrConnExc) {
+			atomic.StoreInt64(&replica.failedAt, 0)
+			return
+		}
+		atomic.StoreInt64(&replica.failedAt, now.UnixNano())
+	}
+	fn(c.primary)
+}
+
+// bindContext binds the 'ctx' to the 'repo' if it is a repository.ContextBinder.
+func bindContext(repo repository.Repository, ctx context.Context) repository.Repository {
+	if binder, ok := repo.(repository.ContextBinder); ok {
+		return binder.WithContext(ctx)
+	}
+	return repo
+}
@@ -0,0 +1,149 @@
+package replicarepo
+
+import (
+	"context"
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryAfter is the default duration for which the failed replica is not used.
+const DefaultRetryAfter = 10 * time.Second
+
+// ReplicaRepository is the Repository that writes to the primary repository and reads
+// (Get, List, ListWithParams and Count) from the read replicas. The replicas are used
+// in the round-robin manner. If the replica returns the dberrors.ErrConnExc it is not
+// used for the 'RetryAfter' duration and the read is repeated using the next replica.
+// If none of the replicas is available the primary repository is used.
+//
+// The ReplicaRepository is a repository.ContextBinder, so that the handlers.GenericHandler
+// binds the request context to its calls - the contexts created by the ReadPrimary and
+// ReadYourWrites functions route the reads to the primary repository.
+// I.e. the middleware:
+//	func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+//			next.ServeHTTP(rw, req.WithContext(replicarepo.ReadYourWrites(req.Context())))
+//		})
+//	}
+// makes the GenericHandler return the patched record from the primary repository.
+// ReplicaRepository is safe for concurrent use.
+type ReplicaRepository struct {
+	primary  repository.Repository
+	replicas []*replica
+	next     uint32
+
+	// RetryAfter - the duration for which the failed replica is not used
+	RetryAfter time.Duration
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+type replica struct {
+	repo repository.Repository
+
+	// failedAt is the unix nano time of the last connection failure or 0 if the replica is healthy
+	failedAt int64
+}
+
+// New creates the ReplicaRepository that writes to the 'primary' and reads from the 'replicas'.
+// If no replicas are provided all the calls use the primary repository.
+func New(primary repository.Repository, replicas ...repository.Repository) (*ReplicaRepository, error) {
+	if primary == nil {
+		return nil, errors.New("Nil primary repository provided.")
+	}
+	r := &ReplicaRepository{primary: primary, RetryAfter: DefaultRetryAfter, now: time.Now}
+	for _, repo := range replicas {
+		if repo == nil {
+			return nil, errors.New("Nil replica repository provided.")
+		}
+		r.replicas = append(r.replicas, &replica{repo: repo})
+	}
+	return r, nil
+}
+
+// WithRetryAfter sets the duration for which the failed replica is not used.
+// Returns given repository so it can be used in a callback manner
+func (r *ReplicaRepository) WithRetryAfter(d time.Duration) *ReplicaRepository {
+	r.RetryAfter = d
+	return r
+}
+
+// WithContext returns the repository that routes the reads using the 'ctx' created
+// by the ReadPrimary or ReadYourWrites functions. The context is bound to the primary
+// and replica repositories as well, if they are repository.ContextBinder.
+// Implements repository.ContextBinder interface.
+func (r *ReplicaRepository) WithContext(ctx context.Context) repository.Repository {
+	bound := &contextRepository{r: r, ctx: ctx, primary: bindContext(r.primary, ctx)}
+	for _, replica := range r.replicas {
+		bound.replicas = append(bound.replicas, bindContext(replica.repo, ctx))
+	}
+	return bound
+}
+
+func (r *ReplicaRepository) Create(req interface{}) *dberrors.Error {
+	return r.bound().Create(req)
+}
+
+func (r *ReplicaRepository) Get(req interface{}) (interface{}, *dberrors.Error) {
+	return r.bound().Get(req)
+}
+
+func (r *ReplicaRepository) List(req interface{}) (interface{}, *dberrors.Error) {
+	return r.bound().List(req)
+}
+
+func (r *ReplicaRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	return r.bound().ListWithParams(req, params)
+}
+
+func (r *ReplicaRepository) Count(req interface{}) (int, *dberrors.Error) {
+	return r.bound().Count(req)
+}
+
+func (r *ReplicaRepository) Update(req interface{}) *dberrors.Error {
+	return r.bound().Update(req)
+}
+
+func (r *ReplicaRepository) Patch(req, where interface{}) *dberrors.Error {
+	return r.bound().Patch(req, where)
+}
+
+// PatchFields patches the 'fields' in the primary repository, which must be
+// a repository.FieldPatcher, otherwise the dberrors.ErrNotSupported is returned.
+// Implements repository.FieldPatcher interface.
+func (r *ReplicaRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	return r.bound().PatchFields(req, where, fields)
+}
+
+// Upsert upserts the 'req' in the primary repository. The replicas are never upserted,
+// the primary which is not a repository.Upserter results in the dberrors.ErrNotSupported.
+// Implements repository.Upserter interface.
+func (r *ReplicaRepository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (bool, *dberrors.Error) {
+	return r.bound().Upsert(req, conflictFields, updateFields)
+}
+
+func (r *ReplicaRepository) Delete(req, where interface{}) *dberrors.Error {
+	return r.bound().Delete(req, where)
+}
+
+func (r *ReplicaRepository) bound() *contextRepository {
+	bound := &contextRepository{r: r, ctx: context.Background(), primary: r.primary}
+	for _, replica := range r.replicas {
+		bound.replicas = append(bound.replicas, replica.repo)
+	}
+	return bound
+}
+
+// available checks if the replica is healthy or its last failure is older than 'retryAfter'.
+func (rep *replica) available(now time.Time, retryAfter time.Duration) bool {
+	failedAt := atomic.LoadInt64(&rep.failedAt)
+	return failedAt == 0 || now.Sub(time.Unix(0, failedAt)) >= retryAfter
+}
@@ -0,0 +1,224 @@
+package replicarepo
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/errhandler"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/handlers"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/memrepo"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Foo struct {
+	ID   int
+	Name string
+}
+
+func TestReplicaRepository(t *testing.T) {
+	Convey("Subject: ReplicaRepository routes the reads to the replicas", t, func() {
+		primary, first, second := &mockrepo.MockRepository{}, &mockrepo.MockRepository{}, &mockrepo.MockRepository{}
+		repo, err := New(primary, first, second)
+		So(err, ShouldBeNil)
+
+		now := time.Now()
+		repo.now = func() time.Time { return now }
+
+		_, err = New(nil)
+		So(err, ShouldNotBeNil)
+		_, err = New(primary, nil)
+		So(err, ShouldNotBeNil)
+
+		Convey("The replicas are used in the round-robin manner", func() {
+			first.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "first"}, nil)
+			second.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "second"}, nil)
+
+			res, dbErr := repo.Get(&Foo{ID: 1})
+			So(dbErr, ShouldBeNil)
+			So(res.(*Foo).Name, ShouldEqual, "first")
+
+			res, _ = repo.Get(&Foo{ID: 1})
+			So(res.(*Foo).Name, ShouldEqual, "second")
+
+			res, _ = repo.Get(&Foo{ID: 1})
+			So(res.(*Foo).Name, ShouldEqual, "first")
+			primary.AssertNotCalled(t, "Get", &Foo{ID: 1})
+		})
+
+		Convey("The replica failing to connect is not used until the RetryAfter elapses", func() {
+			first.On("Count", &Foo{}).Return(0, dberrors.ErrConnExc.New())
+			second.On("Count", &Foo{}).Return(2, nil)
+
+			for i := 0; i < 3; i++ {
+				count, dbErr := repo.Count(&Foo{})
+				So(dbErr, ShouldBeNil)
+				So(count, ShouldEqual, 2)
+			}
+			first.AssertNumberOfCalls(t, "Count", 1)
+
+			now = now.Add(repo.RetryAfter)
+			repo.Count(&Foo{})
+			repo.Count(&Foo{})
+			first.AssertNumberOfCalls(t, "Count", 2)
+		})
+
+		Convey("The primary is used if no replica is available", func() {
+			first.On("List", &Foo{}).Return(nil, dberrors.ErrConnExc.New())
+			second.On("List", &Foo{}).Return(nil, dberrors.ErrConnExc.New())
+			primary.On("List", &Foo{}).Return([]*Foo{{ID: 1}}, nil)
+
+			res, dbErr := repo.List(&Foo{})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldHaveLength, 1)
+		})
+
+		Convey("The other errors are returned by the replica", func() {
+			first.On("Get", &Foo{ID: 2}).Return(nil, dberrors.ErrNoResult.New())
+
+			_, dbErr := repo.Get(&Foo{ID: 2})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+			primary.AssertNotCalled(t, "Get", &Foo{ID: 2})
+		})
+
+		Convey("The writes use the primary", func() {
+			primary.On("Create", &Foo{Name: "name"}).Return(nil)
+			primary.On("Delete", &Foo{}, nil).Return(nil)
+
+			So(repo.Create(&Foo{Name: "name"}), ShouldBeNil)
+			So(repo.Delete(&Foo{}, nil), ShouldBeNil)
+			first.AssertNotCalled(t, "Create", &Foo{Name: "name"})
+		})
+
+		Convey("The context routes the reads to the primary", func() {
+			primary.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "primary"}, nil)
+			first.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "first"}, nil)
+			second.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "second"}, nil)
+
+			res, _ := repo.WithContext(ReadPrimary(context.Background())).Get(&Foo{ID: 1})
+			So(res.(*Foo).Name, ShouldEqual, "primary")
+
+			Convey("ReadYourWrites uses the primary after the first write", func() {
+				bound := repo.WithContext(ReadYourWrites(context.Background()))
+				primary.On("Patch", &Foo{Name: "patched"}, &Foo{ID: 1}).Return(nil)
+
+				res, _ = bound.Get(&Foo{ID: 1})
+				So(res.(*Foo).Name, ShouldNotEqual, "primary")
+
+				So(bound.Patch(&Foo{Name: "patched"}, &Foo{ID: 1}), ShouldBeNil)
+				res, _ = bound.Get(&Foo{ID: 1})
+				So(res.(*Foo).Name, ShouldEqual, "primary")
+			})
+		})
+
+		Convey("GenericHandler binds the request context", func() {
+			handler, err := handlers.New(repo, errhandler.New(), nil, nil)
+			So(err, ShouldBeNil)
+
+			primary.On("Patch", &Foo{Name: "patched"}, &Foo{}).Return(nil)
+			primary.On("Get", &Foo{}).Return(&Foo{ID: 1, Name: "patched"}, nil)
+
+			req := httptest.NewRequest("PATCH", "/foos/1", strings.NewReader(`{"Name":"patched"}`))
+			req = req.WithContext(ReadYourWrites(req.Context()))
+
+			rw := httptest.NewRecorder()
+			handler.Patch(Foo{})(rw, req)
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Body.String(), ShouldContainSubstring, "patched")
+			first.AssertNotCalled(t, "Get", &Foo{})
+			second.AssertNotCalled(t, "Get", &Foo{})
+		})
+	})
+}
+
+// binderRepository is the repository.ContextBinder recording the bound contexts.
+type binderRepository struct {
+	repository.Repository
+	bound *[]context.Context
+}
+
+func (b *binderRepository) WithContext(ctx context.Context) repository.Repository {
+	*b.bound = append(*b.bound, ctx)
+	return b.Repository
+}
+
+func TestReplicaRepositoryContext(t *testing.T) {
+	Convey("Subject: ReplicaRepository binds the context to the decorated repositories", t, func() {
+		var bound []context.Context
+		primary, replica := &mockrepo.MockRepository{}, &mockrepo.MockRepository{}
+		repo, err := New(&binderRepository{Repository: primary, bound: &bound},
+			&binderRepository{Repository: replica, bound: &bound})
+		So(err, ShouldBeNil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		withContext := repo.WithContext(ctx)
+		So(bound, ShouldHaveLength, 2)
+		So(bound[0], ShouldEqual, ctx)
+		So(bound[1], ShouldEqual, ctx)
+
+		primary.On("Create", &Foo{Name: "name"}).Return(nil)
+		replica.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1, Name: "replica"}, nil)
+
+		So(withContext.Create(&Foo{Name: "name"}), ShouldBeNil)
+		res, dbErr := withContext.Get(&Foo{ID: 1})
+		So(dbErr, ShouldBeNil)
+		So(res.(*Foo).Name, ShouldEqual, "replica")
+		primary.AssertCalled(t, "Create", &Foo{Name: "name"})
+	})
+}
+
+func TestReplicaRepositoryExtensions(t *testing.T) {
+	Convey("Subject: ReplicaRepository of the primary without the optional interfaces", t, func() {
+		primary := memrepo.New()
+		repo, err := New(struct{ repository.Repository }{primary})
+		So(err, ShouldBeNil)
+
+		_, dbErr := repo.Upsert(&Foo{ID: 7}, nil, nil)
+		So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+		dbErr = repo.PatchFields(&Foo{}, &Foo{ID: 7}, []string{"Name"})
+		So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+
+		handler, err := handlers.New(repo, errhandler.New(), nil, nil)
+		So(err, ShouldBeNil)
+		handler.WithPutSemantics(true).
+			WithURLParams(true).
+			WithParamPolicy(forms.DefaultParamPolicy.Copy()).
+			WithParamGetterFunc(func(param string, req *http.Request) (string, error) {
+				if param == "foo" {
+					return "7", nil
+				}
+				return "", nil
+			})
+
+		Convey("The PUT falls back to the Update", func() {
+			rw := httptest.NewRecorder()
+			handler.Update(Foo{})(rw, httptest.NewRequest("PUT", "/foos/7", strings.NewReader(`{"Name":"name"}`)))
+			So(rw.Code, ShouldEqual, 201)
+
+			rw = httptest.NewRecorder()
+			handler.Update(Foo{})(rw, httptest.NewRequest("PUT", "/foos/7", strings.NewReader(`{"Name":"other"}`)))
+			So(rw.Code, ShouldEqual, 200)
+
+			stored, dbErr := primary.Get(&Foo{ID: 7})
+			So(dbErr, ShouldBeNil)
+			So(stored, ShouldResemble, &Foo{ID: 7, Name: "other"})
+		})
+
+		Convey("The patch documents are not supported", func() {
+			So(primary.Create(&Foo{ID: 7}), ShouldBeNil)
+
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/foos/7", strings.NewReader(`{"Name":"name"}`))
+			req.Header.Set("Content-Type", forms.MergePatchMediaType)
+			handler.Patch(Foo{})(rw, req)
+			So(rw.Code, ShouldEqual, 415)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"github.com/kucjac/go-rest-sdk/dberrors"
+	"strconv"
 )
 
 type Repository interface {
@@ -30,7 +31,7 @@ type Repository interface {
 	// List search and list all objects of given type
 	// It extends the List method by providing query parameters.
 	// Using list parameters allows i.e. paginate the results or specify order of the query
-	// By providing 'IDs' field all entries with given ID's would be queried
+	// By providing 'IDs' or 'Keys' field all entries with given ID's would be queried
 	ListWithParams(req interface{}, params *ListParameters) (res interface{}, err *dberrors.Error)
 
 	// Count returns the number of record defined by 'req' argument
@@ -53,18 +54,34 @@ type Repository interface {
 }
 
 // List Parameters contains fields common for queries
+// The IDs are the numeric primary keys, where the Keys are the string values of the models
+// primary keys, so that the non-numeric (i.e. UUID or slug) primary keys may be queried too.
 type ListParameters struct {
-	IDs    []int  `form:"ids"`
-	Limit  int    `form:"limit"`
-	Offset int    `form:"offset"`
-	Order  string `form:"order"`
+	IDs    []int    `form:"ids"`
+	Keys   []string `form:"keys"`
+	Limit  int      `form:"limit"`
+	Offset int      `form:"offset"`
+	Order  string   `form:"order"`
 }
 
 // ContainsParameters checks whether given 'ListParameters'
 // Has any parameters of non-zero value
 func (l ListParameters) ContainsParameters() bool {
-	if l.Limit != 0 || l.Offset != 0 || l.Order != "" || len(l.IDs) != 0 {
+	if l.Limit != 0 || l.Offset != 0 || l.Order != "" || len(l.IDs) != 0 || len(l.Keys) != 0 {
 		return true
 	}
 	return false
 }
+
+// PrimaryKeys returns the string values of both the IDs and the Keys.
+// Returns nil if none of them is set.
+func (l ListParameters) PrimaryKeys() []string {
+	if len(l.IDs) == 0 && len(l.Keys) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(l.IDs)+len(l.Keys))
+	for _, id := range l.IDs {
+		keys = append(keys, strconv.Itoa(id))
+	}
+	return append(keys, l.Keys...)
+}
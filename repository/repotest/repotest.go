@@ -0,0 +1,375 @@
+// Package repotest contains the conformance test suite of the repository.Repository
+// implementations. The suite checks the documented semantics of the Repository methods,
+// so that any backend could be verified in its tests:
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T, models ...interface{}) repository.Repository {
+//			db := openEmptyDatabase(t)
+//			db.AutoMigrate(models...)
+//			repo, err := gormrepo.New(db)
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			return repo
+//		})
+//	}
+package repotest
+
+import (
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Factory creates the new, empty repository that stores the 'models'.
+// Each test of the suite gets its own repository.
+type Factory func(t *testing.T, models ...interface{}) repository.Repository
+
+// Model is the model stored by the repositories in the conformance tests.
+type Model struct {
+	ID   uint
+	Name string
+	Age  int
+}
+
+// Slug is the model with the non-numeric primary key.
+type Slug struct {
+	Code string `gorm:"primary_key"`
+	Name string
+}
+
+// Run runs the conformance suite against the repositories created by the 'factory'.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repository.Repository)
+	}{
+		{"Create", testCreate},
+		{"Get", testGet},
+		{"List", testList},
+		{"ListWithParams", testListWithParams},
+		{"Count", testCount},
+		{"Update", testUpdate},
+		{"Patch", testPatch},
+		{"Delete", testDelete},
+		{"FieldPatcher", testFieldPatcher},
+	}
+	for _, tc := range tests {
+		test := tc.test
+		t.Run(tc.name, func(t *testing.T) {
+			test(t, factory(t, &Model{}, &Slug{}))
+		})
+	}
+}
+
+// fixtures creates the models with the names: 'john', 'jane', 'john', 'adam'
+// and ages 20, 21, 22, 23.
+func fixtures(t *testing.T, repo repository.Repository) []*Model {
+	t.Helper()
+	var models []*Model
+	for i, name := range []string{"john", "jane", "john", "adam"} {
+		model := &Model{Name: name, Age: 20 + i}
+		if dbErr := repo.Create(model); dbErr != nil {
+			t.Fatalf("Create(%+v) failed: %v", model, dbErr)
+		}
+		models = append(models, model)
+	}
+	return models
+}
+
+func testCreate(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+	if models[0].ID == 0 || models[0].ID == models[1].ID {
+		t.Errorf("Create should set the unique primary keys, got: %d and %d", models[0].ID, models[1].ID)
+	}
+
+	res, dbErr := repo.Get(&Model{ID: models[1].ID})
+	if dbErr != nil {
+		t.Fatalf("Get of the created model failed: %v", dbErr)
+	}
+	if !reflect.DeepEqual(res, models[1]) {
+		t.Errorf("Get of the created model returned: %+v, expected: %+v", res, models[1])
+	}
+
+	dbErr = repo.Create(&Model{ID: models[0].ID, Name: "duplicate"})
+	expectError(t, "Create with the duplicated primary key", dbErr, dberrors.ErrUniqueViolation)
+
+	if dbErr = repo.Create(&Slug{Code: "code", Name: "name"}); dbErr != nil {
+		t.Fatalf("Create of the non-numeric primary key model failed: %v", dbErr)
+	}
+	dbErr = repo.Create(&Slug{Code: "code", Name: "other"})
+	expectError(t, "Create with the duplicated non-numeric primary key", dbErr, dberrors.ErrUniqueViolation)
+}
+
+func testGet(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	res, dbErr := repo.Get(&Model{Name: "john"})
+	if dbErr != nil {
+		t.Fatalf("Get with partial fields failed: %v", dbErr)
+	}
+	if !reflect.DeepEqual(res, models[0]) {
+		t.Errorf("Get with partial fields should return the first match: %+v, got: %+v", models[0], res)
+	}
+
+	res, dbErr = repo.Get(&Model{Name: "john", Age: 22})
+	if dbErr != nil {
+		t.Fatalf("Get with multiple fields failed: %v", dbErr)
+	}
+	if !reflect.DeepEqual(res, models[2]) {
+		t.Errorf("Get with multiple fields should return: %+v, got: %+v", models[2], res)
+	}
+
+	_, dbErr = repo.Get(&Model{Name: "john", Age: 21})
+	expectError(t, "Get of not existing model", dbErr, dberrors.ErrNoResult)
+}
+
+func testList(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	res, dbErr := repo.List(&Model{Name: "john"})
+	if dbErr != nil {
+		t.Fatalf("List failed: %v", dbErr)
+	}
+	expectModels(t, "List with partial fields", res, models[0], models[2])
+
+	res, dbErr = repo.List(&Model{})
+	if dbErr != nil {
+		t.Fatalf("List of all models failed: %v", dbErr)
+	}
+	expectModels(t, "List of all models", res, models...)
+
+	res, dbErr = repo.List(&Model{Name: "none"})
+	if dbErr != nil {
+		t.Fatalf("List without results should not fail: %v", dbErr)
+	}
+	expectModels(t, "List without results", res)
+}
+
+func testListWithParams(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	res, dbErr := repo.ListWithParams(&Model{}, nil)
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with nil params failed: %v", dbErr)
+	}
+	expectModels(t, "ListWithParams with nil params", res, models...)
+
+	res, dbErr = repo.ListWithParams(&Model{}, &repository.ListParameters{Limit: 2, Order: "age desc"})
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with limit failed: %v", dbErr)
+	}
+	expectOrderedModels(t, "ListWithParams with limit and order", res, models[3], models[2])
+
+	res, dbErr = repo.ListWithParams(&Model{}, &repository.ListParameters{Limit: 2, Offset: 1, Order: "age"})
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with offset failed: %v", dbErr)
+	}
+	expectOrderedModels(t, "ListWithParams with offset", res, models[1], models[2])
+
+	ids := []int{int(models[1].ID), int(models[2].ID)}
+	res, dbErr = repo.ListWithParams(&Model{}, &repository.ListParameters{IDs: ids})
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with IDs failed: %v", dbErr)
+	}
+	expectModels(t, "ListWithParams with IDs", res, models[1], models[2])
+
+	keys := []string{fmt.Sprint(models[2].ID)}
+	res, dbErr = repo.ListWithParams(&Model{Name: "john"}, &repository.ListParameters{IDs: ids[:1], Keys: keys})
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with IDs and fields failed: %v", dbErr)
+	}
+	expectModels(t, "ListWithParams with IDs and fields", res, models[2])
+
+	for _, slug := range []*Slug{{Code: "a"}, {Code: "b"}, {Code: "c"}} {
+		if dbErr := repo.Create(slug); dbErr != nil {
+			t.Fatalf("Create(%+v) failed: %v", slug, dbErr)
+		}
+	}
+	res, dbErr = repo.ListWithParams(&Slug{}, &repository.ListParameters{Keys: []string{"a", "c"}})
+	if dbErr != nil {
+		t.Fatalf("ListWithParams with non-numeric IDs failed: %v", dbErr)
+	}
+	slugs := reflect.ValueOf(res)
+	if slugs.Kind() != reflect.Slice || slugs.Len() != 2 {
+		t.Errorf("ListWithParams with non-numeric IDs should return 2 slugs, got: %+v", res)
+	}
+}
+
+func testCount(t *testing.T, repo repository.Repository) {
+	fixtures(t, repo)
+
+	tests := []struct {
+		where *Model
+		count int
+	}{
+		{&Model{}, 4},
+		{&Model{Name: "john"}, 2},
+		{&Model{Name: "john", Age: 22}, 1},
+		{&Model{Name: "none"}, 0},
+	}
+	for _, test := range tests {
+		count, dbErr := repo.Count(test.where)
+		if dbErr != nil {
+			t.Fatalf("Count(%+v) failed: %v", test.where, dbErr)
+		}
+		if count != test.count {
+			t.Errorf("Count(%+v) should return: %d, got: %d", test.where, test.count, count)
+		}
+	}
+}
+
+func testUpdate(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	updated := &Model{ID: models[1].ID, Name: "updated"}
+	if dbErr := repo.Update(updated); dbErr != nil {
+		t.Fatalf("Update failed: %v", dbErr)
+	}
+	res, dbErr := repo.Get(&Model{ID: models[1].ID})
+	if dbErr != nil {
+		t.Fatalf("Get of the updated model failed: %v", dbErr)
+	}
+	if !reflect.DeepEqual(res, updated) {
+		t.Errorf("Update should replace the whole model: %+v, got: %+v", updated, res)
+	}
+
+	created := &Model{Name: "created"}
+	if dbErr = repo.Update(created); dbErr != nil {
+		t.Fatalf("Update without primary key failed: %v", dbErr)
+	}
+	if created.ID == 0 {
+		t.Errorf("Update without primary key should create the model")
+	}
+	expectCount(t, "Update without primary key", repo, &Model{}, 5)
+}
+
+func testPatch(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	if dbErr := repo.Patch(&Model{Age: 30}, &Model{Name: "john"}); dbErr != nil {
+		t.Fatalf("Patch failed: %v", dbErr)
+	}
+	expectCount(t, "Patch with where", repo, &Model{Name: "john", Age: 30}, 2)
+
+	res, dbErr := repo.Get(&Model{ID: models[1].ID})
+	if dbErr != nil {
+		t.Fatalf("Get of the not patched model failed: %v", dbErr)
+	}
+	if !reflect.DeepEqual(res, models[1]) {
+		t.Errorf("Patch should not change the not matching models: %+v, got: %+v", models[1], res)
+	}
+
+	if dbErr := repo.Patch(&Model{Name: "patched"}, &Model{ID: models[0].ID}); dbErr != nil {
+		t.Fatalf("Patch of the single model failed: %v", dbErr)
+	}
+	res, dbErr = repo.Get(&Model{ID: models[0].ID})
+	if dbErr != nil {
+		t.Fatalf("Get of the patched model failed: %v", dbErr)
+	}
+	if patched := res.(*Model); patched.Name != "patched" || patched.Age != 30 {
+		t.Errorf("Patch should set only the non-zero fields, got: %+v", patched)
+	}
+
+	dbErr = repo.Patch(&Model{Age: 40}, &Model{Name: "none"})
+	expectError(t, "Patch without matching models", dbErr, dberrors.ErrNoResult)
+
+	if dbErr := repo.Patch(&Model{Age: 50}, nil); dbErr != nil {
+		t.Fatalf("Patch with nil where failed: %v", dbErr)
+	}
+	expectCount(t, "Patch with nil where", repo, &Model{Age: 50}, 4)
+}
+
+func testDelete(t *testing.T, repo repository.Repository) {
+	models := fixtures(t, repo)
+
+	if dbErr := repo.Delete(&Model{}, &Model{Name: "john"}); dbErr != nil {
+		t.Fatalf("Delete failed: %v", dbErr)
+	}
+	expectCount(t, "Delete with where", repo, &Model{}, 2)
+
+	_, dbErr := repo.Get(&Model{ID: models[0].ID})
+	expectError(t, "Get of the deleted model", dbErr, dberrors.ErrNoResult)
+
+	dbErr = repo.Delete(&Model{}, &Model{Name: "john"})
+	expectError(t, "Delete without matching models", dbErr, dberrors.ErrNoResult)
+
+	if dbErr = repo.Delete(&Model{}, nil); dbErr != nil {
+		t.Fatalf("Delete with nil where failed: %v", dbErr)
+	}
+	expectCount(t, "Delete with nil where", repo, &Model{}, 0)
+}
+
+func testFieldPatcher(t *testing.T, repo repository.Repository) {
+	patcher, ok := repo.(repository.FieldPatcher)
+	if !ok {
+		t.Skip("The repository does not implement the repository.FieldPatcher")
+	}
+	models := fixtures(t, repo)
+
+	if dbErr := patcher.PatchFields(&Model{Age: 0}, &Model{Name: "john"}, []string{"Age"}); dbErr != nil {
+		t.Fatalf("PatchFields failed: %v", dbErr)
+	}
+	expectCount(t, "PatchFields with zero value", repo, &Model{Name: "john", Age: 0}, 2)
+	expectCount(t, "PatchFields with zero value", repo, &Model{Age: models[1].Age}, 1)
+
+	dbErr := patcher.PatchFields(&Model{Age: 1}, &Model{Name: "none"}, []string{"Age"})
+	expectError(t, "PatchFields without matching models", dbErr, dberrors.ErrNoResult)
+}
+
+func expectError(t *testing.T, operation string, dbErr *dberrors.Error, proto dberrors.Error) {
+	t.Helper()
+	if dbErr == nil {
+		t.Errorf("%s should return the %s error", operation, proto.Title)
+		return
+	}
+	if !dbErr.Compare(proto) {
+		t.Errorf("%s should return the %s error, got: %v", operation, proto.Title, dbErr)
+	}
+}
+
+func expectCount(t *testing.T, operation string, repo repository.Repository, where *Model, expected int) {
+	t.Helper()
+	res, dbErr := repo.List(where)
+	if dbErr != nil {
+		t.Fatalf("%s: List(%+v) failed: %v", operation, where, dbErr)
+	}
+	if count := reflect.ValueOf(res).Len(); count != expected {
+		t.Errorf("%s: expected %d models matching: %+v, got: %d", operation, expected, where, count)
+	}
+}
+
+// expectModels checks if the 'res' contains the 'expected' models in any order.
+func expectModels(t *testing.T, operation string, res interface{}, expected ...*Model) {
+	t.Helper()
+	models, ok := res.([]*Model)
+	if !ok {
+		t.Errorf("%s should return []*Model, got: %T", operation, res)
+		return
+	}
+	sorted := make([]*Model, len(models))
+	copy(sorted, models)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	expectOrderedModels(t, operation, sorted, expected...)
+}
+
+// expectOrderedModels checks if the 'res' contains the 'expected' models in the same order.
+func expectOrderedModels(t *testing.T, operation string, res interface{}, expected ...*Model) {
+	t.Helper()
+	models, ok := res.([]*Model)
+	if !ok {
+		t.Errorf("%s should return []*Model, got: %T", operation, res)
+		return
+	}
+	if len(models) != len(expected) {
+		t.Errorf("%s should return %d models, got: %d", operation, len(expected), len(models))
+		return
+	}
+	for i := range models {
+		if !reflect.DeepEqual(models[i], expected[i]) {
+			t.Errorf("%s returned: %+v at %d, expected: %+v", operation, models[i], i, expected[i])
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package retryrepo
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"reflect"
+)
+
+// boundRepository retries the calls of the 'repo' until the 'ctx' is done.
+type boundRepository struct {
+	r    *RetryRepository
+	repo repository.Repository
+	ctx  context.Context
+}
+
+func (b *boundRepository) Create(req interface{}) (dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, false, func() *dberrors.Error {
+		dbErr = b.repo.Create(req)
+		return dbErr
+	})
+	return dbErr
+}
+
+func (b *boundRepository) Get(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		res, dbErr = b.repo.Get(req)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (b *boundRepository) List(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		res, dbErr = b.repo.List(req)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (b *boundRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (res interface{}, dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		res, dbErr = b.repo.ListWithParams(req, params)
+		return dbErr
+	})
+	return res, dbErr
+}
+
+func (b *boundRepository) Count(req interface{}) (count int, dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		count, dbErr = b.repo.Count(req)
+		return dbErr
+	})
+	return count, dbErr
+}
+
+// Update is idempotent only for the 'req' with the primary key set,
+// as the model without the primary key is created by the Update.
+func (b *boundRepository) Update(req interface{}) (dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, hasPrimaryKey(req), func() *dberrors.Error {
+		dbErr = b.repo.Update(req)
+		return dbErr
+	})
+	return dbErr
+}
+
+func (b *boundRepository) Patch(req, where interface{}) (dbErr *dberrors.Error) {
+	b.r.retry(b.ctx, false, func() *dberrors.Error {
+		dbErr = b.repo.Patch(req, where)
+		return dbErr
+	})
+	return dbErr
+}
+
+func (b *boundRepository) PatchFields(req, where interface{}, fields []string) (dbErr *dberrors.Error) {
+	patcher, ok := b.repo.(repository.FieldPatcher)
+	if !ok {
+		return dberrors.ErrNotSupported.NewWithMessage("Repository could not patch the fields")
+	}
+	b.r.retry(b.ctx, false, func() *dberrors.Error {
+		dbErr = patcher.PatchFields(req, where, fields)
+		return dbErr
+	})
+	return dbErr
+}
+
+func (b *boundRepository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (created bool, dbErr *dberrors.Error) {
+	upserter, ok := b.repo.(repository.Upserter)
+	if !ok {
+		return false, dberrors.ErrNotSupported.NewWithMessage("Repository could not upsert")
+	}
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		created, dbErr = upserter.Upsert(req, conflictFields, updateFields)
+		return dbErr
+	})
+	return created, dbErr
+}
+
+// Delete retries the transient errors. The previous attempt might have deleted the records
+// before its error was returned, thus the ErrNoResult of the retried attempt is the success.
+func (b *boundRepository) Delete(req, where interface{}) (dbErr *dberrors.Error) {
+	var retried bool
+	b.r.retry(b.ctx, true, func() *dberrors.Error {
+		dbErr = b.repo.Delete(req, where)
+		if retried && dbErr != nil && dbErr.Compare(dberrors.ErrNoResult) {
+			dbErr = nil
+		}
+		retried = true
+		return dbErr
+	})
+	return dbErr
+}
+
+// hasPrimaryKey checks if the primary key of the 'req' model is set.
+func hasPrimaryKey(req interface{}) bool {
+	field, ok := forms.PrimaryKeyField(reflect.TypeOf(req))
+	if !ok {
+		return false
+	}
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	pk := v.FieldByIndex(field.Index)
+	return !reflect.DeepEqual(pk.Interface(), reflect.Zero(pk.Type()).Interface())
+}
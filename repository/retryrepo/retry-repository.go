@@ -0,0 +1,197 @@
+package retryrepo
+
+import (
+	"context"
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"math/rand"
+	"time"
+)
+
+// Policy defines when and how the repository calls are retried.
+type Policy struct {
+	// MaxAttempts is the maximum number of the calls, including the first one
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each next retry
+	BaseDelay time.Duration
+
+	// MaxDelay limits the delay between the retries
+	MaxDelay time.Duration
+
+	// Retryable checks if the call returning the error may be retried.
+	// If nil the IsRetryable function is used
+	Retryable func(dbErr *dberrors.Error) bool
+
+	// RetryNonIdempotent enables retrying the Create, Patch and PatchFields calls and
+	// the Update of the model without the primary key. The call failing with the connection
+	// exception may have been applied, so that its retry could i.e. create the record twice
+	RetryNonIdempotent bool
+}
+
+// DefaultPolicy is the Policy used by the RetryRepository if none is provided.
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    time.Second,
+}
+
+// IsRetryable checks if the 'dbErr' is transient, so that the call may succeed if retried.
+// The connection exceptions (dberrors.ErrConnExc) and the transaction rollbacks
+// (dberrors.ErrTransRollback) are retryable. The postgres serialization failures (40001)
+// and deadlocks (40P01), as well as the mysql deadlocks and lock timeouts, are converted
+// into the ErrTransRollback.
+func IsRetryable(dbErr *dberrors.Error) bool {
+	return dbErr.Compare(dberrors.ErrConnExc) || dbErr.Compare(dberrors.ErrTransRollback)
+}
+
+// RetryRepository is the repository decorator that retries the calls failing with
+// the transient errors. The delay between the retries grows exponentially and is randomized
+// (jitter), so that the concurrent calls are not retried at once.
+// By default only the idempotent calls are retried: Get, List, ListWithParams, Count,
+// Upsert, Delete and the Update of the model with the primary key set. The ErrNoResult
+// of the retried Delete is not returned, as the deleting attempt might have failed after
+// its commit.
+//
+// The RetryRepository is a repository.ContextBinder - the retries of the repository bound
+// to the context stop when the context is done. The context is bound to the decorated
+// repository as well, if it is a repository.ContextBinder.
+type RetryRepository struct {
+	repo   repository.Repository
+	policy Policy
+
+	// sleep waits for the delay or until the context is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+// New creates the RetryRepository that decorates the 'repo' with given 'policy'.
+// If the 'policy' is nil the DefaultPolicy is used.
+func New(repo repository.Repository, policy *Policy) (*RetryRepository, error) {
+	if repo == nil {
+		return nil, errors.New("Nil repository provided.")
+	}
+	r := &RetryRepository{repo: repo, policy: DefaultPolicy, sleep: sleep}
+	if policy != nil {
+		r.policy = *policy
+	}
+	if r.policy.MaxAttempts < 1 {
+		r.policy.MaxAttempts = 1
+	}
+	if r.policy.Retryable == nil {
+		r.policy.Retryable = IsRetryable
+	}
+	return r, nil
+}
+
+// WithContext returns the repository which retries stop when the 'ctx' is done.
+// Implements repository.ContextBinder interface.
+func (r *RetryRepository) WithContext(ctx context.Context) repository.Repository {
+	repo := r.repo
+	if binder, ok := repo.(repository.ContextBinder); ok {
+		repo = binder.WithContext(ctx)
+	}
+	return &boundRepository{r: r, repo: repo, ctx: ctx}
+}
+
+func (r *RetryRepository) Create(req interface{}) *dberrors.Error {
+	return r.bound().Create(req)
+}
+
+func (r *RetryRepository) Get(req interface{}) (interface{}, *dberrors.Error) {
+	return r.bound().Get(req)
+}
+
+func (r *RetryRepository) List(req interface{}) (interface{}, *dberrors.Error) {
+	return r.bound().List(req)
+}
+
+func (r *RetryRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	return r.bound().ListWithParams(req, params)
+}
+
+func (r *RetryRepository) Count(req interface{}) (int, *dberrors.Error) {
+	return r.bound().Count(req)
+}
+
+func (r *RetryRepository) Update(req interface{}) *dberrors.Error {
+	return r.bound().Update(req)
+}
+
+func (r *RetryRepository) Patch(req, where interface{}) *dberrors.Error {
+	return r.bound().Patch(req, where)
+}
+
+// PatchFields patches the 'fields' without retries, as the patch is not idempotent.
+// The decorated repository that is not a repository.FieldPatcher results in
+// the dberrors.ErrNotSupported. Implements repository.FieldPatcher interface.
+func (r *RetryRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	return r.bound().PatchFields(req, where, fields)
+}
+
+// Upsert upserts the 'req' retrying the transient errors. If the decorated repository
+// could not upsert the dberrors.ErrNotSupported is returned without retries.
+// The failed attempt might have inserted the record before its error was returned,
+// thus the retried attempt reports the record as not created.
+// Implements repository.Upserter interface.
+func (r *RetryRepository) Upsert(req interface{}, conflictFields, updateFields []string) (bool, *dberrors.Error) {
+	return r.bound().Upsert(req, conflictFields, updateFields)
+}
+
+func (r *RetryRepository) Delete(req, where interface{}) *dberrors.Error {
+	return r.bound().Delete(req, where)
+}
+
+func (r *RetryRepository) bound() *boundRepository {
+	return &boundRepository{r: r, repo: r.repo, ctx: context.Background()}
+}
+
+// retry calls the 'fn' until it succeeds, returns not retryable error or the attempts
+// are exhausted. The non idempotent calls are retried only if the policy enables it.
+func (r *RetryRepository) retry(ctx context.Context, idempotent bool, fn func() *dberrors.Error) {
+	attempts := r.policy.MaxAttempts
+	if !idempotent && !r.policy.RetryNonIdempotent {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		dbErr := fn()
+		if dbErr == nil || attempt >= attempts || !r.policy.Retryable(dbErr) {
+			return
+		}
+		if !r.sleep(ctx, r.delay(attempt)) {
+			return
+		}
+	}
+}
+
+// delay returns the randomized delay before the retry following the 'attempt'.
+// The delay is between the half and the whole of the exponential backoff.
+func (r *RetryRepository) delay(attempt int) time.Duration {
+	backoff := r.policy.BaseDelay
+	for i := 1; i < attempt && backoff < r.policy.MaxDelay; i++ {
+		backoff *= 2
+	}
+	if r.policy.MaxDelay > 0 && backoff > r.policy.MaxDelay {
+		backoff = r.policy.MaxDelay
+	}
+	if backoff <= 1 {
+		return backoff
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)))
+}
+
+// sleep waits for the 'd' duration. Returns false if the 'ctx' was done before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+package retryrepo
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/dberrors/pgconv"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/lib/pq"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+type Foo struct {
+	ID   int
+	Name string
+}
+
+func TestIsRetryable(t *testing.T) {
+	Convey("Subject: Classifying the transient database errors", t, func() {
+		converter := pgconv.New()
+		So(IsRetryable(converter.Convert(&pq.Error{Code: "40001"})), ShouldBeTrue)
+		So(IsRetryable(converter.Convert(&pq.Error{Code: "40P01"})), ShouldBeTrue)
+		So(IsRetryable(converter.Convert(&pq.Error{Code: "08006"})), ShouldBeTrue)
+		So(IsRetryable(converter.Convert(&pq.Error{Code: "23505"})), ShouldBeFalse)
+		So(IsRetryable(dberrors.ErrNoResult.New()), ShouldBeFalse)
+	})
+}
+
+func TestRetryRepository(t *testing.T) {
+	Convey("Subject: RetryRepository retries the transient errors", t, func() {
+		mock := &mockrepo.MockRepository{}
+		repo, err := New(mock, nil)
+		So(err, ShouldBeNil)
+
+		var delays []time.Duration
+		repo.sleep = func(ctx context.Context, d time.Duration) bool {
+			delays = append(delays, d)
+			return ctx.Err() == nil
+		}
+
+		_, err = New(nil, nil)
+		So(err, ShouldNotBeNil)
+
+		Convey("The idempotent calls are retried until they succeed", func() {
+			mock.On("Get", &Foo{ID: 1}).Return(nil, dberrors.ErrConnExc.New()).Once()
+			mock.On("Get", &Foo{ID: 1}).Return(&Foo{ID: 1}, nil).Once()
+
+			res, dbErr := repo.Get(&Foo{ID: 1})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldResemble, &Foo{ID: 1})
+			So(delays, ShouldHaveLength, 1)
+		})
+
+		Convey("The attempts are limited by the policy", func() {
+			mock.On("Count", &Foo{}).Return(0, dberrors.ErrTransRollback.New())
+
+			_, dbErr := repo.Count(&Foo{})
+			So(dbErr.Compare(dberrors.ErrTransRollback), ShouldBeTrue)
+			mock.AssertNumberOfCalls(t, "Count", DefaultPolicy.MaxAttempts)
+		})
+
+		Convey("The other errors are not retried", func() {
+			mock.On("Delete", &Foo{}, &Foo{ID: 1}).Return(dberrors.ErrNoResult.New())
+
+			dbErr := repo.Delete(&Foo{}, &Foo{ID: 1})
+			So(dbErr.Compare(dberrors.ErrNoResult), ShouldBeTrue)
+			mock.AssertNumberOfCalls(t, "Delete", 1)
+		})
+
+		Convey("The non idempotent calls are retried only if configured", func() {
+			mock.On("Create", &Foo{Name: "name"}).Return(dberrors.ErrConnExc.New())
+
+			So(repo.Create(&Foo{Name: "name"}), ShouldNotBeNil)
+			mock.AssertNumberOfCalls(t, "Create", 1)
+
+			repo.policy.RetryNonIdempotent = true
+			So(repo.Create(&Foo{Name: "name"}), ShouldNotBeNil)
+			mock.AssertNumberOfCalls(t, "Create", 1+DefaultPolicy.MaxAttempts)
+		})
+
+		Convey("The optional interfaces of the plain repository are not supported", func() {
+			plain, err := New(struct{ repository.Repository }{mock}, nil)
+			So(err, ShouldBeNil)
+
+			_, dbErr := plain.Upsert(&Foo{ID: 1}, nil, nil)
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+			dbErr = plain.PatchFields(&Foo{}, &Foo{ID: 1}, []string{"Name"})
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+			So(delays, ShouldBeEmpty)
+		})
+
+		Convey("The Update of the model without the primary key is not idempotent", func() {
+			mock.On("Update", &Foo{Name: "name"}).Return(dberrors.ErrConnExc.New())
+			mock.On("Update", &Foo{ID: 1, Name: "name"}).Return(dberrors.ErrConnExc.New())
+
+			So(repo.Update(&Foo{Name: "name"}), ShouldNotBeNil)
+			mock.AssertNumberOfCalls(t, "Update", 1)
+
+			So(repo.Update(&Foo{ID: 1, Name: "name"}), ShouldNotBeNil)
+			mock.AssertNumberOfCalls(t, "Update", 1+DefaultPolicy.MaxAttempts)
+		})
+
+		Convey("The retried Delete of the already deleted record succeeds", func() {
+			mock.On("Delete", &Foo{}, &Foo{ID: 1}).Return(dberrors.ErrConnExc.New()).Once()
+			mock.On("Delete", &Foo{}, &Foo{ID: 1}).Return(dberrors.ErrNoResult.New()).Once()
+
+			So(repo.Delete(&Foo{}, &Foo{ID: 1}), ShouldBeNil)
+			mock.AssertNumberOfCalls(t, "Delete", 2)
+		})
+
+		Convey("The retries stop when the bound context is done", func() {
+			mock.On("List", &Foo{}).Return(nil, dberrors.ErrConnExc.New())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, dbErr := repo.WithContext(ctx).List(&Foo{})
+			So(dbErr.Compare(dberrors.ErrConnExc), ShouldBeTrue)
+			mock.AssertNumberOfCalls(t, "List", 1)
+		})
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	Convey("Subject: The exponential backoff with jitter", t, func() {
+		repo, _ := New(&mockrepo.MockRepository{}, &Policy{
+			MaxAttempts: 5,
+			BaseDelay:   100 * time.Millisecond,
+			MaxDelay:    300 * time.Millisecond,
+		})
+
+		for i := 0; i < 20; i++ {
+			So(repo.delay(1), ShouldBeBetweenOrEqual, 50*time.Millisecond, 100*time.Millisecond)
+			So(repo.delay(2), ShouldBeBetweenOrEqual, 100*time.Millisecond, 200*time.Millisecond)
+			So(repo.delay(4), ShouldBeBetweenOrEqual, 150*time.Millisecond, 300*time.Millisecond)
+		}
+
+		Convey("The real sleep stops when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			So(sleep(ctx, time.Hour), ShouldBeFalse)
+			So(sleep(context.Background(), time.Millisecond), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package sqlrepo
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/dberrors/mysqlconv"
+	"github.com/kucjac/go-rest-sdk/dberrors/pgconv"
+	"github.com/kucjac/go-rest-sdk/dberrors/sqliteconv"
+	"strconv"
+	"strings"
+)
+
+// Dialect defines the SQL syntax of the database used by the SQLRepository.
+type Dialect int
+
+// Following dialects are supported by the SQLRepository
+const (
+	// Postgres uses the '$1' placeholders and the 'RETURNING' clause
+	Postgres Dialect = iota
+
+	// MySQL uses the '?' placeholders and the backtick quoted identifiers
+	MySQL
+
+	// SQLite uses the '?' placeholders
+	SQLite
+)
+
+var dialectNames = []string{
+	"postgres",
+	"mysql",
+	"sqlite3",
+}
+
+func (d Dialect) String() string {
+	if !d.valid() {
+		return "unknown"
+	}
+	return dialectNames[d]
+}
+
+func (d Dialect) valid() bool {
+	return d >= Postgres && d <= SQLite
+}
+
+// Placeholder returns the placeholder of the 'n'-th (starting from 1) query argument.
+func (d Dialect) Placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// Quote quotes the identifier i.e. table or column name.
+func (d Dialect) Quote(identifier string) string {
+	if d == MySQL {
+		return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+	}
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+// converter returns the dberrors.Converter of the dialect's database driver.
+func (d Dialect) converter() dberrors.Converter {
+	switch d {
+	case Postgres:
+		return pgconv.New()
+	case MySQL:
+		return mysqlconv.New()
+	}
+	return sqliteconv.New()
+}
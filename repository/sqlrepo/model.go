@@ -0,0 +1,161 @@
+package sqlrepo
+
+import (
+	"database/sql/driver"
+	"github.com/jinzhu/inflection"
+	"github.com/kucjac/go-rest-sdk/forms"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// TableNamer is the model that defines its own table name.
+type TableNamer interface {
+	TableName() string
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	modelsCache sync.Map
+)
+
+// column is the model's field stored in the table column.
+type column struct {
+	field string
+	name  string
+	index []int
+}
+
+// model describes the table of the model type.
+type model struct {
+	typ     reflect.Type
+	table   string
+	columns []column
+
+	// pk is the index of the primary key column or -1
+	pk int
+}
+
+// modelOf returns the cached model description of the struct type 't'.
+func modelOf(t reflect.Type) *model {
+	if m, ok := modelsCache.Load(t); ok {
+		return m.(*model)
+	}
+
+	m := &model{typ: t, table: tableName(t), pk: -1}
+	pkField, hasPK := forms.PrimaryKeyField(t)
+	for _, field := range columnFields(t, nil) {
+		if hasPK && field.Name == pkField.Name {
+			m.pk = len(m.columns)
+		}
+		m.columns = append(m.columns, column{field: field.Name, name: columnName(field), index: field.Index})
+	}
+	modelsCache.Store(t, m)
+	return m
+}
+
+// tableName returns the TableName of the model or the plural snake case of its struct name.
+func tableName(t reflect.Type) string {
+	if namer, ok := reflect.New(t).Interface().(TableNamer); ok {
+		return namer.TableName()
+	}
+	return inflection.Plural(snakeCase(t.Name()))
+}
+
+// columnName returns the column name defined by the 'db' tag or the snake case of the field name.
+func columnName(field reflect.StructField) string {
+	name := field.Tag.Get("db")
+	if i := strings.Index(name, ","); i != -1 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = snakeCase(field.Name)
+	}
+	return name
+}
+
+// columnFields returns the fields of the struct type that are stored as the columns.
+// The fields of the embedded structs are promoted, while the relationships
+// (structs, slices and pointers to structs) and the fields tagged with `db:"-"` are skipped.
+func columnFields(t reflect.Type, index []int) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int{}, index...), i)
+		if field.Tag.Get("db") == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !isValueType(field.Type) {
+			fields = append(fields, columnFields(field.Type, field.Index)...)
+			continue
+		}
+		if field.PkgPath != "" || !isColumnType(field.Type) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func isColumnType(t reflect.Type) bool {
+	if isValueType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return isColumnType(t.Elem())
+	case reflect.Struct, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+// isValueType checks if the struct type is stored as a single value i.e. time.Time.
+func isValueType(t reflect.Type) bool {
+	return t == timeType || t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)
+}
+
+// snakeCase converts the 'name' into the snake case i.e. 'UserID' into 'user_id'.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// the underscore separates the words i.e. 'userID' or 'HTTPServer'
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// columnByField returns the column of the struct field with given 'name'.
+func (m *model) columnByField(name string) (column, bool) {
+	for _, col := range m.columns {
+		if col.field == name {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// columnByOrder returns the column for the 'name' used in the order clause.
+// The name matches the column name or the field name case insensitive.
+func (m *model) columnByOrder(name string) (column, bool) {
+	for _, col := range m.columns {
+		if strings.EqualFold(col.name, name) || strings.EqualFold(col.field, name) {
+			return col, true
+		}
+	}
+	return column{}, false
+}
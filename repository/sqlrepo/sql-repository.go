@@ -0,0 +1,562 @@
+package sqlrepo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SQLRepository is an implementation of the Repository interface for the 'database/sql' package.
+// The SQL queries are generated from the model's struct:
+//	- the table name is defined by the TableName method of the model or is the plural
+//	  snake case of the struct name i.e. 'UserRole' -> 'user_roles'
+//	- the column names are defined by the `db:"column"` tag or are the snake case
+//	  of the field names. The fields tagged with `db:"-"` and the relationships are skipped.
+//	- the primary key is the field returned by the forms.PrimaryKeyField. The zero integer
+//	  primary keys are generated by the database on create.
+// The query objects match the records with equal non-zero fields, as in the gormrepo.
+// The database errors are converted using the pgconv, mysqlconv and sqliteconv converters.
+type SQLRepository struct {
+	db        *sql.DB
+	dialect   Dialect
+	converter dberrors.Converter
+}
+
+// New creates the SQLRepository for the 'db' using given 'dialect'.
+// Returns error if the nil pointer or unsupported dialect is provided.
+func New(db *sql.DB, dialect Dialect) (*SQLRepository, error) {
+	if db == nil {
+		return nil, errors.New("Nil pointer as an argument provided.")
+	}
+	if !dialect.valid() {
+		return nil, errors.New("Unsupported database dialect.")
+	}
+	return &SQLRepository{db: db, dialect: dialect, converter: dialect.converter()}, nil
+}
+
+// Create inserts the 'req' model. If the model has the zero integer primary key,
+// it is omitted and set to the value generated by the database.
+func (s *SQLRepository) Create(req interface{}) *dberrors.Error {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	return s.insert(v, m)
+}
+
+// Get returns the first record, ordered by the primary key, that matches the 'req'.
+func (s *SQLRepository) Get(req interface{}) (interface{}, *dberrors.Error) {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	q := s.selectQuery(m)
+	q.where(m, v)
+	q.orderByPrimaryKey(m)
+	q.sql.WriteString(" LIMIT 1")
+
+	res := reflect.New(m.typ)
+	err := s.db.QueryRow(q.String(), q.args...).Scan(fieldAddrs(m, res.Elem())...)
+	if err != nil {
+		return nil, s.converter.Convert(err)
+	}
+	return res.Interface(), nil
+}
+
+// List returns all the records that match the 'req' ordered by the primary key.
+// The result is the slice of pointers to the model i.e. []*Foo.
+func (s *SQLRepository) List(req interface{}) (interface{}, *dberrors.Error) {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	q := s.selectQuery(m)
+	q.where(m, v)
+	q.orderByPrimaryKey(m)
+	return s.list(m, q)
+}
+
+// ListWithParams lists the 'req' models using the 'params'. The 'Order' parameter
+// contains the comma separated column or field names, optionally followed by the
+// 'asc' or 'desc' direction i.e. 'name desc, id'. If any of the parameters is set
+// and the 'Limit' is zero, at most 10 records are returned, as in the gormrepo.
+func (s *SQLRepository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (interface{}, *dberrors.Error) {
+	if params == nil || !params.ContainsParameters() {
+		return s.List(req)
+	}
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	q := s.selectQuery(m)
+	q.where(m, v)
+	if keys := params.PrimaryKeys(); len(keys) > 0 {
+		if m.pk == -1 {
+			return nil, noPrimaryKey(m)
+		}
+		q.conjunction()
+		q.sql.WriteString(s.dialect.Quote(m.columns[m.pk].name) + " IN (")
+		for i, id := range keys {
+			if i > 0 {
+				q.sql.WriteString(", ")
+			}
+			q.arg(id)
+		}
+		q.sql.WriteString(")")
+	}
+
+	if params.Order != "" {
+		if dbErr = q.orderBy(m, params.Order); dbErr != nil {
+			return nil, dbErr
+		}
+	} else {
+		q.orderByPrimaryKey(m)
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = 10
+	}
+	q.sql.WriteString(" LIMIT " + strconv.Itoa(limit))
+	if params.Offset > 0 {
+		q.sql.WriteString(" OFFSET " + strconv.Itoa(params.Offset))
+	}
+	return s.list(m, q)
+}
+
+// Count returns the number of records that match the 'req'.
+func (s *SQLRepository) Count(req interface{}) (int, *dberrors.Error) {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return 0, dbErr
+	}
+
+	q := s.newQuery()
+	q.sql.WriteString("SELECT COUNT(*) FROM " + s.dialect.Quote(m.table))
+	q.where(m, v)
+
+	var count int
+	if err := s.db.QueryRow(q.String(), q.args...).Scan(&count); err != nil {
+		return 0, s.converter.Convert(err)
+	}
+	return count, nil
+}
+
+// Update replaces all the columns of the record with the 'req' primary key.
+// If the 'req' has no primary key set or the record does not exist, the 'req' is created.
+func (s *SQLRepository) Update(req interface{}) *dberrors.Error {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	if m.pk == -1 {
+		return noPrimaryKey(m)
+	}
+	pk := m.columns[m.pk]
+	if v.FieldByIndex(pk.index).IsZero() {
+		return s.insert(v, m)
+	}
+
+	var columns []column
+	for i, col := range m.columns {
+		if i != m.pk {
+			columns = append(columns, col)
+		}
+	}
+	q := s.updateQuery(m, v, columns)
+	q.conjunction()
+	q.equals(pk, v.FieldByIndex(pk.index).Interface())
+
+	affected, dbErr := s.exec(q)
+	if dbErr != nil {
+		return dbErr
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// the record may exist with the same values (i.e. mysql returns the number of changed rows)
+	exists := reflect.New(m.typ)
+	exists.Elem().FieldByIndex(pk.index).Set(v.FieldByIndex(pk.index))
+	count, dbErr := s.Count(exists.Interface())
+	if dbErr != nil {
+		return dbErr
+	}
+	if count > 0 {
+		return nil
+	}
+	return s.insert(v, m)
+}
+
+// Patch sets the non-zero fields of the 'req' in all the records that match the 'where'.
+// If the 'req' has the primary key set, only the record with that key is patched.
+// If the 'where' is nil all the records are patched.
+func (s *SQLRepository) Patch(req, where interface{}) *dberrors.Error {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	var columns []column
+	for i, col := range m.columns {
+		if i != m.pk && !v.FieldByIndex(col.index).IsZero() {
+			columns = append(columns, col)
+		}
+	}
+	return s.patch(m, v, where, columns)
+}
+
+// PatchFields sets the 'fields' of the 'req' in all the records that match the 'where'.
+// The zero values and nil pointers of the fields are stored as well.
+// Implements repository.FieldPatcher interface.
+func (s *SQLRepository) PatchFields(req, where interface{}, fields []string) *dberrors.Error {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	columns := make([]column, 0, len(fields))
+	for _, name := range fields {
+		col, ok := m.columnByField(name)
+		if !ok {
+			return dberrors.ErrUnspecifiedError.NewWithMessage("Field: '" + name + "' cannot be patched")
+		}
+		columns = append(columns, col)
+	}
+	return s.patch(m, v, where, columns)
+}
+
+// Delete deletes all the records that match the 'where'. If the 'req' has the primary key
+// set only the record with that key is deleted. If the 'where' is nil all the records
+// of the 'req' model are deleted.
+func (s *SQLRepository) Delete(req, where interface{}) *dberrors.Error {
+	v, m, dbErr := s.modelValue(req)
+	if dbErr != nil {
+		return dbErr
+	}
+	whereValue, dbErr := s.whereValue(m, where)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	q := s.newQuery()
+	q.sql.WriteString("DELETE FROM " + s.dialect.Quote(m.table))
+	q.wherePrimaryKey(m, v)
+	q.where(m, whereValue)
+
+	affected, dbErr := s.exec(q)
+	if dbErr != nil {
+		return dbErr
+	}
+	if affected == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+	return nil
+}
+
+// insert inserts the 'v' model and sets its generated primary key.
+func (s *SQLRepository) insert(v reflect.Value, m *model) *dberrors.Error {
+	var generated *column
+	var columns []column
+	for i, col := range m.columns {
+		if i == m.pk && isGenerated(v.FieldByIndex(col.index)) {
+			generated = &m.columns[i]
+			continue
+		}
+		columns = append(columns, col)
+	}
+
+	q := s.newQuery()
+	q.sql.WriteString("INSERT INTO " + s.dialect.Quote(m.table) + " (")
+	for i, col := range columns {
+		if i > 0 {
+			q.sql.WriteString(", ")
+		}
+		q.sql.WriteString(s.dialect.Quote(col.name))
+	}
+	q.sql.WriteString(") VALUES (")
+	for i, col := range columns {
+		if i > 0 {
+			q.sql.WriteString(", ")
+		}
+		q.arg(v.FieldByIndex(col.index).Interface())
+	}
+	q.sql.WriteString(")")
+
+	if generated == nil {
+		_, dbErr := s.exec(q)
+		return dbErr
+	}
+
+	pk := v.FieldByIndex(generated.index)
+	if s.dialect == Postgres {
+		q.sql.WriteString(" RETURNING " + s.dialect.Quote(generated.name))
+		if err := s.db.QueryRow(q.String(), q.args...).Scan(pk.Addr().Interface()); err != nil {
+			return s.converter.Convert(err)
+		}
+		return nil
+	}
+
+	result, err := s.db.Exec(q.String(), q.args...)
+	if err != nil {
+		return s.converter.Convert(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return s.converter.Convert(err)
+	}
+	if pk.Kind() >= reflect.Uint && pk.Kind() <= reflect.Uint64 {
+		pk.SetUint(uint64(id))
+	} else {
+		pk.SetInt(id)
+	}
+	return nil
+}
+
+// patch sets the 'columns' of the 'v' in the records that match the 'where'.
+func (s *SQLRepository) patch(m *model, v reflect.Value, where interface{}, columns []column) *dberrors.Error {
+	whereValue, dbErr := s.whereValue(m, where)
+	if dbErr != nil {
+		return dbErr
+	}
+	if len(columns) == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+
+	q := s.updateQuery(m, v, columns)
+	q.wherePrimaryKey(m, v)
+	q.where(m, whereValue)
+
+	affected, dbErr := s.exec(q)
+	if dbErr != nil {
+		return dbErr
+	}
+	if affected == 0 {
+		return dberrors.ErrNoResult.NewWithMessage("No rows affected")
+	}
+	return nil
+}
+
+func (s *SQLRepository) list(m *model, q *query) (interface{}, *dberrors.Error) {
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, s.converter.Convert(err)
+	}
+	defer rows.Close()
+
+	result := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(m.typ)), 0, 0)
+	for rows.Next() {
+		res := reflect.New(m.typ)
+		if err = rows.Scan(fieldAddrs(m, res.Elem())...); err != nil {
+			return nil, s.converter.Convert(err)
+		}
+		result = reflect.Append(result, res)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.converter.Convert(err)
+	}
+	return result.Interface(), nil
+}
+
+func (s *SQLRepository) exec(q *query) (int64, *dberrors.Error) {
+	result, err := s.db.Exec(q.String(), q.args...)
+	if err != nil {
+		return 0, s.converter.Convert(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, s.converter.Convert(err)
+	}
+	return affected, nil
+}
+
+func (s *SQLRepository) newQuery() *query {
+	return &query{dialect: s.dialect}
+}
+
+func (s *SQLRepository) selectQuery(m *model) *query {
+	q := s.newQuery()
+	q.sql.WriteString("SELECT ")
+	for i, col := range m.columns {
+		if i > 0 {
+			q.sql.WriteString(", ")
+		}
+		q.sql.WriteString(s.dialect.Quote(col.name))
+	}
+	q.sql.WriteString(" FROM " + s.dialect.Quote(m.table))
+	return q
+}
+
+func (s *SQLRepository) updateQuery(m *model, v reflect.Value, columns []column) *query {
+	q := s.newQuery()
+	q.sql.WriteString("UPDATE " + s.dialect.Quote(m.table) + " SET ")
+	for i, col := range columns {
+		if i > 0 {
+			q.sql.WriteString(", ")
+		}
+		q.sql.WriteString(s.dialect.Quote(col.name) + " = ")
+		q.arg(v.FieldByIndex(col.index).Interface())
+	}
+	return q
+}
+
+// modelValue returns the struct value and the model description of the 'req'.
+func (s *SQLRepository) modelValue(req interface{}) (reflect.Value, *model, *dberrors.Error) {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, dberrors.ErrUnspecifiedError.NewWithMessage(
+			fmt.Sprintf("Unsupported model: %T. The pointer to struct is required", req))
+	}
+	return v.Elem(), modelOf(v.Elem().Type()), nil
+}
+
+// whereValue returns the struct value of the 'where' model of the 'm' type
+// or invalid value if the 'where' is nil.
+func (s *SQLRepository) whereValue(m *model, where interface{}) (reflect.Value, *dberrors.Error) {
+	if where == nil {
+		return reflect.Value{}, nil
+	}
+	v := reflect.ValueOf(where)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Type() != m.typ {
+		return reflect.Value{}, dberrors.ErrUnspecifiedError.NewWithMessage(
+			fmt.Sprintf("Unsupported query: %T for model: %s", where, m.typ.Name()))
+	}
+	return v, nil
+}
+
+func noPrimaryKey(m *model) *dberrors.Error {
+	return dberrors.ErrUnspecifiedError.NewWithMessage(
+		fmt.Sprintf("Model: %s has no primary key", m.typ.Name()))
+}
+
+// isGenerated checks if the primary key value should be generated by the database.
+func isGenerated(pk reflect.Value) bool {
+	switch pk.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return pk.IsZero()
+	}
+	return false
+}
+
+// fieldAddrs returns the addresses of the column fields of the 'v' used as the Scan destinations.
+func fieldAddrs(m *model, v reflect.Value) []interface{} {
+	addrs := make([]interface{}, len(m.columns))
+	for i, col := range m.columns {
+		addrs[i] = v.FieldByIndex(col.index).Addr().Interface()
+	}
+	return addrs
+}
+
+// query builds the SQL query with the dialect's placeholders.
+type query struct {
+	dialect  Dialect
+	sql      strings.Builder
+	args     []interface{}
+	hasWhere bool
+}
+
+func (q *query) String() string {
+	return q.sql.String()
+}
+
+// arg writes the placeholder of the 'value' argument.
+func (q *query) arg(value interface{}) {
+	q.args = append(q.args, value)
+	q.sql.WriteString(q.dialect.Placeholder(len(q.args)))
+}
+
+// conjunction writes the 'WHERE' or 'AND' keyword.
+func (q *query) conjunction() {
+	if q.hasWhere {
+		q.sql.WriteString(" AND ")
+		return
+	}
+	q.hasWhere = true
+	q.sql.WriteString(" WHERE ")
+}
+
+func (q *query) equals(col column, value interface{}) {
+	q.sql.WriteString(q.dialect.Quote(col.name) + " = ")
+	q.arg(value)
+}
+
+// where writes the conditions for the non-zero columns of the 'v'.
+// The invalid 'v' adds no conditions.
+func (q *query) where(m *model, v reflect.Value) {
+	if !v.IsValid() {
+		return
+	}
+	for _, col := range m.columns {
+		field := v.FieldByIndex(col.index)
+		if field.IsZero() {
+			continue
+		}
+		q.conjunction()
+		q.equals(col, field.Interface())
+	}
+}
+
+// wherePrimaryKey writes the condition for the non-zero primary key of the 'v'.
+func (q *query) wherePrimaryKey(m *model, v reflect.Value) {
+	if m.pk == -1 {
+		return
+	}
+	pk := m.columns[m.pk]
+	if field := v.FieldByIndex(pk.index); !field.IsZero() {
+		q.conjunction()
+		q.equals(pk, field.Interface())
+	}
+}
+
+func (q *query) orderByPrimaryKey(m *model) {
+	if m.pk != -1 {
+		q.sql.WriteString(" ORDER BY " + q.dialect.Quote(m.columns[m.pk].name))
+	}
+}
+
+// orderBy writes the 'order' clause i.e. 'name desc, id'. The columns must
+// be the model's columns, so that the order could not inject the SQL.
+func (q *query) orderBy(m *model, order string) *dberrors.Error {
+	q.sql.WriteString(" ORDER BY ")
+	for i, part := range strings.Split(order, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return dberrors.ErrInvalidSyntax.NewWithMessage("Invalid order: '" + order + "'")
+		}
+		col, ok := m.columnByOrder(words[0])
+		if !ok {
+			return dberrors.ErrInvalidSyntax.NewWithMessage("Unknown order column: '" + words[0] + "'")
+		}
+		if i > 0 {
+			q.sql.WriteString(", ")
+		}
+		q.sql.WriteString(q.dialect.Quote(col.name))
+		if len(words) == 2 {
+			switch direction := strings.ToUpper(words[1]); direction {
+			case "ASC", "DESC":
+				q.sql.WriteString(" " + direction)
+			default:
+				return dberrors.ErrInvalidSyntax.NewWithMessage("Invalid order: '" + order + "'")
+			}
+		}
+	}
+	return nil
+}
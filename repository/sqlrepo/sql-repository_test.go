@@ -0,0 +1,126 @@
+package sqlrepo
+
+import (
+	"database/sql"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/repotest"
+	_ "github.com/mattn/go-sqlite3"
+	. "github.com/smartystreets/goconvey/convey"
+	"reflect"
+	"testing"
+)
+
+type UserRole struct {
+	ID       int64  `db:"role_id"`
+	RoleName string `db:"name,omitempty"`
+	Secret   string `db:"-"`
+	User     *UserRole
+}
+
+type Named struct {
+	ID uint
+}
+
+func (n *Named) TableName() string {
+	return "custom"
+}
+
+func openDB(t *testing.T, schema ...string) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each connection to the ':memory:' database opens the new database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range schema {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestDialect(t *testing.T) {
+	Convey("Subject: SQL dialects placeholders and identifiers", t, func() {
+		So(Postgres.Placeholder(2), ShouldEqual, "$2")
+		So(MySQL.Placeholder(2), ShouldEqual, "?")
+		So(SQLite.Placeholder(2), ShouldEqual, "?")
+
+		So(Postgres.Quote(`user"s`), ShouldEqual, `"user""s"`)
+		So(MySQL.Quote("users"), ShouldEqual, "`users`")
+		So(SQLite.String(), ShouldEqual, "sqlite3")
+	})
+}
+
+func TestModel(t *testing.T) {
+	Convey("Subject: Models description from the struct tags", t, func() {
+		So(snakeCase("UserID"), ShouldEqual, "user_id")
+		So(snakeCase("HTTPServer"), ShouldEqual, "http_server")
+		So(snakeCase("Name"), ShouldEqual, "name")
+
+		m := modelOf(reflect.TypeOf(UserRole{}))
+		So(m.table, ShouldEqual, "user_roles")
+		So(m.columns, ShouldHaveLength, 2)
+		So(m.columns[0].name, ShouldEqual, "role_id")
+		So(m.columns[1].name, ShouldEqual, "name")
+		So(m.pk, ShouldEqual, 0)
+
+		So(modelOf(reflect.TypeOf(Named{})).table, ShouldEqual, "custom")
+	})
+}
+
+func TestSQLRepository(t *testing.T) {
+	Convey("Subject: SQLRepository specific behaviour", t, func() {
+		_, err := New(nil, SQLite)
+		So(err, ShouldNotBeNil)
+
+		db := openDB(t, "CREATE TABLE user_roles (role_id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)")
+		_, err = New(db, Dialect(10))
+		So(err, ShouldNotBeNil)
+
+		repo, err := New(db, SQLite)
+		So(err, ShouldBeNil)
+
+		Convey("The columns are named by the db tags", func() {
+			role := &UserRole{RoleName: "admin"}
+			So(repo.Create(role), ShouldBeNil)
+			So(role.ID, ShouldEqual, 1)
+
+			res, dbErr := repo.Get(&UserRole{RoleName: "admin"})
+			So(dbErr, ShouldBeNil)
+			So(res, ShouldResemble, &UserRole{ID: 1, RoleName: "admin"})
+		})
+
+		Convey("The database errors are converted", func() {
+			So(repo.Create(&UserRole{RoleName: "admin"}), ShouldBeNil)
+			dbErr := repo.Create(&UserRole{RoleName: "admin"})
+			So(dbErr, ShouldNotBeNil)
+			So(dbErr.Compare(dberrors.ErrUniqueViolation), ShouldBeTrue)
+		})
+
+		Convey("The order could reference only the model columns", func() {
+			_, dbErr := repo.ListWithParams(&UserRole{}, &repository.ListParameters{Order: "name; DROP TABLE user_roles"})
+			So(dbErr.Compare(dberrors.ErrInvalidSyntax), ShouldBeTrue)
+
+			_, dbErr = repo.ListWithParams(&UserRole{}, &repository.ListParameters{Order: "RoleName desc"})
+			So(dbErr, ShouldBeNil)
+		})
+	})
+}
+
+func TestSQLRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, models ...interface{}) repository.Repository {
+		db := openDB(t,
+			"CREATE TABLE models (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, age INTEGER)",
+			"CREATE TABLE slugs (code TEXT PRIMARY KEY, name TEXT)",
+		)
+		repo, err := New(db, SQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/kucjac/go-rest-sdk/dberrors"
+)
+
+// Upserter is the Repository extension that inserts the record or updates the existing one
+// in a single statement, when the record conflicts with the stored one on a unique key.
+// The repository decorators, which decorated repository is not an Upserter,
+// return the dberrors.ErrNotSupported.
+type Upserter interface {
+	// Upsert inserts the 'req' object or, if it conflicts on the 'conflictFields' (struct field
+	// names) with the stored record, updates the 'updateFields' of that record.
+	// If no 'conflictFields' are provided the primary key is used. If no 'updateFields'
+	// are provided all the fields, except the conflict ones, are updated.
+	// The 'req' is set to the stored record. Returns true if the record was created.
+	Upsert(req interface{}, conflictFields, updateFields []string) (created bool, err *dberrors.Error)
+}
@@ -17,6 +17,7 @@ type DefaultBody struct {
 // pluralized if slice provided.
 // I.e. Providing model type Foo struct{} - would be saved as 'foo'
 // 		But slice []Foo or []*Foo would result in 'foos'
+// The fields tagged as `rest:"writeonly"` or `rest:"hidden"` are not included in the content.
 // With this method DefaultBody implements ContentAdder interface
 func (d *DefaultBody) AddContent(content ...interface{}) {
 	d.addContent(content...)
@@ -59,7 +60,7 @@ func (d *DefaultBody) NewErrored() Responser {
 
 func (d *DefaultBody) addContent(contents ...interface{}) {
 	for _, content := range contents {
-		d.Content[refutils.ModelName(content)] = content
+		d.Content[refutils.ModelName(content)] = visibleContent(content)
 	}
 }
 
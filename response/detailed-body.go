@@ -41,6 +41,7 @@ type DetailedBody struct {
 // The key for the Content is set as provided 'content' struct Name - lowercased
 // I.e. type Model struct would use key 'model'
 // But Slice of models []Model or []*Model would use pluralized name - 'models'
+// The fields tagged as `rest:"writeonly"` or `rest:"hidden"` are not included in the content.
 // For basic types like 'int' or 'string' use wrapper struct so that the name would
 // be as proided i.e.: having some Limit variable of type int, by wrapping it as
 // type Limit int and insert content as Limit(limitValue) would result storing
@@ -109,7 +110,7 @@ func (d *DetailedBody) WithStatus(status interface{}) StatusResponser {
 
 func (d *DetailedBody) addContent(contents ...interface{}) {
 	for _, content := range contents {
-		d.Content[refutils.ModelName(content)] = content
+		d.Content[refutils.ModelName(content)] = visibleContent(content)
 	}
 }
 
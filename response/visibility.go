@@ -0,0 +1,188 @@
+package response
+
+import (
+	"fmt"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// viewIndexTag is the struct tag of the view fields that keeps the index
+// of the source struct field.
+const viewIndexTag = "view"
+
+var (
+	viewsCache sync.Map
+
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// visibleContent returns the 'content' without the fields that are not readable by
+// the clients - tagged as `rest:"writeonly"` or `rest:"hidden"` (see refutils.FieldAccess).
+// The content with such fields is copied into the view - the value of the type built
+// from the content type without the not readable fields, which is then encoded by the
+// encoding/json package. The content without such fields is returned unchanged.
+func visibleContent(content interface{}) interface{} {
+	if content == nil {
+		return nil
+	}
+	t := reflect.TypeOf(content)
+	if !refutils.HasAccessRules(t) {
+		return content
+	}
+	return toView(reflect.ValueOf(content), viewOf(t)).Interface()
+}
+
+// viewOf returns the view type of the type 't'. The result is cached for each type.
+func viewOf(t reflect.Type) reflect.Type {
+	if view, ok := viewsCache.Load(t); ok {
+		return view.(reflect.Type)
+	}
+	view := buildView(t, map[reflect.Type]bool{})
+	viewsCache.Store(t, view)
+	return view
+}
+
+// buildView builds the view type of the type 't'. The types without the access rules
+// and the json marshalers are their own views. The interfaces are viewed by their
+// dynamic values, as well as the recursive types that are still being built.
+func buildView(t reflect.Type, building map[reflect.Type]bool) reflect.Type {
+	if t.Kind() == reflect.Interface {
+		return emptyInterfaceType
+	}
+	if !refutils.HasAccessRules(t) || refutils.IsJSONMarshaler(t) {
+		return t
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return reflect.PtrTo(buildView(t.Elem(), building))
+	case reflect.Slice:
+		return reflect.SliceOf(buildView(t.Elem(), building))
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), buildView(t.Elem(), building))
+	case reflect.Map:
+		return reflect.MapOf(t.Key(), buildView(t.Elem(), building))
+	case reflect.Struct:
+		if building[t] {
+			return emptyInterfaceType
+		}
+		return buildStructView(t, building)
+	}
+	return t
+}
+
+// buildStructView builds the struct view of the struct type 't'. The view contains the
+// readable fields encoded by the encoding/json package, in their struct order and with
+// their json tags. The embedded structs are embedded as their struct views, so that
+// their fields are promoted as in the 't'.
+func buildStructView(t reflect.Type, building map[reflect.Type]bool) reflect.Type {
+	building[t] = true
+	defer delete(building, t)
+
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !refutils.FieldAccessOf(field).Readable() {
+			continue
+		}
+
+		view := reflect.StructField{
+			Name: field.Name,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q %s:"%d"`, tag, viewIndexTag, i)),
+		}
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		switch {
+		case field.Anonymous && embedded.Kind() == reflect.Struct && jsonName(tag) == "":
+			if building[embedded] {
+				continue
+			}
+			view.Anonymous = true
+			view.Type = buildStructView(embedded, building)
+			if field.Type.Kind() == reflect.Ptr {
+				view.Type = reflect.PtrTo(view.Type)
+			}
+			if field.PkgPath != "" {
+				view.Name = "Embedded" + strconv.Itoa(i)
+			}
+		case field.PkgPath != "":
+			continue
+		default:
+			view.Type = buildView(field.Type, building)
+		}
+		fields = append(fields, view)
+	}
+	return reflect.StructOf(fields)
+}
+
+// jsonName returns the name from the json 'tag'.
+func jsonName(tag string) string {
+	if i := strings.Index(tag, ","); i != -1 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// toView copies the value 'v' into the new value of its view type 'view'.
+func toView(v reflect.Value, view reflect.Type) reflect.Value {
+	if view.Kind() == reflect.Interface {
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Zero(view)
+			}
+			v = v.Elem()
+		}
+		if !v.CanInterface() {
+			return reflect.Zero(view)
+		}
+		return toView(v, viewOf(v.Type()))
+	}
+	if view == v.Type() {
+		if !v.CanInterface() {
+			return reflect.Zero(view)
+		}
+		return v
+	}
+
+	result := reflect.New(view).Elem()
+	switch view.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return result
+		}
+		result.Set(reflect.New(view.Elem()))
+		result.Elem().Set(toView(v.Elem(), view.Elem()))
+	case reflect.Slice:
+		if v.IsNil() {
+			return result
+		}
+		result.Set(reflect.MakeSlice(view, v.Len(), v.Len()))
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(toView(v.Index(i), view.Elem()))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return result
+		}
+		result.Set(reflect.MakeMapWithSize(view, v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), toView(iter.Value(), view.Elem()))
+		}
+	case reflect.Struct:
+		for i := 0; i < view.NumField(); i++ {
+			index, _ := strconv.Atoi(view.Field(i).Tag.Get(viewIndexTag))
+			result.Field(i).Set(toView(v.Field(index), view.Field(i).Type))
+		}
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package response
+
+import (
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+type account struct {
+	ID       int        `json:"id" rest:"readonly"`
+	Login    string     `json:"login"`
+	Password string     `json:"password" rest:"writeonly"`
+	Token    string     `rest:"hidden"`
+	Friends  []*account `json:"friends,omitempty"`
+}
+
+type plainModel struct {
+	Name string
+}
+
+type timestamps struct {
+	Created time.Time `json:"created"`
+	Version int       `json:"version"`
+}
+
+type Audit struct {
+	Editor string `json:"editor"`
+	Secret string `json:"secret" rest:"hidden"`
+}
+
+type document struct {
+	Title string `json:"title"`
+	timestamps
+	*Audit
+	Size     int64               `json:"size,string"`
+	Notes    string              `json:"notes,omitempty"`
+	Key      string              `json:"key" rest:"writeonly"`
+	Owners   map[string]*account `json:"owners"`
+	Extra    interface{}         `json:"extra"`
+	Internal string              `json:"-"`
+}
+
+func TestVisibleContent(t *testing.T) {
+	Convey("Subject: the response bodies do not contain unreadable fields", t, func() {
+		accounts := []*account{{ID: 1, Login: "john", Password: "secret", Token: "t",
+			Friends: []*account{{ID: 2, Login: "doe", Password: "secret"}}}}
+
+		Convey("The writeonly and hidden fields are removed from the content", func() {
+			for _, body := range []Responser{(&DefaultBody{}).New(), (&DetailedBody{}).New()} {
+				body.AddContent(accounts[0], accounts)
+
+				data, err := json.Marshal(body)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldContainSubstring, `"login":"john"`)
+				So(string(data), ShouldContainSubstring, `"login":"doe"`)
+				So(string(data), ShouldContainSubstring, `"id":2`)
+				So(string(data), ShouldNotContainSubstring, "secret")
+				So(string(data), ShouldNotContainSubstring, "Token")
+			}
+		})
+
+		Convey("The fields are encoded in their struct order as by the encoding/json", func() {
+			created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			doc := &document{
+				Title:      "title",
+				timestamps: timestamps{Created: created, Version: 2},
+				Audit:      &Audit{Editor: "john", Secret: "secret"},
+				Size:       9007199254740993,
+				Key:        "secret",
+				Owners:     map[string]*account{"b": {ID: 2, Password: "secret"}, "a": {ID: 1}},
+				Extra:      &account{ID: 3, Token: "secret"},
+			}
+
+			// the content is copied into the view encoded by the encoding/json
+			So(visibleContent(doc), ShouldNotHaveSameTypeAs, doc)
+			data, err := json.Marshal(visibleContent(doc))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"title":"title","created":"2020-01-02T03:04:05Z","version":2,`+
+				`"editor":"john","size":"9007199254740993",`+
+				`"owners":{"a":{"id":1,"login":""},"b":{"id":2,"login":""}},`+
+				`"extra":{"id":3,"login":""}}`)
+
+			doc.Audit = nil
+			data, err = json.Marshal(visibleContent(doc))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldNotContainSubstring, "editor")
+		})
+
+		Convey("The content without access rules is not changed", func() {
+			model := &plainModel{Name: "name"}
+			So(visibleContent(model), ShouldEqual, model)
+			So(visibleContent(nil), ShouldBeNil)
+		})
+	})
+}
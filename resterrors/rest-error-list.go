@@ -129,6 +129,12 @@ var (
 		Status: "400",
 	}
 
+	ErrMissingRequiredCookie = Error{
+		Code: "BRQ020", Title: "Missing required cookie",
+		Detail: &Detail{Title: "A required HTTP cookie was not specified"},
+		Status: "400",
+	}
+
 	// STATUS 403, CODE: 'AUTHXX'
 	ErrAccountDisabled = Error{
 		Code: "AUTH01", Title: "Accound disabled",
@@ -181,6 +187,12 @@ var (
 		Status: "409",
 	}
 
+	ErrPatchConflict = Error{
+		Code: "CON003", Title: "Patch conflict.",
+		Detail: &Detail{Title: "The patch could not be applied to the current state of the resource."},
+		Status: "409",
+	}
+
 	// STATUS 413, CODE: 'RTLXXX'
 	ErrRequestBodyTooLarge = Error{
 		Code: "RTL001", Title: "Request body too large.",
@@ -188,6 +200,13 @@ var (
 		Status: "413",
 	}
 
+	// STATUS 415, CODE: 'UMTXXX'
+	ErrUnsupportedMediaType = Error{
+		Code: "UMT001", Title: "Unsupported media type.",
+		Detail: &Detail{Title: "The media type of the request body is not supported."},
+		Status: "415",
+	}
+
 	// STATUS 500, CODE: 'INTXXX'
 	ErrInternalError = Error{
 		Code: "INT001", Title: "Internal server error",
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"sync"
+)
+
+// Exporter exports the ended spans i.e. to the tracing backend.
+// The ExportSpan method could be called concurrently.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// InMemoryExporter is the Exporter that stores the spans in memory.
+// Useful for testing the instrumented code.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter creates new empty InMemoryExporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan stores the 'span'.
+// Implements Exporter interface.
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans returns the exported spans in the order they were ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spans := make([]SpanData, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Span returns the first exported span with given 'name'.
+func (e *InMemoryExporter) Span(name string) (SpanData, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range e.spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return SpanData{}, false
+}
+
+// Reset removes the stored spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"github.com/kucjac/go-rest-sdk/internal/httpwriter"
+	"net/http"
+	"strconv"
+)
+
+// Middleware decorates the 'handler' mounted at the 'route' path with the server span
+// named by the request method and the 'route'. The span continues the trace from the
+// request 'traceparent' header. The handlers.GenericHandler starts the child spans of
+// the request span and records the resterrors of the error responses on it.
+func Middleware(tracer *Tracer, route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		req = Extract(req)
+		ctx, span := tracer.Start(req.Context(), req.Method+" "+route)
+		defer span.End()
+
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", req.URL.RequestURI())
+
+		writer := httpwriter.NewStatusWriter(rw)
+		handler(writer, req.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", strconv.Itoa(writer.Status()))
+	}
+}
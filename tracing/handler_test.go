@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	Convey("Subject: Middleware starts the server span of the request", t, func() {
+		exporter := NewInMemoryExporter()
+		tracer := NewTracer(exporter)
+
+		Convey("The span is named by the method and route and records the status", func() {
+			handler := Middleware(tracer, "/foos/{foo}", func(rw http.ResponseWriter, req *http.Request) {
+				So(SpanFromContext(req.Context()), ShouldNotBeNil)
+				rw.WriteHeader(http.StatusNoContent)
+				rw.WriteHeader(http.StatusInternalServerError)
+			})
+			handler(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/foos/1", nil))
+
+			span, ok := exporter.Span("DELETE /foos/{foo}")
+			So(ok, ShouldBeTrue)
+			So(span.Attributes["http.method"], ShouldEqual, "DELETE")
+			So(span.Attributes["http.route"], ShouldEqual, "/foos/{foo}")
+			So(span.Attributes["http.status_code"], ShouldEqual, "204")
+		})
+	})
+}
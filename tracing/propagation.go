@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// TraceparentHeader is the W3C Trace Context header propagating the parent span.
+const TraceparentHeader = "traceparent"
+
+// ErrInvalidTraceparent is returned when the traceparent value is malformed.
+var ErrInvalidTraceparent = errors.New("Invalid traceparent")
+
+// ParseTraceparent parses the W3C traceparent 'value' in the form:
+// 'version-traceid-parentid-flags', i.e. '00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01'.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+
+	version, err := hex.DecodeString(value[:2])
+	// the version 'ff' is forbidden and the version '00' does not allow additional fields
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) ||
+		(len(value) > 55 && value[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+
+	if _, err = hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, err := hex.DecodeString(value[53:55])
+	if err != nil || !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// Traceparent formats the span context as the W3C traceparent value.
+func (s SpanContext) Traceparent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return "00-" + s.TraceID.String() + "-" + s.SpanID.String() + "-" + flags
+}
+
+// Extract returns the copy of the 'req' which context contains the remote span context
+// from the 'traceparent' header. If the header is missing or invalid the 'req' is returned.
+func Extract(req *http.Request) *http.Request {
+	sc, err := ParseTraceparent(req.Header.Get(TraceparentHeader))
+	if err != nil {
+		return req
+	}
+	return req.WithContext(ContextWithRemoteSpanContext(req.Context(), sc))
+}
+
+// Inject sets the 'traceparent' header of the 'header' to the span from the 'ctx',
+// so that the outgoing request continues the trace.
+func Inject(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(TraceparentHeader, span.SpanContext().Traceparent())
+}
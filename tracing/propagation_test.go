@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	Convey("Subject: W3C traceparent propagation", t, func() {
+		Convey("The valid traceparent is parsed and formatted back", func() {
+			value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+			sc, err := ParseTraceparent(value)
+			So(err, ShouldBeNil)
+			So(sc.TraceID.String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+			So(sc.SpanID.String(), ShouldEqual, "00f067aa0ba902b7")
+			So(sc.Sampled, ShouldBeTrue)
+			So(sc.Traceparent(), ShouldEqual, value)
+
+			sc, err = ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			So(err, ShouldBeNil)
+			So(sc.Sampled, ShouldBeFalse)
+
+			_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("The invalid traceparent returns the ErrInvalidTraceparent", func() {
+			for _, value := range []string{
+				"",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			} {
+				_, err := ParseTraceparent(value)
+				So(err, ShouldEqual, ErrInvalidTraceparent)
+			}
+		})
+
+		Convey("The trace is extracted from the request and injected into the header", func() {
+			tracer := NewTracer(nil)
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+			ctx, span := tracer.Start(Extract(req).Context(), "span")
+			So(span.SpanContext().TraceID.String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+			So(span.SpanContext().Sampled, ShouldBeFalse)
+
+			header := http.Header{}
+			Inject(ctx, header)
+			So(header.Get(TraceparentHeader), ShouldEqual, span.SpanContext().Traceparent())
+
+			header = http.Header{}
+			Inject(context.Background(), header)
+			So(header.Get(TraceparentHeader), ShouldBeEmpty)
+		})
+	})
+}
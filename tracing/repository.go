@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/refutils"
+	"github.com/kucjac/go-rest-sdk/repository"
+)
+
+// Repository is the repository.Repository decorator that traces the calls with the spans
+// named 'Repository.<Operation>'. The spans are the children of the span from the context
+// bound with WithContext, i.e. the request span when used by the handlers.GenericHandler.
+// The returned dberrors are recorded on the spans.
+type Repository struct {
+	repo   repository.Repository
+	tracer *Tracer
+	ctx    context.Context
+}
+
+// InstrumentRepository decorates the 'repo' with the spans started by the 'tracer'.
+func InstrumentRepository(repo repository.Repository, tracer *Tracer) *Repository {
+	return &Repository{repo: repo, tracer: tracer, ctx: context.Background()}
+}
+
+// WithContext returns the traced repository which spans are the children of the 'ctx' span.
+// If the decorated repository is a repository.ContextBinder it is bound to the 'ctx' too.
+// Implements repository.ContextBinder interface.
+func (r *Repository) WithContext(ctx context.Context) repository.Repository {
+	repo := r.repo
+	if binder, ok := repo.(repository.ContextBinder); ok {
+		repo = binder.WithContext(ctx)
+	}
+	return &Repository{repo: repo, tracer: r.tracer, ctx: ctx}
+}
+
+func (r *Repository) Create(req interface{}) (dbErr *dberrors.Error) {
+	defer r.trace("Create", req)(&dbErr)
+	return r.repo.Create(req)
+}
+
+func (r *Repository) Get(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	defer r.trace("Get", req)(&dbErr)
+	return r.repo.Get(req)
+}
+
+func (r *Repository) List(req interface{}) (res interface{}, dbErr *dberrors.Error) {
+	defer r.trace("List", req)(&dbErr)
+	return r.repo.List(req)
+}
+
+func (r *Repository) ListWithParams(
+	req interface{},
+	params *repository.ListParameters,
+) (res interface{}, dbErr *dberrors.Error) {
+	defer r.trace("ListWithParams", req)(&dbErr)
+	return r.repo.ListWithParams(req, params)
+}
+
+func (r *Repository) Count(req interface{}) (count int, dbErr *dberrors.Error) {
+	defer r.trace("Count", req)(&dbErr)
+	return r.repo.Count(req)
+}
+
+func (r *Repository) Update(req interface{}) (dbErr *dberrors.Error) {
+	defer r.trace("Update", req)(&dbErr)
+	return r.repo.Update(req)
+}
+
+func (r *Repository) Patch(req, where interface{}) (dbErr *dberrors.Error) {
+	defer r.trace("Patch", req)(&dbErr)
+	return r.repo.Patch(req, where)
+}
+
+// PatchFields patches the 'fields' using the decorated repository within the traced span.
+// No span is started if the decorated repository could not patch the fields - the
+// dberrors.ErrNotSupported is returned. Implements repository.FieldPatcher interface.
+func (r *Repository) PatchFields(req, where interface{}, fields []string) (dbErr *dberrors.Error) {
+	patcher, ok := r.repo.(repository.FieldPatcher)
+	if !ok {
+		return dberrors.ErrNotSupported.New()
+	}
+	defer r.trace("PatchFields", req)(&dbErr)
+	return patcher.PatchFields(req, where, fields)
+}
+
+// Upsert upserts the 'req' using the decorated repository within the traced span.
+// The decorated repository which is not a repository.Upserter results in the
+// dberrors.ErrNotSupported, which is not recorded as the span error, so that the trace
+// of the handler falling back to the Update is not marked as failed.
+// Implements repository.Upserter interface.
+func (r *Repository) Upsert(
+	req interface{}, conflictFields, updateFields []string,
+) (created bool, dbErr *dberrors.Error) {
+	upserter, ok := r.repo.(repository.Upserter)
+	if !ok {
+		return false, dberrors.ErrNotSupported.New()
+	}
+	defer r.trace("Upsert", req)(&dbErr)
+	return upserter.Upsert(req, conflictFields, updateFields)
+}
+
+func (r *Repository) Delete(req, where interface{}) (dbErr *dberrors.Error) {
+	defer r.trace("Delete", req)(&dbErr)
+	return r.repo.Delete(req, where)
+}
+
+// trace starts the span of the 'op' operation. The returned function ends the span
+// recording the returned 'dbErr'.
+func (r *Repository) trace(op string, req interface{}) func(dbErr **dberrors.Error) {
+	_, span := r.tracer.Start(r.ctx, "Repository."+op)
+	span.SetAttribute("db.operation", op)
+	if req != nil {
+		span.SetAttribute("db.model", refutils.GetType(req).Name())
+	}
+	return func(dbErr **dberrors.Error) {
+		if *dbErr != nil {
+			span.RecordError(*dbErr)
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,239 @@
+// Package tracing contains the OpenTelemetry compatible tracing of the handlers, bindings
+// and repositories, without the dependency on the OpenTelemetry libraries.
+// The spans are propagated using the W3C 'traceparent' header and exported
+// by the Exporter i.e. the InMemoryExporter in the tests.
+// I.e.:
+//	tracer := tracing.NewTracer(exporter)
+//	repo := tracing.InstrumentRepository(gormRepo, tracer)
+//	handler, _ := handlers.New(repo, errhandler.New(), nil, nil)
+//
+//	router.Get("/users", tracing.Middleware(tracer, "/users", handler.List(User{})))
+//
+// The GenericHandler starts the child spans of the request span for the forms bindings
+// and the JSON encoding and records the resterrors of the error responses.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TraceID is the W3C trace identifier.
+type TraceID [16]byte
+
+// IsValid checks if the TraceID is not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is the W3C span (parent) identifier.
+type SpanID [8]byte
+
+// IsValid checks if the SpanID is not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext identifies the span within the trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid checks if both TraceID and SpanID are valid.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID.IsValid() && s.SpanID.IsValid()
+}
+
+// The span statuses.
+const (
+	StatusUnset = "Unset"
+	StatusError = "Error"
+)
+
+// Event is the timestamped annotation of the span.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]string
+}
+
+// SpanData is the snapshot of the ended span passed to the Exporter.
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanContext
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]string
+	Events        []Event
+	Status        string
+	StatusMessage string
+}
+
+// Span is the traced operation. All the Span methods are safe to use on nil Span,
+// which is returned when there is no tracer for the operation.
+type Span struct {
+	mu     sync.Mutex
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+}
+
+// SpanContext returns the context of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttribute sets the span attribute 'key' to the 'value'.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError adds the 'exception' event describing the 'err' and sets the span status to
+// StatusError. The dberrors.Error is described with its ID and prototype title and
+// the resterrors.Error with its code and status.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	attributes := map[string]string{"exception.message": err.Error()}
+	switch e := err.(type) {
+	case *dberrors.Error:
+		attributes["exception.type"] = "dberrors.Error"
+		attributes["dberrors.id"] = strconv.FormatUint(uint64(e.ID), 10)
+		attributes["dberrors.title"] = e.Title
+		if proto, err := e.GetPrototype(); err == nil {
+			attributes["dberrors.title"] = proto.Title
+		}
+	case *resterrors.Error:
+		attributes["exception.type"] = "resterrors.Error"
+		attributes["resterrors.code"] = e.Code
+		attributes["resterrors.status"] = e.Status
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Events = append(s.data.Events, Event{Name: "exception", Time: time.Now(), Attributes: attributes})
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End ends the span and exports it if it is sampled. Subsequent calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	data.Attributes = make(map[string]string, len(s.data.Attributes))
+	for key, value := range s.data.Attributes {
+		data.Attributes[key] = value
+	}
+	data.Events = append([]Event{}, s.data.Events...)
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// Tracer starts the spans exported by its Exporter.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates new Tracer exporting the spans with the 'exporter'.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// Start starts the span with given 'name'. The span is the child of the span in the 'ctx',
+// or of the remote span context set by the ContextWithRemoteSpanContext. Otherwise the span
+// starts new sampled trace. Returns the context containing the started span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	var parent SpanContext
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.SpanContext()
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		parent = remote
+	}
+
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		rand.Read(sc.TraceID[:])
+		sc.Sampled = true
+	}
+	rand.Read(sc.SpanID[:])
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			SpanContext: sc,
+			Parent:      parent,
+			Start:       time.Now(),
+			Attributes:  map[string]string{},
+			Status:      StatusUnset,
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// StartSpan starts the child span of the span in the 'ctx' using its Tracer.
+// If the 'ctx' contains no span the 'ctx' and nil Span are returned.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name)
+}
+
+// SpanFromContext returns the span from the 'ctx' or nil if the 'ctx' contains no span.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns the copy of the 'ctx' with the remote parent
+// span context 'sc', i.e. extracted from the request 'traceparent' header.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
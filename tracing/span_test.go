@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/kucjac/go-rest-sdk/dberrors"
+	"github.com/kucjac/go-rest-sdk/repository"
+	"github.com/kucjac/go-rest-sdk/repository/mockrepo"
+	"github.com/kucjac/go-rest-sdk/resterrors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+type Foo struct {
+	ID int
+}
+
+func TestTracer(t *testing.T) {
+	Convey("Subject: Tracer starts and exports the spans", t, func() {
+		exporter := NewInMemoryExporter()
+		tracer := NewTracer(exporter)
+
+		Convey("The child spans continue the trace of the parent span", func() {
+			ctx, root := tracer.Start(context.Background(), "root")
+			So(root.SpanContext().IsValid(), ShouldBeTrue)
+			So(SpanFromContext(ctx), ShouldEqual, root)
+
+			_, child := StartSpan(ctx, "child")
+			child.SetAttribute("key", "value")
+			child.End()
+			child.End()
+			root.End()
+
+			spans := exporter.Spans()
+			So(spans, ShouldHaveLength, 2)
+			So(spans[0].Name, ShouldEqual, "child")
+			So(spans[0].Attributes["key"], ShouldEqual, "value")
+			So(spans[0].Parent, ShouldResemble, root.SpanContext())
+			So(spans[0].SpanContext.TraceID, ShouldEqual, root.SpanContext().TraceID)
+			So(spans[1].Parent.IsValid(), ShouldBeFalse)
+
+			exporter.Reset()
+			So(exporter.Spans(), ShouldBeEmpty)
+		})
+
+		Convey("The spans are not started without the parent span", func() {
+			ctx, span := StartSpan(context.Background(), "span")
+			So(span, ShouldBeNil)
+			So(SpanFromContext(ctx), ShouldBeNil)
+
+			span.SetAttribute("key", "value")
+			span.RecordError(errors.New("error"))
+			span.End()
+		})
+
+		Convey("The errors are recorded with their codes", func() {
+			_, span := tracer.Start(context.Background(), "span")
+			span.RecordError(dberrors.ErrNoResult.NewWithMessage("not found"))
+			span.RecordError(resterrors.ErrResourceNotFound.New())
+			span.End()
+
+			data, ok := exporter.Span("span")
+			So(ok, ShouldBeTrue)
+			So(data.Status, ShouldEqual, StatusError)
+			So(data.Events, ShouldHaveLength, 2)
+			So(data.Events[0].Attributes["dberrors.id"], ShouldEqual, "2")
+			So(data.Events[0].Attributes["dberrors.title"], ShouldEqual, dberrors.ErrNoResult.Title)
+			So(data.Events[1].Attributes["resterrors.code"], ShouldEqual, resterrors.ErrResourceNotFound.Code)
+			So(data.Events[1].Attributes["resterrors.status"], ShouldEqual, "404")
+		})
+
+		Convey("The repository calls are traced", func() {
+			mock := &mockrepo.MockRepository{}
+			mock.On("Get", &Foo{ID: 1}).Return(nil, dberrors.ErrNoResult.New())
+			repo := InstrumentRepository(mock, tracer)
+
+			ctx, root := tracer.Start(context.Background(), "root")
+			repo.WithContext(ctx).Get(&Foo{ID: 1})
+			root.End()
+
+			get, ok := exporter.Span("Repository.Get")
+			So(ok, ShouldBeTrue)
+			So(get.Parent, ShouldResemble, root.SpanContext())
+			So(get.Attributes["db.model"], ShouldEqual, "Foo")
+			So(get.Status, ShouldEqual, StatusError)
+		})
+
+		Convey("The not supported calls are not traced", func() {
+			repo := InstrumentRepository(struct{ repository.Repository }{&mockrepo.MockRepository{}}, tracer)
+
+			ctx, root := tracer.Start(context.Background(), "root")
+			_, dbErr := repo.WithContext(ctx).(*Repository).Upsert(&Foo{ID: 1}, nil, nil)
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+			dbErr = repo.PatchFields(&Foo{}, &Foo{ID: 1}, []string{"ID"})
+			So(dbErr.Compare(dberrors.ErrNotSupported), ShouldBeTrue)
+			root.End()
+
+			So(exporter.Spans(), ShouldHaveLength, 1)
+		})
+	})
+}